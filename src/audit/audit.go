@@ -0,0 +1,183 @@
+// Package audit records security and admin actions, such as logins, password
+// resets and changes to users and roles, in a hash-chained log kept by
+// repository.AuditRepository. Services call Recorder.Record after an action;
+// who acted and from where comes from the request context, set by the
+// middleware through WithActor and WithClient.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/metrics"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Actions recorded in the log.
+const (
+	ActionLogin                = "auth.login"
+	ActionLoginFailed          = "auth.login_failed"
+	ActionPasswordResetRequest = "auth.password_reset_request"
+	ActionPasswordReset        = "auth.password_reset"
+	ActionUserCreate           = "user.create"
+	ActionUserUpdate           = "user.update"
+	ActionUserDelete           = "user.delete"
+	ActionUserRestore          = "user.restore"
+	ActionUserPurge            = "user.purge"
+	ActionUserRoles            = "user.roles"
+	ActionRoleCreate           = "role.create"
+	ActionRoleUpdate           = "role.update"
+	ActionRoleDelete           = "role.delete"
+	ActionPermissionCreate     = "permission.create"
+	ActionPermissionDelete     = "permission.delete"
+)
+
+// Types of the records an action applies to.
+const (
+	TargetUser       = "user"
+	TargetRole       = "role"
+	TargetPermission = "permission"
+)
+
+// Actor is who performed an action: a user, or a tool used outside the API,
+// which has no Id.
+type Actor struct {
+	Id   int
+	Name string
+}
+
+// UserActor returns the actor for user.
+func UserActor(user *models.User) *Actor {
+	return &Actor{Id: user.Id, Name: user.Email}
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx in which actor performs the actions.
+func WithActor(ctx context.Context, actor *Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor of ctx, or nil when there is none.
+func ActorFrom(ctx context.Context) *Actor {
+	actor, _ := ctx.Value(actorKey{}).(*Actor)
+	return actor
+}
+
+type client struct {
+	ip        string
+	userAgent string
+}
+
+type clientKey struct{}
+
+// WithClient returns a copy of ctx carrying the IP address and user agent of
+// the client that made the request.
+func WithClient(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client{ip: ip, userAgent: userAgent})
+}
+
+// Event describes one action.
+type Event struct {
+	Action string
+	// Actor overrides the actor of the context, for actions such as logins
+	// that are performed before there is one.
+	Actor      *Actor
+	TargetType string
+	TargetId   int
+	// Changes are the fields the action changed, see Diff.
+	Changes Changes
+	// Reason explains a failure, e.g. "wrong password".
+	Reason string
+}
+
+// Recorder records events in the audit log.
+type Recorder interface {
+	Record(ctx context.Context, event Event)
+}
+
+type recorder struct {
+	repo repository.AuditRepository
+}
+
+// NewRecorder returns a Recorder appending to repo.
+func NewRecorder(repo repository.AuditRepository) *recorder {
+	return &recorder{repo: repo}
+}
+
+// Record appends event with the actor, client and request ID of ctx. A
+// failure to write is logged and counted rather than returned, since the
+// action it describes has already happened. The write outlives ctx, so a
+// client hanging up does not drop the entry.
+func (r *recorder) Record(ctx context.Context, event Event) {
+	var err error
+	ctx, span := tracing.Start(context.WithoutCancel(ctx), "audit.record", attribute.String("audit.action", event.Action))
+	defer tracing.End(span, &err)
+
+	entry := models.AuditLog{
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		Reason:     event.Reason,
+		RequestId:  logger.RequestID(ctx),
+		CreatedAt:  time.Now().Truncate(time.Millisecond),
+	}
+	if event.TargetId != 0 {
+		entry.TargetId = strconv.Itoa(event.TargetId)
+	}
+
+	actor := event.Actor
+	if actor == nil {
+		actor = ActorFrom(ctx)
+	}
+	if actor != nil {
+		if actor.Id != 0 {
+			entry.ActorId = &actor.Id
+		}
+		entry.Actor = actor.Name
+	}
+
+	if c, ok := ctx.Value(clientKey{}).(client); ok {
+		entry.IP = c.ip
+		entry.UserAgent = truncate(c.userAgent, 255)
+	}
+
+	if len(event.Changes) > 0 {
+		changes, marshalErr := json.Marshal(event.Changes)
+		if marshalErr != nil {
+			err = marshalErr
+		}
+		entry.Changes = string(changes)
+	}
+
+	if err == nil {
+		err = r.repo.Append(ctx, &entry)
+	}
+	metrics.AuditEntry(event.Action, err)
+	if err != nil {
+		logger.FromContext(ctx).Error("recording audit entry failed",
+			"action", entry.Action,
+			"actor", entry.Actor,
+			"target_type", entry.TargetType,
+			"target_id", entry.TargetId,
+			"error", err,
+		)
+	}
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces the values of secret fields in Changes.
+const Redacted = "[redacted]"
+
+// secretFields are the substrings that mark a field as secret.
+var secretFields = []string{"password", "secret", "token", "code", "otp"}
+
+// Change is the value of a field before and after an action. The value is
+// nil on the side where the field did not exist.
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// Changes maps field names to their changes.
+type Changes map[string]Change
+
+// Snapshot is the state of a record as field names and values.
+type Snapshot map[string]any
+
+// Diff returns the fields that differ between before and after, either of
+// which may be nil for a record that is created or deleted. Secret fields,
+// such as password hashes, show that they changed but not their values.
+func Diff(before, after Snapshot) Changes {
+	changes := Changes{}
+	for field, from := range before {
+		to, ok := after[field]
+		if !ok || !reflect.DeepEqual(from, to) {
+			changes[field] = redact(field, Change{From: from, To: to})
+		}
+	}
+	for field, to := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = redact(field, Change{To: to})
+		}
+	}
+	return changes
+}
+
+func redact(field string, change Change) Change {
+	if !isSecret(field) {
+		return change
+	}
+	if change.From != nil {
+		change.From = Redacted
+	}
+	if change.To != nil {
+		change.To = Redacted
+	}
+	return change
+}
+
+func isSecret(field string) bool {
+	field = strings.ToLower(field)
+	for _, secret := range secretFields {
+		if strings.Contains(field, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+)
+
+// errBroken stops Verify at the first broken entry.
+var errBroken = errors.New("audit chain is broken")
+
+// Verification is the result of Verify.
+type Verification struct {
+	// Entries is the number of entries checked.
+	Entries int64
+	// HeadId and HeadHash identify the last entry checked. Since removing
+	// entries from the end leaves a valid chain, keeping them somewhere the
+	// database cannot reach makes such a cut show.
+	HeadId   int64
+	HeadHash string
+	// BrokenAt is the Id of the first entry that fails a check, or 0 when the
+	// chain is intact, and Problem says what is wrong with it.
+	BrokenAt int64
+	Problem  string
+}
+
+// Valid reports whether the whole chain checked out.
+func (v *Verification) Valid() bool {
+	return v.BrokenAt == 0
+}
+
+// Verify walks the audit log from the start and checks that no entry is
+// missing, that each links to the hash of the one before, and that each hash
+// matches the contents of its entry. It stops at the first broken entry.
+func Verify(ctx context.Context, repo repository.AuditRepository) (*Verification, error) {
+	result := &Verification{}
+	var prev models.AuditLog
+
+	err := repo.Each(ctx, repository.AuditFilter{}, func(entry *models.AuditLog) error {
+		switch {
+		case entry.Id != prev.Id+1:
+			result.Problem = fmt.Sprintf("entries %d to %d are missing", prev.Id+1, entry.Id-1)
+		case entry.PrevHash != prev.Hash:
+			result.Problem = "prev_hash does not match the hash of the entry before"
+		case entry.Hash != entry.ChainHash():
+			result.Problem = "hash does not match the contents of the entry"
+		default:
+			result.Entries++
+			result.HeadId = entry.Id
+			result.HeadHash = entry.Hash
+			prev = *entry
+			return nil
+		}
+		result.BrokenAt = entry.Id
+		return errBroken
+	})
+	if err != nil && !errors.Is(err, errBroken) {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/repository"
+
+	"gorm.io/gorm"
+)
+
+// cliActor is the actor of the changes made with these commands.
+var cliActor = &audit.Actor{Name: "cli"}
+
+var auditCommand = command{
+	name:    "audit",
+	usage:   "verify",
+	summary: "check the audit log",
+	commands: []command{
+		{
+			name:    "verify",
+			summary: "check the hash chain of the audit log and print its head",
+			run:     runAuditVerify,
+		},
+	},
+}
+
+func runAuditVerify(a *app, args []string) error {
+	flags := a.flagSet("audit verify", "")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	result, err := audit.Verify(context.Background(), repository.NewAuditRepository(db))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "checked %d entries, head %d %s\n", result.Entries, result.HeadId, result.HeadHash)
+	if !result.Valid() {
+		return fmt.Errorf("audit log is broken at entry %d: %s", result.BrokenAt, result.Problem)
+	}
+	return nil
+}
+
+// recordAudit records event as done by cliActor.
+func recordAudit(db *gorm.DB, event audit.Event) {
+	event.Actor = cliActor
+	audit.NewRecorder(repository.NewAuditRepository(db)).Record(context.Background(), event)
+}
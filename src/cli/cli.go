@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"restApi-GoGin/src/config"
+
+	"gorm.io/gorm"
+)
+
+// command is a subcommand. Commands with subcommands of their own, like
+// "user", set commands instead of run.
+type command struct {
+	name     string
+	usage    string
+	summary  string
+	run      func(a *app, args []string) error
+	commands []command
+}
+
+type app struct {
+	in       io.Reader
+	out      io.Writer
+	commands []command
+}
+
+// NewApp builds the management CLI. Passwords not given as flags are read
+// from in; output meant for the operator, such as tables and dry-run SQL, is
+// written to out.
+func NewApp(in io.Reader, out io.Writer) *app {
+	return &app{
+		in:  in,
+		out: out,
+		commands: []command{
+			serveCommand,
+			migrateCommand,
+			seedCommand,
+			userCommand,
+			jobsCommand,
+			auditCommand,
+			routesCommand,
+			configCommand,
+		},
+	}
+}
+
+// Run runs the subcommand named by args[0]. Without arguments it starts the
+// server, so the binary keeps working when started without a command.
+func (a *app) Run(args []string) error {
+	if len(args) == 0 {
+		return runServe(a, nil)
+	}
+
+	return a.dispatch("", a.commands, args)
+}
+
+func (a *app) dispatch(prefix string, commands []command, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		a.printUsage(prefix, commands)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		if cmd.commands != nil {
+			return a.dispatch(prefix+cmd.name+" ", cmd.commands, args[1:])
+		}
+		return cmd.run(a, args[1:])
+	}
+
+	a.printUsage(prefix, commands)
+	return fmt.Errorf("unknown command %q", strings.TrimSpace(prefix+args[0]))
+}
+
+func (a *app) printUsage(prefix string, commands []command) {
+	fmt.Fprintf(a.out, "Usage: app %s<command> [flags]\n\nCommands:\n", prefix)
+	defer fmt.Fprintf(a.out, "\nRun \"app %s<command> -h\" for the flags of a command.\n", prefix)
+	for _, cmd := range commands {
+		fmt.Fprintf(a.out, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// flagSet returns a flag set for a subcommand whose errors and usage are
+// written to the app's output.
+func (a *app) flagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(a.out)
+	flags.Usage = func() {
+		fmt.Fprintf(a.out, "Usage: app %s %s\n", name, usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses args, turning -h into a successful no-op.
+func parseFlags(flags *flag.FlagSet, args []string) (bool, error) {
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// connect loads the configuration and opens the database.
+func connect() (*gorm.DB, error) {
+	if err := config.LoadConfig(); err != nil {
+		return nil, err
+	}
+	return config.LoadDatabase(), nil
+}
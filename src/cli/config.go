@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"restApi-GoGin/src/config"
+)
+
+var configCommand = command{
+	name:    "config",
+	summary: "validate and print the configuration with secrets redacted",
+	run:     runConfig,
+}
+
+func runConfig(a *app, args []string) error {
+	flags := a.flagSet("config", "")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+
+	if err := config.LoadConfig(); err != nil {
+		return err
+	}
+
+	table := newTable(a.out, "KEY", "VALUE")
+	for _, setting := range config.ENV.Settings() {
+		table.row(setting.Key, setting.Value)
+	}
+	return table.flush()
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/jobs"
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	jobsListUsage    = "[-status pending|dead]"
+	jobsEnqueueUsage = "[-payload JSON] [-delay DURATION] NAME"
+	jobsRunUsage     = "NAME"
+)
+
+var jobsCommand = command{
+	name:    "jobs",
+	usage:   "list | enqueue | run",
+	summary: "inspect and run background jobs",
+	commands: []command{
+		{
+			name:    "list",
+			usage:   jobsListUsage,
+			summary: "list pending and dead jobs",
+			run:     runJobsList,
+		},
+		{
+			name:    "enqueue",
+			usage:   jobsEnqueueUsage,
+			summary: "queue a job for the workers of the running servers",
+			run:     runJobsEnqueue,
+		},
+		{
+			name:    "run",
+			usage:   jobsRunUsage,
+			summary: "run a job now in this process, e.g. purge_deleted_users",
+			run:     runJobsRun,
+		},
+	},
+}
+
+func runJobsList(a *app, args []string) error {
+	flags := a.flagSet("jobs list", jobsListUsage)
+	status := flags.String("status", "", "only jobs with this status")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	if *status != "" && *status != models.JobPending && *status != models.JobDead {
+		return fmt.Errorf("status must be %s or %s, got %q", models.JobPending, models.JobDead, *status)
+	}
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	list, err := repository.NewJobRepository(db).List(context.Background(), *status)
+	if err != nil {
+		return err
+	}
+
+	table := newTable(a.out, "ID", "NAME", "STATUS", "ATTEMPTS", "RUN AT", "LAST ERROR")
+	for _, job := range list {
+		table.row(
+			fmt.Sprint(job.Id),
+			job.Name,
+			job.Status,
+			fmt.Sprintf("%d/%d", job.Attempts, job.MaxAttempts),
+			job.RunAt.Format("2006-01-02 15:04:05"),
+			job.LastError,
+		)
+	}
+	return table.flush()
+}
+
+func runJobsEnqueue(a *app, args []string) error {
+	flags := a.flagSet("jobs enqueue", jobsEnqueueUsage)
+	payload := flags.String("payload", "", "JSON passed to the job")
+	delay := flags.Duration("delay", 0, "run the job after this long")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one job name")
+	}
+	name := flags.Arg(0)
+
+	var data any
+	if *payload != "" {
+		if !json.Valid([]byte(*payload)) {
+			return fmt.Errorf("payload must be JSON")
+		}
+		data = json.RawMessage(*payload)
+	}
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	if _, err := builtinHandler(db, name, a.out); err != nil {
+		return err
+	}
+
+	queue := jobs.NewQueue(repository.NewJobRepository(db), config.ENV.JOB_MAX_ATTEMPTS)
+	if _, err := queue.Enqueue(context.Background(), name, data, jobs.EnqueueOptions{RunAt: time.Now().Add(*delay)}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "enqueued %s\n", name)
+	return nil
+}
+
+func runJobsRun(a *app, args []string) error {
+	flags := a.flagSet("jobs run", jobsRunUsage)
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one job name")
+	}
+	name := flags.Arg(0)
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	handler, err := builtinHandler(db, name, a.out)
+	if err != nil {
+		return err
+	}
+
+	return handler(context.Background(), nil)
+}
+
+// builtinHandler returns the handler of the built-in job name, logging to
+// out.
+func builtinHandler(db *gorm.DB, name string, out io.Writer) (jobs.Handler, error) {
+	registry := jobs.NewRegistry()
+	log := logger.New(out, config.ENV.LOG_FORMAT, config.ENV.LOG_LEVEL)
+	jobs.RegisterBuiltins(registry, repository.NewUserRepository(db), repository.NewSessionRepository(db), config.ENV.DELETED_USER_RETENTION, log)
+
+	handler, ok := registry.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown job %q, expected one of %s", name, strings.Join(registry.Names(), ", "))
+	}
+	return handler, nil
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/migration"
+)
+
+const migrateUsage = "[-dry-run] up | down [N] | to VERSION | status"
+
+var migrateCommand = command{
+	name:    "migrate",
+	usage:   migrateUsage,
+	summary: "apply, roll back or list schema migrations",
+	run:     runMigrate,
+}
+
+func runMigrate(a *app, args []string) error {
+	flags := a.flagSet("migrate", migrateUsage)
+	dryRun := flags.Bool("dry-run", false, "print the SQL that would run without changing the database")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing migrate command")
+	}
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	migrator, err := migration.NewMigrator(db, a.out)
+	if err != nil {
+		return err
+	}
+	migrator.DryRun = *dryRun
+
+	switch args[0] {
+	case "up":
+		if *dryRun {
+			return migrator.Up()
+		}
+		return config.RunMigration(db)
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[1])
+			}
+		}
+		return migrator.Down(n)
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("missing version")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return migrator.To(version)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		table := newTable(a.out, "VERSION", "NAME", "APPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			table.row(fmt.Sprintf("%06d", status.Version), status.Name, appliedAt)
+		}
+		return table.flush()
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
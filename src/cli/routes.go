@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/routes"
+
+	"github.com/gin-gonic/gin"
+)
+
+var routesCommand = command{
+	name:    "routes",
+	summary: "list the registered HTTP routes",
+	run:     runRoutes,
+}
+
+func runRoutes(a *app, args []string) error {
+	flags := a.flagSet("routes", "")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+
+	// Release mode keeps gin from printing every route as it is registered.
+	mode := gin.Mode()
+	gin.SetMode(gin.ReleaseMode)
+	router := routes.NewRouter(logger.New(io.Discard, logger.FormatText, "error"))
+	gin.SetMode(mode)
+
+	registered := router.Routes()
+	sort.Slice(registered, func(i, j int) bool {
+		if registered[i].Path != registered[j].Path {
+			return registered[i].Path < registered[j].Path
+		}
+		return registered[i].Method < registered[j].Method
+	})
+
+	table := newTable(a.out, "METHOD", "PATH", "HANDLER")
+	for _, route := range registered {
+		table.row(route.Method, route.Path, route.Handler)
+	}
+	return table.flush()
+}
+
+// table writes tab-aligned columns.
+type table struct {
+	w *tabwriter.Writer
+}
+
+func newTable(out io.Writer, headers ...string) *table {
+	t := &table{w: tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)}
+	t.row(headers...)
+	return t
+}
+
+func (t *table) row(columns ...string) {
+	fmt.Fprintln(t.w, strings.Join(columns, "\t"))
+}
+
+func (t *table) flush() error {
+	return t.w.Flush()
+}
@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/utils"
+
+	"gorm.io/gorm"
+)
+
+// defaultFixtures are seeded when no fixture file is named.
+const defaultFixtures = "fixtures/*.json"
+
+const seedUsage = "[FILE...]"
+
+var seedCommand = command{
+	name:    "seed",
+	usage:   seedUsage,
+	summary: "create the permissions, roles and users in fixture files",
+	run:     runSeed,
+}
+
+// fixture is the content of a seed file. Records that already exist, matched
+// by name or email, are left unchanged.
+type fixture struct {
+	Permissions []fixturePermission `json:"permissions"`
+	Roles       []fixtureRole       `json:"roles"`
+	Users       []newUser           `json:"users"`
+}
+
+type fixturePermission struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+type fixtureRole struct {
+	Name        string   `json:"name" validate:"required,max=100"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+// newUser is a user created by the seed or user create commands.
+type newUser struct {
+	Name     string   `json:"name" validate:"required"`
+	Email    string   `json:"email" validate:"required,email"`
+	Password string   `json:"password" validate:"required,min=6"`
+	Roles    []string `json:"roles" validate:"required,min=1"`
+}
+
+func runSeed(a *app, args []string) error {
+	flags := a.flagSet("seed", seedUsage)
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files, _ = filepath.Glob(defaultFixtures)
+		if len(files) == 0 {
+			return fmt.Errorf("no fixture files found in %s", defaultFixtures)
+		}
+	}
+
+	fixtures := make([]fixture, 0, len(files))
+	for _, file := range files {
+		f, err := readFixture(file)
+		if err != nil {
+			return err
+		}
+		fixtures = append(fixtures, *f)
+	}
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i, f := range fixtures {
+			if err := a.seed(tx, f); err != nil {
+				return fmt.Errorf("%s: %w", files[i], err)
+			}
+		}
+		return nil
+	})
+}
+
+func readFixture(file string) (*fixture, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var f fixture
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	for _, permission := range f.Permissions {
+		if err := utils.Validator.Struct(permission); err != nil {
+			return nil, fmt.Errorf("%s: permission %q: %w", file, permission.Name, err)
+		}
+	}
+	for _, role := range f.Roles {
+		if err := utils.Validator.Struct(role); err != nil {
+			return nil, fmt.Errorf("%s: role %q: %w", file, role.Name, err)
+		}
+	}
+	for _, user := range f.Users {
+		if err := utils.Validator.Struct(user); err != nil {
+			return nil, fmt.Errorf("%s: user %q: %w", file, user.Email, err)
+		}
+	}
+
+	return &f, nil
+}
+
+func (a *app) seed(tx *gorm.DB, f fixture) error {
+	permissionRepository := repository.NewPermissionRepository(tx)
+	roleRepository := repository.NewRoleRepository(tx)
+	userRepository := repository.NewUserRepository(tx)
+
+	for _, p := range f.Permissions {
+		existing, err := permissionRepository.GetByNames([]string{p.Name})
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			continue
+		}
+
+		if err := permissionRepository.Create(&models.Permission{Name: p.Name, Description: p.Description}); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "created permission %s\n", p.Name)
+	}
+
+	for _, r := range f.Roles {
+		existing, err := roleRepository.GetByNames([]string{r.Name})
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			continue
+		}
+
+		permissions, err := permissionRepository.GetByNames(r.Permissions)
+		if err != nil {
+			return err
+		}
+		if len(permissions) != len(r.Permissions) {
+			return fmt.Errorf("role %s grants a permission that does not exist", r.Name)
+		}
+
+		role := &models.Role{Name: r.Name, Description: r.Description, Permissions: permissions}
+		if err := roleRepository.Create(role); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "created role %s\n", r.Name)
+	}
+
+	for _, u := range f.Users {
+		existing, err := userRepository.GetUserByEmail(context.Background(), u.Email)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		if _, err := createUser(tx, u); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "created user %s\n", u.Email)
+	}
+
+	return nil
+}
+
+// createUser creates a user with the given roles. The email address counts
+// as verified since the operator running the command vouches for it.
+func createUser(db *gorm.DB, u newUser) (*models.User, error) {
+	if err := utils.Validator.Struct(u); err != nil {
+		return nil, err
+	}
+
+	userRepository := repository.NewUserRepository(db)
+	roleRepository := repository.NewRoleRepository(db)
+
+	existing, err := userRepository.GetUserByEmail(context.Background(), u.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("user %s already exists", u.Email)
+	}
+
+	roles, err := roleRepository.GetByNames(u.Roles)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(u.Roles) {
+		return nil, fmt.Errorf("user %s has a role that does not exist", u.Email)
+	}
+
+	passwordHash, err := utils.HashBcrypt(u.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Name:            u.Name,
+		Email:           u.Email,
+		Password:        passwordHash,
+		Roles:           roles,
+		EmailVerifiedAt: &now,
+	}
+	if err := userRepository.CreateUser(context.Background(), user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/health"
+	"restApi-GoGin/src/jobs"
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/mail"
+	"restApi-GoGin/src/metrics"
+	"restApi-GoGin/src/outbox"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/routes"
+	"restApi-GoGin/src/server"
+	"restApi-GoGin/src/tracing"
+	"restApi-GoGin/src/utils"
+
+	"gorm.io/gorm"
+)
+
+const serveUsage = "[-migrate=false]"
+
+var serveCommand = command{
+	name:    "serve",
+	usage:   serveUsage,
+	summary: "apply pending migrations and serve HTTP until SIGINT or SIGTERM",
+	run:     runServe,
+}
+
+func runServe(a *app, args []string) error {
+	flags := a.flagSet("serve", serveUsage)
+	migrate := flags.Bool("migrate", true, "apply pending migrations before serving")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+
+	if err := config.LoadConfig(); err != nil {
+		return err
+	}
+	log := logger.New(os.Stderr, config.ENV.LOG_FORMAT, config.ENV.LOG_LEVEL)
+	logger.SetDefault(log)
+	log.Info("loaded configuration", "app_env", config.ENV.APP_ENV, "config", config.ENV)
+
+	err := utils.LoadJWTKeys(utils.JWTKeyOptions{
+		AccessSecret:     config.ENV.ACCESS_SECRET,
+		RefreshSecret:    config.ENV.REFRESH_SECRET,
+		SigningKeyFile:   config.ENV.JWT_SIGNING_KEY_FILE,
+		SigningKeyId:     config.ENV.JWT_SIGNING_KEY_ID,
+		VerificationKeys: config.ENV.JWT_VERIFICATION_KEYS,
+	})
+	if err != nil {
+		return fmt.Errorf("loading JWT keys: %w", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    config.ENV.TRACING_EXPORTER,
+		ServiceName: config.ENV.OTEL_SERVICE_NAME,
+		Endpoint:    config.ENV.OTEL_EXPORTER_OTLP_ENDPOINT,
+		SampleRatio: config.ENV.TRACING_SAMPLE_RATIO,
+		Stdout:      os.Stdout,
+	})
+	if err != nil {
+		return fmt.Errorf("setting up tracing: %w", err)
+	}
+
+	db := config.LoadDatabase()
+	if *migrate {
+		if err := config.RunMigration(db); err != nil {
+			return fmt.Errorf("running migrations: %w", err)
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := metrics.InstrumentDB(db); err != nil {
+		return fmt.Errorf("instrumenting database: %w", err)
+	}
+	if err := tracing.InstrumentDB(db); err != nil {
+		return fmt.Errorf("instrumenting database: %w", err)
+	}
+
+	stopOutbox, err := setupMail(db, log)
+	if err != nil {
+		return fmt.Errorf("setting up mail: %w", err)
+	}
+
+	stopJobs, err := setupJobs(db, log)
+	if err != nil {
+		return fmt.Errorf("setting up jobs: %w", err)
+	}
+
+	registerHealthChecks(db)
+
+	srv := server.NewServer(fmt.Sprintf(":%v", config.ENV.PORT), routes.NewRouter(log), server.TimeoutsFromConfig(config.ENV), log)
+	// Registered first so it runs last and exports the spans of the other
+	// closers.
+	srv.OnShutdown("tracing", shutdownTracing)
+	srv.OnShutdown("database", func(context.Context) error {
+		return sqlDB.Close()
+	})
+	srv.OnShutdown("mail outbox", stopOutbox)
+	srv.OnShutdown("jobs", stopJobs)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Info("listening", "port", config.ENV.PORT)
+	if err := srv.ListenAndServe(ctx); err != nil {
+		return err
+	}
+	log.Info("shut down cleanly")
+	return nil
+}
+
+// registerHealthChecks adds the checks of the core dependencies to /readyz.
+// The mail server is optional: while it is down mail waits, but the API
+// still serves.
+func registerHealthChecks(db *gorm.DB) {
+	cfg := config.Current()
+	timeout := cfg.HEALTH_CHECK_TIMEOUT
+
+	health.Register("database", health.Database(db), health.Options{Timeout: timeout})
+	health.Register("migrations", health.Migrations(db), health.Options{Timeout: timeout})
+	if cfg.MailDriver() == mail.DriverSMTP {
+		addr := net.JoinHostPort(cfg.SMTP_HOST, cfg.SMTP_PORT)
+		health.Register("mail", health.SMTP(addr, cfg.SMTP_TLS == mail.TLSImplicit), health.Options{Timeout: timeout, Optional: true})
+	}
+}
+
+// setupMail makes mail.Default queue mail in the outbox and starts the
+// worker that delivers it with the mailer of the MAIL_* and SMTP_* settings.
+// The returned function stops the worker once its deliveries in progress
+// are done.
+func setupMail(db *gorm.DB, log *slog.Logger) (func(context.Context) error, error) {
+	cfg := config.Current()
+	mailer, err := mail.New(mail.Options{
+		Driver: cfg.MailDriver(),
+		From:   cfg.MailFrom(),
+		SMTP: mail.SMTPOptions{
+			Host:     cfg.SMTP_HOST,
+			Port:     cfg.SMTP_PORT,
+			Username: cfg.SMTP_EMAIL,
+			Password: cfg.SMTP_PASSWORD,
+			TLS:      cfg.SMTP_TLS,
+		},
+		Dir: cfg.MAIL_DIR,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := mail.LoadTemplates(cfg.MAIL_LOCALE)
+	if err != nil {
+		return nil, err
+	}
+
+	outboxRepository := repository.NewOutboxRepository(db)
+	mail.SetDefault(outbox.NewSender(outboxRepository, templates))
+
+	worker := outbox.NewWorker(outboxRepository, mailer, outbox.OptionsFromConfig(cfg), log.With("component", "outbox"))
+	return background(worker.Run), nil
+}
+
+// setupJobs starts the job worker with the built-in jobs, and the scheduler
+// that enqueues them on the SCHEDULE_* settings. The returned function stops
+// both once the jobs in progress are done.
+func setupJobs(db *gorm.DB, log *slog.Logger) (func(context.Context) error, error) {
+	cfg := config.Current()
+	options := jobs.OptionsFromConfig(cfg)
+	log = log.With("component", "jobs")
+
+	registry := jobs.NewRegistry()
+	jobs.RegisterBuiltins(registry, repository.NewUserRepository(db), repository.NewSessionRepository(db), cfg.DELETED_USER_RETENTION, log)
+
+	jobRepository := repository.NewJobRepository(db)
+	queue := jobs.NewQueue(jobRepository, options.MaxAttempts)
+	scheduler := jobs.NewScheduler(jobRepository, queue, repository.NewTransactor(db), options.PollInterval, log)
+	for name, spec := range jobs.BuiltinSchedules(cfg) {
+		if err := scheduler.Add(name, spec); err != nil {
+			return nil, err
+		}
+	}
+
+	worker := jobs.NewWorker(jobRepository, registry, options, log)
+	return background(worker.Run, scheduler.Run), nil
+}
+
+// background starts each of runs in a goroutine. The returned function
+// cancels their context and waits for them to return, or for its own
+// context to end.
+func background(runs ...func(context.Context)) func(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, run := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(ctx)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	return func(shutdownCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+
+	"golang.org/x/term"
+)
+
+const (
+	userCreateUsage        = "-name NAME -email EMAIL [-role ROLE] [-password PASSWORD]"
+	userResetPasswordUsage = "-email EMAIL [-password PASSWORD]"
+	userListUsage          = "[-role ROLE] [-email EMAIL] [-status active|deleted|all] [-sort FIELDS] [-page N] [-per-page N]"
+)
+
+var userCommand = command{
+	name:    "user",
+	usage:   "create | reset-password | list",
+	summary: "manage user accounts",
+	commands: []command{
+		{
+			name:    "create",
+			usage:   userCreateUsage,
+			summary: "create a user, e.g. the first admin with -role admin",
+			run:     runUserCreate,
+		},
+		{
+			name:    "reset-password",
+			usage:   userResetPasswordUsage,
+			summary: "set a user's password and sign them out everywhere",
+			run:     runUserResetPassword,
+		},
+		{
+			name:    "list",
+			usage:   userListUsage,
+			summary: "list users",
+			run:     runUserList,
+		},
+	},
+}
+
+func runUserCreate(a *app, args []string) error {
+	flags := a.flagSet("user create", userCreateUsage)
+	name := flags.String("name", "", "display name")
+	email := flags.String("email", "", "email address")
+	role := flags.String("role", models.RoleUser, "comma-separated roles")
+	password := flags.String("password", "", "password; read from standard input when omitted")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+
+	if *password == "" {
+		var err error
+		if *password, err = a.readPassword(); err != nil {
+			return err
+		}
+	}
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	user, err := createUser(db, newUser{
+		Name:     *name,
+		Email:    *email,
+		Password: *password,
+		Roles:    splitList(*role),
+	})
+	if err != nil {
+		return err
+	}
+	recordAudit(db, audit.Event{
+		Action:     audit.ActionUserCreate,
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+		Changes: audit.Diff(nil, audit.Snapshot{
+			"name":     user.Name,
+			"email":    user.Email,
+			"roles":    user.RoleNames(),
+			"password": user.Password,
+		}),
+	})
+
+	fmt.Fprintf(a.out, "created user %d %s with roles %s\n", user.Id, user.Email, strings.Join(user.RoleNames(), ", "))
+	return nil
+}
+
+func runUserResetPassword(a *app, args []string) error {
+	flags := a.flagSet("user reset-password", userResetPasswordUsage)
+	email := flags.String("email", "", "email address")
+	password := flags.String("password", "", "new password; read from standard input when omitted")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+
+	if *password == "" {
+		var err error
+		if *password, err = a.readPassword(); err != nil {
+			return err
+		}
+	}
+	if err := utils.Validator.Var(*password, "required,min=6"); err != nil {
+		return fmt.Errorf("password must be at least 6 characters")
+	}
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	userRepository := repository.NewUserRepository(db)
+	sessionRepository := repository.NewSessionRepository(db)
+
+	user, err := userRepository.GetUserByEmail(context.Background(), *email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user %s not found", *email)
+	}
+
+	passwordHash, err := utils.HashBcrypt(*password)
+	if err != nil {
+		return err
+	}
+
+	previousHash := user.Password
+	user.Password = passwordHash
+	user.ResetToken = nil
+	user.ResetTokenExp = nil
+	if err := userRepository.UpdateUser(context.Background(), user); err != nil {
+		return err
+	}
+
+	// Like a password reset by email, this signs the user out everywhere.
+	if err := sessionRepository.RevokeAllByUser(context.Background(), user.Id, ""); err != nil {
+		return err
+	}
+	recordAudit(db, audit.Event{
+		Action:     audit.ActionPasswordReset,
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+		Changes:    audit.Diff(audit.Snapshot{"password": previousHash}, audit.Snapshot{"password": user.Password}),
+	})
+
+	fmt.Fprintf(a.out, "reset password of %s\n", user.Email)
+	return nil
+}
+
+func runUserList(a *app, args []string) error {
+	query := dto.UserListQuery{}
+	flags := a.flagSet("user list", userListUsage)
+	flags.StringVar(&query.Role, "role", "", "only users with this role")
+	flags.StringVar(&query.Email, "email", "", "only emails containing this text")
+	flags.StringVar(&query.Status, "status", "", "active, deleted or all")
+	flags.StringVar(&query.Sort, "sort", "id", "sort fields, e.g. -created_at,name")
+	flags.IntVar(&query.Page, "page", 1, "page number")
+	flags.IntVar(&query.PerPage, "per-page", 50, "users per page")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+
+	if err := utils.Validator.Struct(query); err != nil {
+		return err
+	}
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	userService := services.NewUserService(repository.NewUserRepository(db), repository.NewRoleRepository(db), repository.NewTransactor(db), audit.NewRecorder(repository.NewAuditRepository(db)))
+	users, paginate, err := userService.GetAllUsers(context.Background(), &query)
+	if err != nil {
+		return err
+	}
+
+	table := newTable(a.out, "ID", "NAME", "EMAIL", "ROLES", "VERIFIED", "CREATED AT")
+	for _, user := range users {
+		verified := "no"
+		if user.EmailVerifiedAt != nil {
+			verified = "yes"
+		}
+		table.row(
+			fmt.Sprint(user.Id),
+			user.Name,
+			user.Email,
+			strings.Join(user.RoleNames(), ","),
+			verified,
+			user.CreatedAt.Format("2006-01-02 15:04"),
+		)
+	}
+	if err := table.flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "page %d of %d, %d users\n", paginate.Page, paginate.TotalPage, paginate.Total)
+	return nil
+}
+
+// readPassword reads a password from the app's input. A terminal does not
+// echo it; piped input is read up to the end of its first line.
+func (a *app) readPassword() (string, error) {
+	fmt.Fprint(a.out, "Password: ")
+	if file, ok := a.in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		password, err := term.ReadPassword(int(file.Fd()))
+		fmt.Fprintln(a.out)
+		if err != nil {
+			return "", fmt.Errorf("reading password: %w", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := bufio.NewReader(a.in).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
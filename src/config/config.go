@@ -1,34 +1,504 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
 
+	"restApi-GoGin/src/cron"
+
+	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
 )
 
+// Config holds every setting of the application. Each field is read from
+// the environment variable of the same name. Fields tagged secret are
+// redacted when the config is printed.
 type Config struct {
-	PORT        string
-	DB_USERNAME string
-	DB_PASSWORD string
-	DB_URL      string
-	DB_DATABASE string
+	APP_ENV                     string `validate:"oneof=development test production"`
+	PORT                        string `validate:"required,numeric"`
+	DB_DRIVER                   string `validate:"oneof=mysql postgres sqlite"`
+	DB_DSN                      string `secret:"true"`
+	DB_USERNAME                 string
+	DB_PASSWORD                 string `secret:"true"`
+	DB_URL                      string
+	DB_DATABASE                 string
+	DB_TIMEZONE                 string
+	DB_SSLMODE                  string
+	ACCESS_SECRET               string `secret:"true"`
+	REFRESH_SECRET              string `secret:"true"`
+	JWT_SIGNING_KEY_FILE        string
+	JWT_SIGNING_KEY_ID          string
+	JWT_VERIFICATION_KEYS       string
+	ACCESS_TOKEN_TTL            time.Duration `validate:"gt=0"`
+	REFRESH_TOKEN_TTL           time.Duration `validate:"gtfield=ACCESS_TOKEN_TTL"`
+	MFA_TOKEN_TTL               time.Duration `validate:"gt=0"`
+	OTP_TTL                     time.Duration `validate:"gt=0"`
+	RESET_TOKEN_TTL             time.Duration `validate:"gt=0"`
+	COOKIE_DOMAIN               string
+	COOKIE_SECURE               bool
+	CORS_ALLOWED_ORIGINS        string
+	SMTP_HOST                   string
+	SMTP_PORT                   string `validate:"omitempty,numeric"`
+	SMTP_EMAIL                  string `validate:"omitempty,email"`
+	SMTP_PASSWORD               string `secret:"true"`
+	SMTP_TLS                    string `validate:"oneof=starttls tls none"`
+	MAIL_DRIVER                 string `validate:"omitempty,oneof=smtp file memory"`
+	MAIL_FROM                   string
+	MAIL_DIR                    string
+	MAIL_LOCALE                 string        `validate:"required"`
+	MAIL_OUTBOX_WORKERS         int           `validate:"min=1"`
+	MAIL_OUTBOX_POLL_INTERVAL   time.Duration `validate:"gt=0"`
+	MAIL_OUTBOX_MAX_ATTEMPTS    int           `validate:"min=1"`
+	MAIL_OUTBOX_BASE_BACKOFF    time.Duration `validate:"gt=0"`
+	MAIL_OUTBOX_MAX_BACKOFF     time.Duration `validate:"gtefield=MAIL_OUTBOX_BASE_BACKOFF"`
+	MAIL_OUTBOX_SEND_TIMEOUT    time.Duration `validate:"gt=0"`
+	JOB_WORKERS                 int           `validate:"min=1"`
+	JOB_POLL_INTERVAL           time.Duration `validate:"gt=0"`
+	JOB_MAX_ATTEMPTS            int           `validate:"min=1"`
+	JOB_BASE_BACKOFF            time.Duration `validate:"gt=0"`
+	JOB_MAX_BACKOFF             time.Duration `validate:"gtefield=JOB_BASE_BACKOFF"`
+	JOB_TIMEOUT                 time.Duration `validate:"gt=0"`
+	SCHEDULE_PURGE_CODES        string
+	SCHEDULE_PURGE_SESSIONS     string
+	SCHEDULE_PURGE_USERS        string
+	DELETED_USER_RETENTION      time.Duration `validate:"gt=0"`
+	MFA_ISSUER                  string        `validate:"required"`
+	MFA_REQUIRED_ROLES          string
+	EMAIL_VERIFICATION_POLICY   string        `validate:"oneof=off login sensitive"`
+	LOCKOUT_THRESHOLD           int           `validate:"min=1"`
+	LOCKOUT_IP_THRESHOLD        int           `validate:"min=1"`
+	LOCKOUT_WINDOW              time.Duration `validate:"gt=0"`
+	LOCKOUT_BASE_DURATION       time.Duration `validate:"gt=0"`
+	LOCKOUT_MAX_DURATION        time.Duration `validate:"gtefield=LOCKOUT_BASE_DURATION"`
+	OTP_MAX_ATTEMPTS            int           `validate:"min=1"`
+	HTTP_READ_TIMEOUT           time.Duration `validate:"gt=0"`
+	HTTP_READ_HEADER_TIMEOUT    time.Duration `validate:"gt=0"`
+	HTTP_WRITE_TIMEOUT          time.Duration `validate:"gt=0"`
+	HTTP_IDLE_TIMEOUT           time.Duration `validate:"gt=0"`
+	SHUTDOWN_DRAIN_DELAY        time.Duration `validate:"gte=0"`
+	SHUTDOWN_GRACE_PERIOD       time.Duration `validate:"gt=0"`
+	SHUTDOWN_CLOSE_TIMEOUT      time.Duration `validate:"gt=0"`
+	HEALTH_CHECK_TIMEOUT        time.Duration `validate:"gt=0"`
+	LOG_LEVEL                   string        `validate:"oneof=debug info warn error"`
+	LOG_FORMAT                  string        `validate:"oneof=text json"`
+	METRICS_TOKEN               string        `secret:"true"`
+	TRACING_EXPORTER            string        `validate:"oneof=none stdout otlp"`
+	TRACING_SAMPLE_RATIO        float64       `validate:"gte=0,lte=1"`
+	OTEL_SERVICE_NAME           string        `validate:"required"`
+	OTEL_EXPORTER_OTLP_ENDPOINT string        `validate:"omitempty,url"`
 }
 
+// Environments selected by APP_ENV. Each has its own defaults in profiles
+// and may override .env with a .env.<APP_ENV> file.
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// Email verification policies. With EmailVerificationLogin unverified users
+// cannot log in; with EmailVerificationSensitive they can, but routes guarded
+// by middleware.VerifiedEmail reject them.
+const (
+	EmailVerificationOff       = "off"
+	EmailVerificationLogin     = "login"
+	EmailVerificationSensitive = "sensitive"
+)
+
+// minSecretLength is the shortest HMAC secret accepted in production.
+const minSecretLength = 32
+
 var ENV *Config
 
-func LoadConfig() {
-	viper.AddConfigPath(".")
-	viper.SetConfigName(".env")
-	viper.SetConfigType("env")
-	viper.AutomaticEnv()
+// defaults apply to every environment unless a profile overrides them.
+var defaults = Config{
+	APP_ENV:                   EnvDevelopment,
+	PORT:                      "8080",
+	DB_DRIVER:                 DriverMySQL,
+	DB_TIMEZONE:               "Asia/Jakarta",
+	DB_SSLMODE:                "disable",
+	ACCESS_TOKEN_TTL:          15 * time.Minute,
+	REFRESH_TOKEN_TTL:         24 * time.Hour,
+	MFA_TOKEN_TTL:             5 * time.Minute,
+	OTP_TTL:                   10 * time.Minute,
+	RESET_TOKEN_TTL:           10 * time.Minute,
+	COOKIE_DOMAIN:             "localhost",
+	CORS_ALLOWED_ORIGINS:      "http://localhost:3000",
+	SMTP_PORT:                 "587",
+	SMTP_TLS:                  "starttls",
+	MAIL_DIR:                  "tmp/mail",
+	MAIL_LOCALE:               "en",
+	MAIL_OUTBOX_WORKERS:       2,
+	MAIL_OUTBOX_POLL_INTERVAL: 2 * time.Second,
+	MAIL_OUTBOX_MAX_ATTEMPTS:  8,
+	MAIL_OUTBOX_BASE_BACKOFF:  30 * time.Second,
+	MAIL_OUTBOX_MAX_BACKOFF:   time.Hour,
+	MAIL_OUTBOX_SEND_TIMEOUT:  30 * time.Second,
+	JOB_WORKERS:               2,
+	JOB_POLL_INTERVAL:         time.Second,
+	JOB_MAX_ATTEMPTS:          5,
+	JOB_BASE_BACKOFF:          10 * time.Second,
+	JOB_MAX_BACKOFF:           time.Hour,
+	JOB_TIMEOUT:               5 * time.Minute,
+	SCHEDULE_PURGE_CODES:      "*/15 * * * *",
+	SCHEDULE_PURGE_SESSIONS:   "0 * * * *",
+	SCHEDULE_PURGE_USERS:      "30 3 * * *",
+	DELETED_USER_RETENTION:    30 * 24 * time.Hour,
+	MFA_ISSUER:                "Boilerplate Go Gin",
+	MFA_REQUIRED_ROLES:        "admin",
+	EMAIL_VERIFICATION_POLICY: EmailVerificationLogin,
+	LOCKOUT_THRESHOLD:         5,
+	LOCKOUT_IP_THRESHOLD:      20,
+	LOCKOUT_WINDOW:            15 * time.Minute,
+	LOCKOUT_BASE_DURATION:     time.Minute,
+	LOCKOUT_MAX_DURATION:      time.Hour,
+	OTP_MAX_ATTEMPTS:          5,
+	HTTP_READ_TIMEOUT:         15 * time.Second,
+	HTTP_READ_HEADER_TIMEOUT:  5 * time.Second,
+	HTTP_WRITE_TIMEOUT:        30 * time.Second,
+	HTTP_IDLE_TIMEOUT:         2 * time.Minute,
+	SHUTDOWN_GRACE_PERIOD:     20 * time.Second,
+	SHUTDOWN_CLOSE_TIMEOUT:    10 * time.Second,
+	HEALTH_CHECK_TIMEOUT:      2 * time.Second,
+	LOG_LEVEL:                 "info",
+	LOG_FORMAT:                "text",
+	TRACING_EXPORTER:          "none",
+	TRACING_SAMPLE_RATIO:      1,
+	OTEL_SERVICE_NAME:         "restapi-gogin",
+}
+
+// profiles override defaults per APP_ENV.
+var profiles = map[string]map[string]any{
+	EnvTest: {
+		"DB_DRIVER":                 DriverSQLite,
+		"DB_DATABASE":               "file::memory:?cache=shared",
+		"EMAIL_VERIFICATION_POLICY": EmailVerificationOff,
+		"MAIL_DRIVER":               "memory",
+	},
+	EnvProduction: {
+		"COOKIE_SECURE":        true,
+		"SHUTDOWN_DRAIN_DELAY": 5 * time.Second,
+		"LOG_FORMAT":           "json",
+	},
+}
+
+// LoadConfig loads ENV from the .env files in the working directory and the
+// environment. See Load.
+func LoadConfig() error {
+	cfg, err := Load(".")
+	if err != nil {
+		return err
+	}
+
+	ENV = cfg
+	return nil
+}
+
+// Load reads the configuration in layers, each overriding the one before:
+// defaults, the APP_ENV profile, dir/.env, dir/.env.<APP_ENV>, environment
+// variables, and finally files named by <KEY>_FILE variables, which hold
+// secrets mounted by the container runtime. The env files are optional.
+func Load(dir string) (*Config, error) {
+	v := viper.New()
+	keys := settingKeys()
+
+	for _, key := range keys {
+		v.SetDefault(key, reflect.ValueOf(defaults).FieldByName(key).Interface())
+		_ = v.BindEnv(key)
+		_ = v.BindEnv(key + "_FILE")
+	}
+
+	if err := mergeEnvFile(v, filepath.Join(dir, ".env")); err != nil {
+		return nil, err
+	}
+
+	appEnv := v.GetString("APP_ENV")
+	for key, value := range profiles[appEnv] {
+		v.SetDefault(key, value)
+	}
+
+	if err := mergeEnvFile(v, filepath.Join(dir, ".env."+appEnv)); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, key := range keys {
+		file := v.GetString(key + "_FILE")
+		if file == "" {
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s_FILE: %v", key, err))
+			continue
+		}
+		v.Set(key, strings.TrimRight(string(content), "\r\n"))
+	}
+
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, &Error{Problems: append(problems, err.Error())}
+	}
+
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &Error{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+// Error lists every invalid setting found while loading the configuration.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Current returns ENV, or the defaults when the configuration has not been
+// loaded, as in unit tests.
+func Current() *Config {
+	if ENV == nil {
+		return &defaults
+	}
+	return ENV
+}
+
+// IsProduction reports whether APP_ENV is production.
+func (c *Config) IsProduction() bool {
+	return c.APP_ENV == EnvProduction
+}
+
+// CORSAllowedOrigins returns the comma-separated CORS_ALLOWED_ORIGINS.
+func (c *Config) CORSAllowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(c.CORS_ALLOWED_ORIGINS, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// MailDriver returns MAIL_DRIVER. When it is unset, mail goes through SMTP
+// if SMTP_HOST is set and to the MAIL_DIR maildir otherwise.
+func (c *Config) MailDriver() string {
+	switch {
+	case c.MAIL_DRIVER != "":
+		return c.MAIL_DRIVER
+	case c.SMTP_HOST != "":
+		return "smtp"
+	default:
+		return "file"
+	}
+}
+
+// MailFrom returns the sender of outgoing mail: MAIL_FROM, falling back to
+// SMTP_EMAIL.
+func (c *Config) MailFrom() string {
+	switch {
+	case c.MAIL_FROM != "":
+		return c.MAIL_FROM
+	case c.SMTP_EMAIL != "":
+		return c.SMTP_EMAIL
+	default:
+		return "no-reply@localhost"
+	}
+}
+
+// Setting is one configuration key with its value as printed.
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// Settings lists every key in declaration order with secrets redacted. An
+// empty secret is shown as empty so a missing secret is still visible.
+func (c *Config) Settings() []Setting {
+	value := reflect.ValueOf(*c)
+	fields := value.Type()
+
+	settings := make([]Setting, 0, fields.NumField())
+	for i := 0; i < fields.NumField(); i++ {
+		printed := fmt.Sprint(value.Field(i).Interface())
+		if fields.Field(i).Tag.Get("secret") == "true" && printed != "" {
+			printed = "[REDACTED]"
+		}
+		settings = append(settings, Setting{Key: fields.Field(i).Name, Value: printed})
+	}
+	return settings
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		panic(err)
+// String prints the configuration with secrets redacted, so it is safe to
+// log.
+func (c *Config) String() string {
+	settings := c.Settings()
+	parts := make([]string, 0, len(settings))
+	for _, setting := range settings {
+		parts = append(parts, setting.Key+"="+setting.Value)
 	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
 
-	if err := viper.Unmarshal(&ENV); err != nil {
-		panic(err)
+// LogValue logs the configuration with secrets redacted, whatever the slog
+// handler.
+func (c *Config) LogValue() slog.Value {
+	settings := c.Settings()
+	attrs := make([]slog.Attr, 0, len(settings))
+	for _, setting := range settings {
+		attrs = append(attrs, slog.String(setting.Key, setting.Value))
 	}
+	return slog.GroupValue(attrs...)
+}
 
-	fmt.Printf("PORT: %v\n", ENV)
+// validate checks the field rules and the rules spanning several fields.
+func (c *Config) validate() []string {
+	var problems []string
+
+	var validationErrors validator.ValidationErrors
+	if err := validator.New().Struct(c); errors.As(err, &validationErrors) {
+		for _, fe := range validationErrors {
+			problems = append(problems, describe(fe))
+		}
+	}
+
+	if c.DB_DSN == "" {
+		switch c.DB_DRIVER {
+		case DriverMySQL, DriverPostgres:
+			for _, setting := range []Setting{{"DB_URL", c.DB_URL}, {"DB_USERNAME", c.DB_USERNAME}, {"DB_DATABASE", c.DB_DATABASE}} {
+				if setting.Value == "" {
+					problems = append(problems, fmt.Sprintf("%s is required for DB_DRIVER %s unless DB_DSN is set", setting.Key, c.DB_DRIVER))
+				}
+			}
+		case DriverSQLite:
+			if c.DB_DATABASE == "" {
+				problems = append(problems, "DB_DATABASE is required for DB_DRIVER sqlite unless DB_DSN is set")
+			}
+		}
+	}
+
+	// HMAC secrets are only used when no asymmetric signing key is set.
+	if c.JWT_SIGNING_KEY_FILE == "" {
+		for _, secret := range []Setting{{"ACCESS_SECRET", c.ACCESS_SECRET}, {"REFRESH_SECRET", c.REFRESH_SECRET}} {
+			switch {
+			case secret.Value == "":
+				problems = append(problems, secret.Key+" is required unless JWT_SIGNING_KEY_FILE is set")
+			case c.IsProduction() && len(secret.Value) < minSecretLength:
+				problems = append(problems, fmt.Sprintf("%s must be at least %d characters in production", secret.Key, minSecretLength))
+			}
+		}
+		if c.ACCESS_SECRET != "" && c.ACCESS_SECRET == c.REFRESH_SECRET {
+			problems = append(problems, "ACCESS_SECRET and REFRESH_SECRET must differ")
+		}
+	}
+
+	if c.IsProduction() && !c.COOKIE_SECURE {
+		problems = append(problems, "COOKIE_SECURE must be true in production")
+	}
+
+	if c.SMTP_HOST != "" && c.SMTP_EMAIL == "" {
+		problems = append(problems, "SMTP_EMAIL is required when SMTP_HOST is set")
+	}
+	if c.MAIL_DRIVER == "smtp" && c.SMTP_HOST == "" {
+		problems = append(problems, "SMTP_HOST is required for MAIL_DRIVER smtp")
+	}
+	if c.MAIL_FROM != "" {
+		if _, err := mail.ParseAddress(c.MAIL_FROM); err != nil {
+			problems = append(problems, fmt.Sprintf("MAIL_FROM must be an address such as \"App <no-reply@example.com>\", got %q", c.MAIL_FROM))
+		}
+	}
+
+	for _, schedule := range []Setting{{"SCHEDULE_PURGE_CODES", c.SCHEDULE_PURGE_CODES}, {"SCHEDULE_PURGE_SESSIONS", c.SCHEDULE_PURGE_SESSIONS}, {"SCHEDULE_PURGE_USERS", c.SCHEDULE_PURGE_USERS}} {
+		if schedule.Value == "" {
+			continue
+		}
+		if _, err := cron.Parse(schedule.Value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s must be a cron expression such as \"0 * * * *\": %v", schedule.Key, err))
+		}
+	}
+
+	return problems
+}
+
+func describe(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s, got %q", fe.Field(), strings.ReplaceAll(fe.Param(), " ", ", "), fe.Value())
+	case "numeric":
+		return fmt.Sprintf("%s must be a number, got %q", fe.Field(), fe.Value())
+	case "email":
+		return fmt.Sprintf("%s must be an email address, got %q", fe.Field(), fe.Value())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s, got %v", fe.Field(), fe.Param(), fe.Value())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s, got %v", fe.Field(), fe.Param(), fe.Value())
+	case "gtfield":
+		return fmt.Sprintf("%s must be greater than %s, got %v", fe.Field(), fe.Param(), fe.Value())
+	case "gtefield":
+		return fmt.Sprintf("%s must not be less than %s, got %v", fe.Field(), fe.Param(), fe.Value())
+	default:
+		return fmt.Sprintf("%s failed the %s check", fe.Field(), fe.Tag())
+	}
+}
+
+// settingKeys lists the Config field names, which are also the keys.
+func settingKeys() []string {
+	fields := reflect.TypeOf(Config{})
+	keys := make([]string, 0, fields.NumField())
+	for i := 0; i < fields.NumField(); i++ {
+		keys = append(keys, fields.Field(i).Name)
+	}
+	return keys
+}
+
+// mergeEnvFile merges a dotenv file into v when it exists.
+func mergeEnvFile(v *viper.Viper, path string) error {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	v.SetConfigFile(path)
+	v.SetConfigType("env")
+	if err := v.MergeInConfig(); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	return nil
+}
+
+// MFARequiredForRoles reports whether a user holding the given roles must
+// have two-factor authentication enabled before using admin routes.
+func MFARequiredForRoles(roles []string) bool {
+	for _, r := range strings.Split(Current().MFA_REQUIRED_ROLES, ",") {
+		for _, role := range roles {
+			if strings.TrimSpace(r) == role {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// EmailVerificationPolicy returns the configured policy. Unknown values turn
+// verification off.
+func EmailVerificationPolicy() string {
+	switch policy := Current().EMAIL_VERIFICATION_POLICY; policy {
+	case EmailVerificationLogin, EmailVerificationSensitive:
+		return policy
+	default:
+		return EmailVerificationOff
+	}
 }
@@ -2,23 +2,105 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"strings"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// Database drivers accepted by DB_DRIVER.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 var DB *gorm.DB
 
 func LoadDatabase() *gorm.DB {
-	connectionString := fmt.Sprintf("%v:%v@tcp(%v)/%v?%v", ENV.DB_USERNAME, ENV.DB_PASSWORD, ENV.DB_URL, ENV.DB_DATABASE, "charset=utf8mb4&parseTime=True&loc=Asia%2FJakarta")
-	fmt.Println(connectionString)
+	dialector, err := Dialector()
+	if err != nil {
+		panic(err)
+	}
 
-	db, err := gorm.Open(mysql.Open(connectionString), &gorm.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{})
 
 	if err != nil {
-		panic("failed to connect database")
+		panic(fmt.Sprintf("failed to connect %s database: %v", ENV.DB_DRIVER, err))
 	}
 
 	DB = db
 	return db
 }
+
+// Dialector returns the gorm dialector for DB_DRIVER. DB_DSN is used as is
+// when set; otherwise the DSN is built from the DB_* settings.
+func Dialector() (gorm.Dialector, error) {
+	dsn := ENV.DB_DSN
+
+	switch ENV.DB_DRIVER {
+	case DriverMySQL, "":
+		if dsn == "" {
+			dsn = mysqlDSN()
+		}
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		if dsn == "" {
+			dsn = postgresDSN()
+		}
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		if dsn == "" {
+			dsn = sqliteDSN()
+		}
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q, use %s, %s or %s", ENV.DB_DRIVER, DriverMySQL, DriverPostgres, DriverSQLite)
+	}
+}
+
+func mysqlDSN() string {
+	params := url.Values{}
+	params.Set("charset", "utf8mb4")
+	params.Set("parseTime", "True")
+	params.Set("loc", ENV.DB_TIMEZONE)
+
+	return fmt.Sprintf("%v:%v@tcp(%v)/%v?%v", ENV.DB_USERNAME, ENV.DB_PASSWORD, ENV.DB_URL, ENV.DB_DATABASE, params.Encode())
+}
+
+// postgresDSN builds a URL DSN so passwords with spaces or quotes need no
+// escaping rules of their own.
+func postgresDSN() string {
+	host, port, err := net.SplitHostPort(ENV.DB_URL)
+	if err != nil {
+		host, port = ENV.DB_URL, "5432"
+	}
+
+	params := url.Values{}
+	params.Set("sslmode", ENV.DB_SSLMODE)
+	params.Set("TimeZone", ENV.DB_TIMEZONE)
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(ENV.DB_USERNAME, ENV.DB_PASSWORD),
+		Host:     net.JoinHostPort(host, port),
+		Path:     "/" + ENV.DB_DATABASE,
+		RawQuery: params.Encode(),
+	}
+	return dsn.String()
+}
+
+// sqliteDSN treats DB_DATABASE as the database file. Foreign keys are off by
+// default in SQLite, and the busy timeout makes concurrent writers wait for
+// each other instead of failing.
+func sqliteDSN() string {
+	separator := "?"
+	if strings.Contains(ENV.DB_DATABASE, "?") {
+		separator = "&"
+	}
+	return ENV.DB_DATABASE + separator + "_foreign_keys=on&_busy_timeout=5000"
+}
@@ -1,13 +1,125 @@
 package config
 
 import (
+	"fmt"
+	"time"
+
+	"restApi-GoGin/src/migration"
 	"restApi-GoGin/src/models"
 
 	"gorm.io/gorm"
 )
 
-func RunMigration(db *gorm.DB) {
-	db.AutoMigrate(
+// legacySchemaVersion is the migration matching the schema AutoMigrate built.
+const legacySchemaVersion = 1
+
+// RunMigration applies the pending SQL migrations and seeds the default roles.
+// It all runs under the migration lock, so replicas starting together adopt
+// a legacy schema and seed the roles once.
+func RunMigration(db *gorm.DB) error {
+	migrator, err := migration.NewMigrator(db, nil)
+	if err != nil {
+		return err
+	}
+
+	return migrator.Locked(func(db *gorm.DB, migrator *migration.Migrator) error {
+		if err := adoptLegacySchema(db, migrator); err != nil {
+			return err
+		}
+
+		if err := migrator.Up(); err != nil {
+			return err
+		}
+
+		return seedRoles(db)
+	})
+}
+
+// adoptLegacySchema brings a database created by AutoMigrate, before SQL
+// migrations existed, up to the initial schema once and records it as
+// migrated so the initial migration is not run against existing tables.
+func adoptLegacySchema(db *gorm.DB, migrator *migration.Migrator) error {
+	if !db.Migrator().HasTable(&models.User{}) || db.Migrator().HasTable("schema_migrations") {
+		return nil
+	}
+
+	// Accounts created before email verification existed are treated as
+	// verified so enabling the feature does not lock them out.
+	backfillEmailVerified := !db.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+	// Users from before roles became entities keep the role named in the
+	// legacy users.role column.
+	backfillUserRoles := !db.Migrator().HasTable("user_roles")
+
+	err := db.AutoMigrate(
+		&models.Permission{},
+		&models.Role{},
 		&models.User{},
+		&models.RefreshToken{},
+		&models.Session{},
+		&models.RecoveryCode{},
+		&models.AuthThrottle{},
 	)
+	if err != nil {
+		return err
+	}
+
+	if backfillEmailVerified {
+		err := db.Model(&models.User{}).
+			Where("email_verified_at IS NULL").
+			Update("email_verified_at", time.Now()).Error
+		if err != nil {
+			return fmt.Errorf("backfilling email_verified_at: %w", err)
+		}
+	}
+
+	if err := seedRoles(db); err != nil {
+		return err
+	}
+
+	if backfillUserRoles && db.Migrator().HasColumn(&models.User{}, "role") {
+		err := db.Exec("INSERT INTO user_roles (user_id, role_id) " +
+			"SELECT users.id, roles.id FROM users JOIN roles ON roles.name = users.role").Error
+		if err != nil {
+			return fmt.Errorf("backfilling user_roles: %w", err)
+		}
+	}
+
+	return migrator.Baseline(legacySchemaVersion)
+}
+
+// seedRoles creates the default roles and permissions that do not exist yet.
+// Every permission it creates is granted to the admin role, so admins keep
+// full access when a release adds a permission.
+func seedRoles(db *gorm.DB) error {
+	for _, role := range models.DefaultRoles {
+		err := db.Where(models.Role{Name: role.Name}).
+			Attrs(models.Role{Description: role.Description}).
+			FirstOrCreate(&models.Role{}).Error
+		if err != nil {
+			return fmt.Errorf("seeding role %s: %w", role.Name, err)
+		}
+	}
+
+	var admin models.Role
+	if err := db.Where("name = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+		return fmt.Errorf("loading role %s: %w", models.RoleAdmin, err)
+	}
+
+	for _, permission := range models.DefaultPermissions {
+		created := models.Permission{}
+		result := db.Where(models.Permission{Name: permission.Name}).
+			Attrs(models.Permission{Description: permission.Description}).
+			FirstOrCreate(&created)
+		if result.Error != nil {
+			return fmt.Errorf("seeding permission %s: %w", permission.Name, result.Error)
+		}
+
+		if result.RowsAffected > 0 {
+			if err := db.Model(&admin).Association("Permissions").Append(&created); err != nil {
+				return fmt.Errorf("granting permission %s to %s: %w", permission.Name, models.RoleAdmin, err)
+			}
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"net/http"
+
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type auditController struct {
+	services services.AuditService
+}
+
+func NewAuditController(auditService services.AuditService) *auditController {
+	return &auditController{
+		services: auditService,
+	}
+}
+
+// ListEntries godoc
+// @Summary List audit log entries
+// @Description List the entries of the audit log, newest first, optionally filtered (admin only)
+// @Tags audit
+// @Produce json
+// @Param page query int false "Page number, starting at 1"
+// @Param per_page query int false "Entries per page, at most 100"
+// @Param action query string false "Only this action, e.g. user.roles"
+// @Param actor_id query int false "Only actions by this user"
+// @Param target_type query string false "Only actions on this type of record" Enums(user, role, permission)
+// @Param target_id query string false "Only actions on this record, with target_type"
+// @Param request_id query string false "Only actions of this request"
+// @Param from query string false "Only entries created on or after this date (YYYY-MM-DD)"
+// @Param until query string false "Only entries created on or before this date (YYYY-MM-DD)"
+// @Success 200 {object} utils.ResponseWithData{data=[]models.AuditLog} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /audit [get]
+func (ctrl *auditController) ListEntries(ctx *gin.Context) {
+	query, ok := bindAuditQuery(ctx)
+	if !ok {
+		return
+	}
+
+	entries, paginate, err := ctrl.services.ListEntries(ctx.Request.Context(), query)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get audit log",
+		Paginate:   paginate,
+		Data:       entries,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ExportEntries godoc
+// @Summary Export audit log entries as CSV
+// @Description Download every entry matching the filters as CSV, oldest first. Takes the filters of GET /audit but is not paginated (admin only)
+// @Tags audit
+// @Produce text/csv
+// @Param action query string false "Only this action, e.g. user.roles"
+// @Param actor_id query int false "Only actions by this user"
+// @Param target_type query string false "Only actions on this type of record" Enums(user, role, permission)
+// @Param target_id query string false "Only actions on this record, with target_type"
+// @Param request_id query string false "Only actions of this request"
+// @Param from query string false "Only entries created on or after this date (YYYY-MM-DD)"
+// @Param until query string false "Only entries created on or before this date (YYYY-MM-DD)"
+// @Success 200 {string} string "CSV with a header row"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /audit/export [get]
+func (ctrl *auditController) ExportEntries(ctx *gin.Context) {
+	query, ok := bindAuditQuery(ctx)
+	if !ok {
+		return
+	}
+
+	write, err := ctrl.services.ExportEntries(ctx.Request.Context(), query)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", `attachment; filename="audit-log.csv"`)
+	ctx.Status(http.StatusOK)
+	// The status is sent with the first row, so a failure part way through
+	// can only cut the download short.
+	if err := write(ctx.Writer); err != nil {
+		logger.FromContext(ctx.Request.Context()).Error("exporting the audit log failed", "error", err)
+		ctx.Abort()
+	}
+}
+
+// Verify godoc
+// @Summary Verify the audit log
+// @Description Check the hash chain of the audit log from the first entry. Reports the first entry that is missing, out of order or altered, and the head of the chain, which can be kept elsewhere to detect entries cut from the end (admin only)
+// @Tags audit
+// @Produce json
+// @Success 200 {object} utils.ResponseWithData{data=dto.AuditVerifyResponse} "OK"
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /audit/verify [get]
+func (ctrl *auditController) Verify(ctx *gin.Context) {
+	result, err := ctrl.services.Verify(ctx.Request.Context())
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	message := "audit log is intact"
+	if !result.Valid {
+		message = "audit log has been tampered with"
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    message,
+		Data:       result,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// bindAuditQuery binds and validates the filters of the audit endpoints. It
+// writes the error response when it returns false.
+func bindAuditQuery(ctx *gin.Context) (*dto.AuditListQuery, bool) {
+	var query dto.AuditListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return nil, false
+	}
+	if err := utils.Validator.Struct(query); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return nil, false
+	}
+	return &query, true
+}
@@ -2,16 +2,20 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
+
+	"restApi-GoGin/src/config"
 	"restApi-GoGin/src/dto"
 	"restApi-GoGin/src/errorhandler"
 	"restApi-GoGin/src/services"
 	"restApi-GoGin/src/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
-var validate = validator.New()
+// tokenDeliveryHeader lets non-browser clients ask for tokens in the JSON
+// response body instead of cookies by sending "X-Token-Delivery: body".
+const tokenDeliveryHeader = "X-Token-Delivery"
 
 type authController struct {
 	services services.AuthService
@@ -41,12 +45,12 @@ func (ctrl *authController) Register(ctx *gin.Context) {
 		return
 	}
 
-	if err := validate.Struct(register); err != nil {
-		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+	if err := utils.Validator.Struct(register); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
-	if err := ctrl.services.Register(&register); err != nil {
+	if err := ctrl.services.Register(ctx.Request.Context(), &register); err != nil {
 		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
@@ -59,16 +63,91 @@ func (ctrl *authController) Register(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, response)
 }
 
+// VerifyEmail godoc
+// @Summary Verify email
+// @Description Verify the email address of a newly registered user with the OTP sent by email. Unknown emails get the same error as a wrong code.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.VerifyEmailRequest true "Verify Email Request"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Router /verify-email [post]
+func (ctrl *authController) VerifyEmail(ctx *gin.Context) {
+	var verifyEmail dto.VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&verifyEmail); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(verifyEmail); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	if err := ctrl.services.VerifyEmail(ctx.Request.Context(), &verifyEmail); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "email verified successfully",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new email verification OTP. One code is sent per minute per user. Unknown emails, verified accounts and extra requests get the same response without an email.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResendVerificationRequest true "Resend Verification Request"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Router /resend-verification [post]
+func (ctrl *authController) ResendVerification(ctx *gin.Context) {
+	var resend dto.ResendVerificationRequest
+	if err := ctx.ShouldBindJSON(&resend); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(resend); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	if err := ctrl.services.ResendVerification(ctx.Request.Context(), &resend); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "if the email is waiting for verification, a new code has been sent to it",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
 // Login godoc
 // @Summary Login user
-// @Description Login user with email and password
+// @Description Login user with email and password. If the user has MFA enabled no cookies are set; the response carries an mfa_token to exchange at /login/mfa. Send "X-Token-Delivery: body" to receive the tokens in the response instead of cookies.
 // @Tags auth
 // @Accept json
 // @Produce json
+// @Param X-Token-Delivery header string false "Set to body to return tokens in the response body" Enums(cookie, body)
 // @Param request body dto.LoginRequest true "Login Request"
 // @Success 200 {object} utils.ResponseWithData{data=dto.LoginResponse} "OK"
 // @Failure 400 {object} errorhandler.BadRequestError
 // @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 423 {object} errorhandler.LockedError
 // @Failure 500 {object} errorhandler.InternalServerError
 // @Router /login [post]
 func (ctrl *authController) Login(ctx *gin.Context) {
@@ -78,36 +157,76 @@ func (ctrl *authController) Login(ctx *gin.Context) {
 		return
 	}
 
-	if err := validate.Struct(login); err != nil {
-		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+	if err := utils.Validator.Struct(login); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
-	responseData, accessToken, refreshToken, err := ctrl.services.Login(&login)
+	responseData, accessToken, refreshToken, err := ctrl.services.Login(ctx.Request.Context(), &login, clientInfo(ctx))
 	if err != nil {
 		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
-	ctx.SetCookie(
-		"accessToken",
-		accessToken,
-		15*60,
-		"/",
-		"localhost",
-		false,
-		true,
-	)
+	if responseData != nil && responseData.MFARequired {
+		res := utils.Response(dto.ResponseParams{
+			StatusCode: http.StatusOK,
+			Message:    "MFA verification required",
+			Data:       responseData,
+		})
 
-	ctx.SetCookie(
-		"refreshToken",
-		refreshToken,
-		1*24*60*60,
-		"/",
-		"localhost",
-		false,
-		true,
-	)
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+
+	if tokens := deliverTokens(ctx, accessToken, refreshToken); tokens != nil && responseData != nil {
+		responseData.Tokens = tokens
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success login user",
+		Data:       responseData,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// LoginMFA godoc
+// @Summary Complete MFA login
+// @Description Exchange the mfa_token returned by /login and a TOTP or recovery code for session cookies, or for tokens in the response when "X-Token-Delivery: body" is sent
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param X-Token-Delivery header string false "Set to body to return tokens in the response body" Enums(cookie, body)
+// @Param request body dto.LoginMFARequest true "Login MFA Request"
+// @Success 200 {object} utils.ResponseWithData{data=dto.LoginResponse} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 423 {object} errorhandler.LockedError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Router /login/mfa [post]
+func (ctrl *authController) LoginMFA(ctx *gin.Context) {
+	var login dto.LoginMFARequest
+	if err := ctx.ShouldBindJSON(&login); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(login); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	responseData, accessToken, refreshToken, err := ctrl.services.LoginMFA(ctx.Request.Context(), &login, clientInfo(ctx))
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	if tokens := deliverTokens(ctx, accessToken, refreshToken); tokens != nil && responseData != nil {
+		responseData.Tokens = tokens
+	}
 
 	res := utils.Response(dto.ResponseParams{
 		StatusCode: http.StatusOK,
@@ -120,32 +239,23 @@ func (ctrl *authController) Login(ctx *gin.Context) {
 
 // Logout godoc
 // @Summary Logout user
-// @Description Logout user by clearing cookies
+// @Description Logout user by revoking the refresh token family and clearing cookies. Non-browser clients send the refresh token in the request body.
 // @Tags auth
 // @Accept json
 // @Produce json
+// @Param request body dto.RefreshTokenRequest false "Refresh Token Request"
 // @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 500 {object} errorhandler.InternalServerError
 // @Router /logout [post]
 func (ctrl *authController) Logout(ctx *gin.Context) {
-	ctx.SetCookie(
-		"accessToken",
-		"",
-		-1,
-		"/",
-		"localhost",
-		false,
-		true,
-	)
+	if refreshToken := requestRefreshToken(ctx); refreshToken != "" {
+		if err := ctrl.services.Logout(ctx.Request.Context(), refreshToken); err != nil {
+			errorhandler.ErrorHandler(ctx, err)
+			return
+		}
+	}
 
-	ctx.SetCookie(
-		"refreshToken",
-		"",
-		-1,
-		"/",
-		"localhost",
-		false,
-		true,
-	)
+	clearAuthCookies(ctx)
 
 	res := utils.Response(dto.ResponseParams{
 		StatusCode: http.StatusOK,
@@ -157,55 +267,51 @@ func (ctrl *authController) Logout(ctx *gin.Context) {
 
 // RefreshToken godoc
 // @Summary Refresh access token
-// @Description Refresh access token using refresh token from cookie
+// @Description Rotate the refresh token from the request body or cookie and issue a new access token. Replaying an already rotated refresh token revokes its whole token family.
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Param X-Token-Delivery header string false "Set to body to return tokens in the response body" Enums(cookie, body)
+// @Param request body dto.RefreshTokenRequest false "Refresh Token Request"
+// @Success 200 {object} utils.ResponseWithData{data=dto.TokenResponse} "OK"
 // @Failure 401 {object} errorhandler.UnauthorizedError
 // @Failure 500 {object} errorhandler.InternalServerError
 // @Router /refresh-token [post]
 func (ctrl *authController) RefreshToken(ctx *gin.Context) {
-	refreshToken, err := ctx.Cookie("refreshToken")
-	if err != nil {
+	refreshToken := requestRefreshToken(ctx)
+	if refreshToken == "" {
 		errorhandler.ErrorHandler(ctx, &errorhandler.UnauthorizedError{Message: "refresh token not found"})
 		return
 	}
 
-	accessToken, err := ctrl.services.RefreshToken(refreshToken)
+	accessToken, newRefreshToken, err := ctrl.services.RefreshToken(ctx.Request.Context(), refreshToken, clientInfo(ctx))
 	if err != nil {
 		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
-	ctx.SetCookie(
-		"accessToken",
-		accessToken,
-		15*60,
-		"/",
-		"localhost",
-		false,
-		true,
-	)
-
-	res := utils.Response(dto.ResponseParams{
+	params := dto.ResponseParams{
 		StatusCode: http.StatusOK,
 		Message:    "success refresh token",
-	})
+	}
+	if tokens := deliverTokens(ctx, accessToken, newRefreshToken); tokens != nil {
+		params.Data = tokens
+	}
+
+	res := utils.Response(params)
 
 	ctx.JSON(http.StatusOK, res)
 }
 
 // ForgotPassword godoc
 // @Summary Forgot password
-// @Description Send OTP to user's email for password reset
+// @Description Send OTP to user's email for password reset. Unknown emails get the same response without an email.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body dto.ForgotPasswordRequest true "Forgot Password Request"
 // @Success 200 {object} utils.ResponseWithoutData "OK"
 // @Failure 400 {object} errorhandler.BadRequestError
-// @Failure 404 {object} errorhandler.NotFoundError
 // @Failure 500 {object} errorhandler.InternalServerError
 // @Router /forgot-password [post]
 func (ctrl *authController) ForgotPassword(ctx *gin.Context) {
@@ -215,19 +321,19 @@ func (ctrl *authController) ForgotPassword(ctx *gin.Context) {
 		return
 	}
 
-	if err := validate.Struct(forgotPassword); err != nil {
-		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+	if err := utils.Validator.Struct(forgotPassword); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
-	if err := ctrl.services.ForgotPassword(&forgotPassword); err != nil {
+	if err := ctrl.services.ForgotPassword(ctx.Request.Context(), &forgotPassword); err != nil {
 		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
 	res := utils.Response(dto.ResponseParams{
 		StatusCode: http.StatusOK,
-		Message:    "OTP has been sent to your email",
+		Message:    "if an account exists for the email, an OTP has been sent to it",
 	})
 
 	ctx.JSON(http.StatusOK, res)
@@ -243,6 +349,7 @@ func (ctrl *authController) ForgotPassword(ctx *gin.Context) {
 // @Success 200 {object} utils.ResponseWithData{data=dto.VerifyOTPResponse}
 // @Failure 400 {object} errorhandler.BadRequestError
 // @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 423 {object} errorhandler.LockedError
 // @Failure 500 {object} errorhandler.InternalServerError
 // @Router /verify-otp [post]
 func (ctrl *authController) VerifyOTP(ctx *gin.Context) {
@@ -252,12 +359,12 @@ func (ctrl *authController) VerifyOTP(ctx *gin.Context) {
 		return
 	}
 
-	if err := validate.Struct(verifyOTP); err != nil {
-		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+	if err := utils.Validator.Struct(verifyOTP); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
-	resetToken, err := ctrl.services.VerifyOTP(&verifyOTP)
+	resetToken, err := ctrl.services.VerifyOTP(ctx.Request.Context(), &verifyOTP, clientInfo(ctx))
 	if err != nil {
 		errorhandler.ErrorHandler(ctx, err)
 		return
@@ -282,6 +389,7 @@ func (ctrl *authController) VerifyOTP(ctx *gin.Context) {
 // @Success 200 {object} utils.ResponseWithoutData
 // @Failure 400 {object} errorhandler.BadRequestError
 // @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 423 {object} errorhandler.LockedError
 // @Failure 500 {object} errorhandler.InternalServerError
 // @Router /reset-password [post]
 func (ctrl *authController) ResetPassword(ctx *gin.Context) {
@@ -291,12 +399,12 @@ func (ctrl *authController) ResetPassword(ctx *gin.Context) {
 		return
 	}
 
-	if err := validate.Struct(resetPassword); err != nil {
-		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+	if err := utils.Validator.Struct(resetPassword); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
-	if err := ctrl.services.ResetPassword(&resetPassword); err != nil {
+	if err := ctrl.services.ResetPassword(ctx.Request.Context(), &resetPassword, clientInfo(ctx)); err != nil {
 		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
@@ -308,3 +416,94 @@ func (ctrl *authController) ResetPassword(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, res)
 }
+
+func clientInfo(ctx *gin.Context) *dto.ClientInfo {
+	return &dto.ClientInfo{
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+	}
+}
+
+// deliverTokens hands freshly issued tokens to the client. Browsers get
+// HttpOnly cookies; clients that asked for body delivery get the tokens back
+// to embed in the response and no cookies are set.
+func deliverTokens(ctx *gin.Context, accessToken string, refreshToken string) *dto.TokenResponse {
+	if !strings.EqualFold(ctx.GetHeader(tokenDeliveryHeader), "body") {
+		setAuthCookies(ctx, accessToken, refreshToken)
+		return nil
+	}
+
+	return &dto.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(config.Current().ACCESS_TOKEN_TTL.Seconds()),
+	}
+}
+
+// requestRefreshToken returns the refresh token from the JSON body, falling
+// back to the refreshToken cookie. An explicit body token wins so that a
+// non-browser client is never confused by a cookie jar.
+func requestRefreshToken(ctx *gin.Context) string {
+	if ctx.Request.ContentLength != 0 {
+		var req dto.RefreshTokenRequest
+		if err := ctx.ShouldBindJSON(&req); err == nil && req.RefreshToken != "" {
+			return req.RefreshToken
+		}
+	}
+
+	refreshToken, err := ctx.Cookie("refreshToken")
+	if err != nil {
+		return ""
+	}
+
+	return refreshToken
+}
+
+func setAuthCookies(ctx *gin.Context, accessToken string, refreshToken string) {
+	cfg := config.Current()
+
+	ctx.SetCookie(
+		"accessToken",
+		accessToken,
+		int(cfg.ACCESS_TOKEN_TTL.Seconds()),
+		"/",
+		cfg.COOKIE_DOMAIN,
+		cfg.COOKIE_SECURE,
+		true,
+	)
+
+	ctx.SetCookie(
+		"refreshToken",
+		refreshToken,
+		int(cfg.REFRESH_TOKEN_TTL.Seconds()),
+		"/",
+		cfg.COOKIE_DOMAIN,
+		cfg.COOKIE_SECURE,
+		true,
+	)
+}
+
+func clearAuthCookies(ctx *gin.Context) {
+	cfg := config.Current()
+
+	ctx.SetCookie(
+		"accessToken",
+		"",
+		-1,
+		"/",
+		cfg.COOKIE_DOMAIN,
+		cfg.COOKIE_SECURE,
+		true,
+	)
+
+	ctx.SetCookie(
+		"refreshToken",
+		"",
+		-1,
+		"/",
+		cfg.COOKIE_DOMAIN,
+		cfg.COOKIE_SECURE,
+		true,
+	)
+}
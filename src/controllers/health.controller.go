@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/health"
+	"restApi-GoGin/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type healthController struct {
+	checks *health.Registry
+}
+
+func NewHealthController(checks *health.Registry) *healthController {
+	return &healthController{checks: checks}
+}
+
+// Live answers liveness probes. It only shows that the process can serve
+// HTTP, so a dependency outage never gets the process restarted. Like the
+// JWKS endpoint the probes are served outside /api and left out of the
+// Swagger docs.
+func (ctrl *healthController) Live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "alive",
+	}))
+}
+
+// Ready answers readiness probes by running the registered checks and
+// reporting the status and latency of each. It fails with 503 when a
+// required check fails, and without running the checks once shutdown starts
+// so traffic moves elsewhere while in-flight requests drain.
+func (ctrl *healthController) Ready(ctx *gin.Context) {
+	if health.Draining() {
+		ctx.JSON(http.StatusServiceUnavailable, utils.Response(dto.ResponseParams{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    "shutting down",
+		}))
+		return
+	}
+
+	report := ctrl.checks.Run(ctx.Request.Context())
+	if !report.Healthy() {
+		ctx.JSON(http.StatusServiceUnavailable, utils.Response(dto.ResponseParams{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    "not ready",
+			Data:       report,
+		}))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "ready",
+		Data:       report,
+	}))
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"net/http"
+
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type jwksController struct{}
+
+func NewJWKSController() *jwksController {
+	return &jwksController{}
+}
+
+// JWKS serves the public keys that verify access tokens as a JSON Web Key Set
+// so other services can validate tokens without sharing a secret. It is not
+// wrapped in the usual response envelope because JWKS clients expect the
+// RFC 7517 document as is.
+func (ctrl *jwksController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, dto.JWKSet{Keys: utils.PublicJWKs()})
+}
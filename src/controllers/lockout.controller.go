@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type lockoutController struct {
+	services services.LockoutService
+}
+
+func NewLockoutController(lockoutService services.LockoutService) *lockoutController {
+	return &lockoutController{
+		services: lockoutService,
+	}
+}
+
+// UnlockUser godoc
+// @Summary Unlock a user account
+// @Description Clear the brute-force lockout and one-time code attempt counters of a user (admin only)
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /user/{id}/unlock [post]
+func (ctrl *lockoutController) UnlockUser(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: "invalid user ID"})
+		return
+	}
+
+	if err := ctrl.services.UnlockUser(ctx.Request.Context(), id); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success unlock user",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
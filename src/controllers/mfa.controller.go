@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"net/http"
+
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mfaController struct {
+	services services.MFAService
+}
+
+func NewMFAController(mfaService services.MFAService) *mfaController {
+	return &mfaController{
+		services: mfaService,
+	}
+}
+
+// Enroll godoc
+// @Summary Start MFA enrollment
+// @Description Generate a TOTP secret for the authenticated user. Render provisioning_uri as a QR code for the authenticator app, then call /mfa/confirm.
+// @Tags mfa
+// @Produce json
+// @Success 200 {object} utils.ResponseWithData{data=dto.MFAEnrollResponse} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /mfa/enroll [post]
+func (ctrl *mfaController) Enroll(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	enrollment, err := ctrl.services.Enroll(ctx.Request.Context(), user)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "scan the provisioning URI with your authenticator app",
+		Data:       enrollment,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Confirm godoc
+// @Summary Confirm MFA enrollment
+// @Description Enable MFA by submitting the first code from the authenticator app. Returns single-use recovery codes that are shown only once.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body dto.MFACodeRequest true "MFA Code Request"
+// @Success 200 {object} utils.ResponseWithData{data=dto.MFARecoveryCodesResponse} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /mfa/confirm [post]
+func (ctrl *mfaController) Confirm(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	codes, err := ctrl.services.Confirm(ctx.Request.Context(), user, &req)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "MFA enabled successfully",
+		Data:       codes,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Disable godoc
+// @Summary Disable MFA
+// @Description Disable MFA with a current TOTP or recovery code. Not allowed for roles that require MFA.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body dto.MFACodeRequest true "MFA Code Request"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /mfa/disable [post]
+func (ctrl *mfaController) Disable(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	if err := ctrl.services.Disable(ctx.Request.Context(), user, &req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "MFA disabled successfully",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after verifying a current TOTP or recovery code
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body dto.MFACodeRequest true "MFA Code Request"
+// @Success 200 {object} utils.ResponseWithData{data=dto.MFARecoveryCodesResponse} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /mfa/recovery-codes [post]
+func (ctrl *mfaController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	codes, err := ctrl.services.RegenerateRecoveryCodes(ctx.Request.Context(), user, &req)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success regenerate recovery codes",
+		Data:       codes,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
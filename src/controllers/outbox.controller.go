@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type outboxController struct {
+	services services.OutboxService
+}
+
+func NewOutboxController(outboxService services.OutboxService) *outboxController {
+	return &outboxController{
+		services: outboxService,
+	}
+}
+
+// ListMessages godoc
+// @Summary List outgoing mail
+// @Description List the messages of the mail outbox, newest first. Bodies are not included (admin only)
+// @Tags mail
+// @Produce json
+// @Param page query int false "Page number, starting at 1"
+// @Param per_page query int false "Messages per page, at most 100"
+// @Param status query string false "Only messages with this status" Enums(pending, sent, dead)
+// @Success 200 {object} utils.ResponseWithData{data=[]models.OutboxMessage} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /mail/outbox [get]
+func (ctrl *outboxController) ListMessages(ctx *gin.Context) {
+	var query dto.OutboxListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+	if err := utils.Validator.Struct(query); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	messages, paginate, err := ctrl.services.ListMessages(ctx.Request.Context(), &query)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get messages",
+		Paginate:   paginate,
+		Data:       messages,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetMessage godoc
+// @Summary Get an outgoing message
+// @Description Get the delivery status, attempts and last error of an outbox message (admin only)
+// @Tags mail
+// @Produce json
+// @Param id path int true "Message ID"
+// @Success 200 {object} utils.ResponseWithData{data=models.OutboxMessage} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /mail/outbox/{id} [get]
+func (ctrl *outboxController) GetMessage(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: "invalid message ID"})
+		return
+	}
+
+	message, err := ctrl.services.GetMessage(ctx.Request.Context(), id)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get message",
+		Data:       message,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Requeue godoc
+// @Summary Requeue a dead message
+// @Description Give a message that ran out of delivery attempts a fresh set, starting now (admin only)
+// @Tags mail
+// @Produce json
+// @Param id path int true "Message ID"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /mail/outbox/{id}/requeue [post]
+func (ctrl *outboxController) Requeue(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: "invalid message ID"})
+		return
+	}
+
+	if err := ctrl.services.Requeue(ctx.Request.Context(), id); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success requeue message",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// RequeueDead godoc
+// @Summary Requeue every dead message
+// @Description Give every message that ran out of delivery attempts a fresh set, such as after a mail server outage (admin only)
+// @Tags mail
+// @Produce json
+// @Success 200 {object} utils.ResponseWithData{data=dto.RequeueResponse} "OK"
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /mail/outbox/requeue [post]
+func (ctrl *outboxController) RequeueDead(ctx *gin.Context) {
+	count, err := ctrl.services.RequeueDead(ctx.Request.Context())
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success requeue messages",
+		Data:       dto.RequeueResponse{Requeued: count},
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type roleController struct {
+	services services.RoleService
+}
+
+func NewRoleController(roleService services.RoleService) *roleController {
+	return &roleController{
+		services: roleService,
+	}
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description List every role with its permissions
+// @Tags roles
+// @Produce json
+// @Success 200 {object} utils.ResponseWithData{data=[]models.Role} "OK"
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /roles [get]
+func (ctrl *roleController) ListRoles(ctx *gin.Context) {
+	roles, err := ctrl.services.ListRoles()
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get roles",
+		Data:       roles,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetRole godoc
+// @Summary Get role by ID
+// @Tags roles
+// @Produce json
+// @Param id path int true "Role ID"
+// @Success 200 {object} utils.ResponseWithData{data=models.Role} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /roles/{id} [get]
+func (ctrl *roleController) GetRole(ctx *gin.Context) {
+	id, ok := pathID(ctx, "invalid role ID")
+	if !ok {
+		return
+	}
+
+	role, err := ctrl.services.GetRole(id)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get role",
+		Data:       role,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// CreateRole godoc
+// @Summary Create role
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateRoleRequest true "Create Role Request"
+// @Success 201 {object} utils.ResponseWithData{data=models.Role} "Created"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /roles [post]
+func (ctrl *roleController) CreateRole(ctx *gin.Context) {
+	var req dto.CreateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	role, err := ctrl.services.CreateRole(ctx.Request.Context(), &req)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusCreated,
+		Message:    "success create role",
+		Data:       role,
+	})
+
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// UpdateRole godoc
+// @Summary Update role
+// @Description Update the description of a role and replace its permissions
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID"
+// @Param request body dto.UpdateRoleRequest true "Update Role Request"
+// @Success 200 {object} utils.ResponseWithData{data=models.Role} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /roles/{id} [put]
+func (ctrl *roleController) UpdateRole(ctx *gin.Context) {
+	id, ok := pathID(ctx, "invalid role ID")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	role, err := ctrl.services.UpdateRole(ctx.Request.Context(), id, &req)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success update role",
+		Data:       role,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// DeleteRole godoc
+// @Summary Delete role
+// @Description Delete a role and remove it from every user. The default roles cannot be deleted.
+// @Tags roles
+// @Produce json
+// @Param id path int true "Role ID"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /roles/{id} [delete]
+func (ctrl *roleController) DeleteRole(ctx *gin.Context) {
+	id, ok := pathID(ctx, "invalid role ID")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.services.DeleteRole(ctx.Request.Context(), id); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success delete role",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ListPermissions godoc
+// @Summary List permissions
+// @Tags roles
+// @Produce json
+// @Success 200 {object} utils.ResponseWithData{data=[]models.Permission} "OK"
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /permissions [get]
+func (ctrl *roleController) ListPermissions(ctx *gin.Context) {
+	permissions, err := ctrl.services.ListPermissions()
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get permissions",
+		Data:       permissions,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// CreatePermission godoc
+// @Summary Create permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param request body dto.CreatePermissionRequest true "Create Permission Request"
+// @Success 201 {object} utils.ResponseWithData{data=models.Permission} "Created"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /permissions [post]
+func (ctrl *roleController) CreatePermission(ctx *gin.Context) {
+	var req dto.CreatePermissionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	permission, err := ctrl.services.CreatePermission(ctx.Request.Context(), &req)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusCreated,
+		Message:    "success create permission",
+		Data:       permission,
+	})
+
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// DeletePermission godoc
+// @Summary Delete permission
+// @Description Delete a permission and revoke it from every role. Built-in permissions cannot be deleted.
+// @Tags roles
+// @Produce json
+// @Param id path int true "Permission ID"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /permissions/{id} [delete]
+func (ctrl *roleController) DeletePermission(ctx *gin.Context) {
+	id, ok := pathID(ctx, "invalid permission ID")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.services.DeletePermission(ctx.Request.Context(), id); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success delete permission",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// AssignRoles godoc
+// @Summary Set user roles
+// @Description Replace the roles of a user. The change reaches the user's access token the next time it is refreshed.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body dto.AssignRolesRequest true "Assign Roles Request"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /user/{id}/roles [put]
+func (ctrl *roleController) AssignRoles(ctx *gin.Context) {
+	id, ok := pathID(ctx, "invalid user ID")
+	if !ok {
+		return
+	}
+
+	var req dto.AssignRolesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	if err := ctrl.services.AssignRoles(ctx.Request.Context(), id, &req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success assign roles",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// pathID parses the numeric :id path parameter, writing a bad request
+// response with message when it is not a number.
+func pathID(ctx *gin.Context, message string) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: message})
+		return 0, false
+	}
+
+	return id, true
+}
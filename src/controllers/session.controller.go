@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sessionController struct {
+	services services.SessionService
+}
+
+func NewSessionController(sessionService services.SessionService) *sessionController {
+	return &sessionController{
+		services: sessionService,
+	}
+}
+
+// ListSessions godoc
+// @Summary List active sessions
+// @Description List the devices where the authenticated user is signed in
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} utils.ResponseWithData{data=[]dto.SessionResponse} "OK"
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /sessions [get]
+func (ctrl *sessionController) ListSessions(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	sessions, err := ctrl.services.ListSessions(ctx.Request.Context(), user.Id, ctx.GetString("sessionId"))
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get sessions",
+		Data:       sessions,
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Sign the authenticated user out of one of their sessions
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /sessions/{id} [delete]
+func (ctrl *sessionController) RevokeSession(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	sessionId := ctx.Param("id")
+	if err := ctrl.services.RevokeSession(ctx.Request.Context(), user.Id, sessionId); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	if sessionId == ctx.GetString("sessionId") {
+		clearAuthCookies(ctx)
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success revoke session",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// RevokeOtherSessions godoc
+// @Summary Log out everywhere else
+// @Description Revoke every session of the authenticated user except the current one
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /sessions/others [delete]
+func (ctrl *sessionController) RevokeOtherSessions(ctx *gin.Context) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.services.RevokeOtherSessions(ctx.Request.Context(), user.Id, ctx.GetString("sessionId")); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success revoke other sessions",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke all sessions of a user
+// @Description Sign a user out of every device (admin only)
+// @Tags sessions
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /user/{id}/sessions [delete]
+func (ctrl *sessionController) RevokeUserSessions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: "invalid user ID"})
+		return
+	}
+
+	if err := ctrl.services.RevokeAllSessions(ctx.Request.Context(), id); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	res := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success revoke user sessions",
+	})
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// currentUser returns the user stored by middleware.Auth, writing an error
+// response when it is missing.
+func currentUser(ctx *gin.Context) (*models.User, bool) {
+	userObj, exists := ctx.Get("user")
+	if !exists {
+		errorhandler.ErrorHandler(ctx, &errorhandler.UnauthorizedError{Message: "unauthorized"})
+		return nil, false
+	}
+
+	user, ok := userObj.(*models.User)
+	if !ok {
+		errorhandler.ErrorHandler(ctx, &errorhandler.InternalServerError{Message: "invalid user context"})
+		return nil, false
+	}
+
+	return user, true
+}
@@ -5,16 +5,15 @@ import (
 	"strconv"
 
 	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/middleware"
 	"restApi-GoGin/src/models"
 	"restApi-GoGin/src/services"
 	"restApi-GoGin/src/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
-var validateUser = validator.New()
-
 type UserController struct {
 	service services.UserService
 }
@@ -25,18 +24,47 @@ func NewUserController(service services.UserService) *UserController {
 
 // GetAllUsers godoc
 // @Summary Get all users
+// @Description List users page by page. Filters can be combined; sort takes comma-separated fields (id, name, email, created_at, updated_at), each prefixed with "-" for descending order.
 // @Tags users
 // @Produce json
-// @Success 200 {array} models.User
+// @Param page query int false "Page number, starting at 1"
+// @Param per_page query int false "Users per page, at most 100"
+// @Param role query string false "Only users with this role"
+// @Param name query string false "Name contains"
+// @Param email query string false "Email contains"
+// @Param status query string false "active (default), deleted or all" Enums(active, deleted, all)
+// @Param created_from query string false "Created on or after this date (YYYY-MM-DD)"
+// @Param created_until query string false "Created on or before this date (YYYY-MM-DD)"
+// @Param sort query string false "Sort fields, e.g. -created_at,name"
+// @Success 200 {object} utils.ResponseWithData{data=[]models.User} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
 // @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
 // @Router /users [get]
 func (ctrl *UserController) GetAllUsers(ctx *gin.Context) {
-	users, err := ctrl.service.GetAllUsers()
+	var query dto.UserListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+	if err := utils.Validator.Struct(query); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+	users, paginate, err := ctrl.service.GetAllUsers(ctx.Request.Context(), &query)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get users"})
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, users)
+	response := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get users",
+		Paginate:   paginate,
+		Data:       users,
+	})
+	ctx.JSON(http.StatusOK, response)
 }
 
 // GetUserByEmail godoc
@@ -44,23 +72,28 @@ func (ctrl *UserController) GetAllUsers(ctx *gin.Context) {
 // @Tags users
 // @Produce json
 // @Param email query string true "User Email"
-// @Success 200 {object} models.User
+// @Success 200 {object} utils.ResponseWithData{data=models.User} "OK"
 // @Failure 404 {object} errorhandler.NotFoundError
 // @Failure 500 {object} errorhandler.InternalServerError
 // @Security BearerAuth
 // @Router /user/searchByEmail [get]
 func (ctrl *UserController) GetUserByEmail(ctx *gin.Context) {
 	email := ctx.Query("email")
-	user, err := ctrl.service.GetUserByEmail(email)
+	user, err := ctrl.service.GetUserByEmail(ctx.Request.Context(), email)
 	if err != nil {
-		if err.Error() == "record not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get user"})
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, user)
+	if user == nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.NotFoundError{Message: "user not found"})
+		return
+	}
+	response := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get user",
+		Data:       user,
+	})
+	ctx.JSON(http.StatusOK, response)
 }
 
 // GetUserByID godoc
@@ -68,7 +101,7 @@ func (ctrl *UserController) GetUserByEmail(ctx *gin.Context) {
 // @Tags users
 // @Produce json
 // @Param id path int true "User ID"
-// @Success 200 {object} models.User
+// @Success 200 {object} utils.ResponseWithData{data=models.User} "OK"
 // @Failure 404 {object} errorhandler.NotFoundError
 // @Failure 500 {object} errorhandler.InternalServerError
 // @Security BearerAuth
@@ -77,24 +110,29 @@ func (ctrl *UserController) GetUserByID(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user ID"})
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: "invalid user ID"})
 		return
 	}
-	user, err := ctrl.service.GetUserByID(id)
+	user, err := ctrl.service.GetUserByID(ctx.Request.Context(), id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get user"})
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 	if user == nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		errorhandler.ErrorHandler(ctx, &errorhandler.NotFoundError{Message: "user not found"})
 		return
 	}
-	ctx.JSON(http.StatusOK, user)
+	response := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get user",
+		Data:       user,
+	})
+	ctx.JSON(http.StatusOK, response)
 }
 
 // CreateUser godoc
 // @Summary Create a new user
-// @Description Create a new user (admin only)
+// @Description Create a new user (admin only). Choosing a role other than user also needs the roles:manage permission.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -109,24 +147,29 @@ func (ctrl *UserController) GetUserByID(ctx *gin.Context) {
 func (ctrl *UserController) CreateUser(ctx *gin.Context) {
 	var req dto.RegisterRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
 		return
 	}
-	if err := validateUser.Struct(req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 	passwordHash, err := utils.HashBcrypt(req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to hash password"})
+		errorhandler.ErrorHandler(ctx, &errorhandler.InternalServerError{Message: "failed to hash password"})
 		return
 	}
-	role := "user"
+	role := models.RoleUser
 	if ctx.PostForm("role") != "" {
+		// Choosing the role is role management, as on PUT /user/:id/roles.
+		if err := middleware.CheckPermission(ctx, models.PermissionRolesManage); err != nil {
+			errorhandler.ErrorHandler(ctx, err)
+			return
+		}
 		role = ctx.PostForm("role")
 	}
-	if err := ctrl.service.CreateUser(req.Name, req.Email, passwordHash, role); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	if err := ctrl.service.CreateUser(ctx.Request.Context(), req.Name, req.Email, passwordHash, role); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 	response := utils.Response(dto.ResponseParams{
@@ -138,7 +181,7 @@ func (ctrl *UserController) CreateUser(ctx *gin.Context) {
 
 // UpdateUser godoc
 // @Summary Update user
-// @Description Update user data (name, email, password, role). Hanya field yang diisi yang akan diupdate.
+// @Description Update user data (name, email, password, role). Hanya field yang diisi yang akan diupdate. Changing the role also needs the roles:manage permission.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -147,6 +190,7 @@ func (ctrl *UserController) CreateUser(ctx *gin.Context) {
 // @Success 200 {object} utils.ResponseWithoutData "OK"
 // @Failure 400 {object} errorhandler.BadRequestError
 // @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
 // @Failure 404 {object} errorhandler.NotFoundError
 // @Failure 500 {object} errorhandler.InternalServerError
 // @Security BearerAuth
@@ -155,17 +199,17 @@ func (ctrl *UserController) UpdateUser(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user ID"})
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: "invalid user ID"})
 		return
 	}
 
 	var req dto.UpdateUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
 		return
 	}
-	if err := validateUser.Struct(req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
@@ -179,20 +223,21 @@ func (ctrl *UserController) UpdateUser(ctx *gin.Context) {
 	if req.Password != "" {
 		hash, err := utils.HashBcrypt(req.Password)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to hash password"})
+			errorhandler.ErrorHandler(ctx, &errorhandler.InternalServerError{Message: "failed to hash password"})
 			return
 		}
 		passwordPtr = &hash
 	}
 	if req.Role != "" {
-		rolePtr = &req.Role
-	}
-	if err := ctrl.service.UpdateUser(id, namePtr, emailPtr, passwordPtr, rolePtr); err != nil {
-		if err.Error() == "record not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		// Changing the role is role management, as on PUT /user/:id/roles.
+		if err := middleware.CheckPermission(ctx, models.PermissionRolesManage); err != nil {
+			errorhandler.ErrorHandler(ctx, err)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		rolePtr = &req.Role
+	}
+	if err := ctrl.service.UpdateUser(ctx.Request.Context(), id, namePtr, emailPtr, passwordPtr, rolePtr); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
@@ -219,25 +264,18 @@ func (ctrl *UserController) UpdateUser(ctx *gin.Context) {
 // @Security BearerAuth
 // @Router /user/profile [put]
 func (ctrl *UserController) UpdateProfile(ctx *gin.Context) {
-	userObj, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
-	}
-
-	user, ok := userObj.(*models.User)
+	user, ok := currentUser(ctx)
 	if !ok {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Invalid user context"})
 		return
 	}
 
 	var req dto.UpdateProfileRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
 		return
 	}
-	if err := validateUser.Struct(req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	if err := utils.Validator.Struct(req); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
@@ -250,17 +288,15 @@ func (ctrl *UserController) UpdateProfile(ctx *gin.Context) {
 	}
 
 	id := user.Id
-	if user.Role == "admin" && ctx.Query("id") != "" {
-		if idParam, err := strconv.Atoi(ctx.Query("id")); err == nil {
-			id = idParam
-		}
-	}
-	if err := ctrl.service.UpdateUser(id, namePtr, emailPtr, nil, nil); err != nil {
-		if err.Error() == "record not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+	if idParam, err := strconv.Atoi(ctx.Query("id")); err == nil && idParam != user.Id {
+		if err := middleware.CheckPermission(ctx, models.PermissionUsersUpdate); err != nil {
+			errorhandler.ErrorHandler(ctx, err)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		id = idParam
+	}
+	if err := ctrl.service.UpdateUser(ctx.Request.Context(), id, namePtr, emailPtr, nil, nil); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
@@ -287,45 +323,35 @@ func (ctrl *UserController) UpdateProfile(ctx *gin.Context) {
 // @Router /user/{id} [delete]
 func (ctrl *UserController) DeleteUser(ctx *gin.Context) {
 	// Get authenticated user from context
-	userObj, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
-	}
-
-	user, ok := userObj.(*models.User)
+	user, ok := currentUser(ctx)
 	if !ok {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Invalid user context"})
 		return
 	}
 
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user ID"})
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: "invalid user ID"})
 		return
 	}
 
-	if user.Role != "admin" && user.Id != id {
-		ctx.JSON(http.StatusForbidden, gin.H{"message": "Access denied: you can only delete your own account"})
-		return
-	}
-
-	if err := ctrl.service.DeleteUser(id); err != nil {
-		if err.Error() == "record not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+	if user.Id != id {
+		if err := middleware.CheckPermission(ctx, models.PermissionUsersDelete); err != nil {
+			errorhandler.ErrorHandler(ctx, err)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete user"})
+	}
+
+	if err := ctrl.service.DeleteUser(ctx.Request.Context(), id); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
 		return
 	}
 
 	// If user is deleting their own account (including admin), clear cookies to logout
 	// If admin is deleting other user, the deleted user will be automatically logged out
-	// when they try to access any protected endpoint due to DeletedAt check in middleware
+	// when they try to access any protected endpoint, as middleware no longer finds them
 	if user.Id == id {
-		ctx.SetCookie("accessToken", "", -1, "/", "", false, true)
-		ctx.SetCookie("refreshToken", "", -1, "/", "", false, true)
+		clearAuthCookies(ctx)
 	}
 
 	response := utils.Response(dto.ResponseParams{
@@ -334,3 +360,113 @@ func (ctrl *UserController) DeleteUser(ctx *gin.Context) {
 	})
 	ctx.JSON(http.StatusOK, response)
 }
+
+// GetDeletedUsers godoc
+// @Summary List deleted users
+// @Description List soft-deleted users page by page, most recently deleted first. Accepts the filters and sort fields of GET /users, plus deleted_at.
+// @Tags users
+// @Produce json
+// @Param page query int false "Page number, starting at 1"
+// @Param per_page query int false "Users per page, at most 100"
+// @Param role query string false "Only users with this role"
+// @Param name query string false "Name contains"
+// @Param email query string false "Email contains"
+// @Param created_from query string false "Created on or after this date (YYYY-MM-DD)"
+// @Param created_until query string false "Created on or before this date (YYYY-MM-DD)"
+// @Param sort query string false "Sort fields, e.g. -deleted_at,name"
+// @Success 200 {object} utils.ResponseWithData{data=[]models.User} "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /users/deleted [get]
+func (ctrl *UserController) GetDeletedUsers(ctx *gin.Context) {
+	var query dto.UserListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: err.Error()})
+		return
+	}
+	if err := utils.Validator.Struct(query); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+	users, paginate, err := ctrl.service.GetDeletedUsers(ctx.Request.Context(), &query)
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+	response := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success get deleted users",
+		Paginate:   paginate,
+		Data:       users,
+	})
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RestoreUser godoc
+// @Summary Restore a deleted user
+// @Description Undo the deletion of a user. The user can log in again; their sessions were not kept.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /user/{id}/restore [post]
+func (ctrl *UserController) RestoreUser(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: "invalid user ID"})
+		return
+	}
+
+	if err := ctrl.service.RestoreUser(ctx.Request.Context(), id); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	response := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success restore user",
+	})
+	ctx.JSON(http.StatusOK, response)
+}
+
+// PurgeUser godoc
+// @Summary Permanently delete a deleted user
+// @Description Remove a deleted user together with their role assignments, sessions, refresh tokens and recovery codes. This cannot be undone. The user has to be deleted first.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.ResponseWithoutData "OK"
+// @Failure 400 {object} errorhandler.BadRequestError
+// @Failure 401 {object} errorhandler.UnauthorizedError
+// @Failure 403 {object} errorhandler.ForbiddenError
+// @Failure 404 {object} errorhandler.NotFoundError
+// @Failure 500 {object} errorhandler.InternalServerError
+// @Security BearerAuth
+// @Router /user/{id}/purge [delete]
+func (ctrl *UserController) PurgeUser(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		errorhandler.ErrorHandler(ctx, &errorhandler.BadRequestError{Message: "invalid user ID"})
+		return
+	}
+
+	if err := ctrl.service.PurgeUser(ctx.Request.Context(), id); err != nil {
+		errorhandler.ErrorHandler(ctx, err)
+		return
+	}
+
+	response := utils.Response(dto.ResponseParams{
+		StatusCode: http.StatusOK,
+		Message:    "success purge user",
+	})
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,26 @@
+package dto
+
+// AuditListQuery represents the query parameters of GET /audit and
+// GET /audit/export
+type AuditListQuery struct {
+	Page       int    `form:"page" validate:"omitempty,min=1" example:"1"`
+	PerPage    int    `form:"per_page" validate:"omitempty,min=1,max=100" example:"20"`
+	Action     string `form:"action" example:"user.roles"`
+	ActorId    int    `form:"actor_id" validate:"omitempty,min=1" example:"1"`
+	TargetType string `form:"target_type" validate:"omitempty,oneof=user role permission" example:"user"`
+	TargetId   string `form:"target_id" example:"42"`
+	RequestId  string `form:"request_id" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	From       string `form:"from" validate:"omitempty,datetime=2006-01-02" example:"2026-01-01"`
+	Until      string `form:"until" validate:"omitempty,datetime=2006-01-02" example:"2026-12-31"`
+}
+
+// AuditVerifyResponse represents the result of checking the audit log's hash
+// chain
+type AuditVerifyResponse struct {
+	Valid    bool   `json:"valid" example:"true"`
+	Entries  int64  `json:"entries" example:"1024"`
+	HeadId   int64  `json:"head_id" example:"1024"`
+	HeadHash string `json:"head_hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	BrokenAt int64  `json:"broken_at,omitempty" example:"0"`
+	Problem  string `json:"problem,omitempty"`
+}
@@ -14,12 +14,49 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required,min=6" example:"password123"`
 }
 
-// LoginResponse represents the response body for successful login
+// LoginResponse represents the response body for successful login.
+// When the user has MFA enabled only MFARequired and MFAToken are set and the
+// token must be exchanged at /login/mfa.
 type LoginResponse struct {
-	ID    int    `json:"id" example:"1"`
-	Name  string `json:"name" example:"John Doe"`
-	Email string `json:"email" example:"john@example.com"`
-	Role  string `json:"role" example:"user"`
+	ID          int            `json:"id,omitempty" example:"1"`
+	Name        string         `json:"name,omitempty" example:"John Doe"`
+	Email       string         `json:"email,omitempty" example:"john@example.com"`
+	Roles       []string       `json:"roles,omitempty" example:"user"`
+	MFARequired bool           `json:"mfa_required" example:"false"`
+	MFAToken    string         `json:"mfa_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Tokens      *TokenResponse `json:"tokens,omitempty"`
+}
+
+// TokenResponse represents the tokens returned in the response body to
+// clients that send "X-Token-Delivery: body" instead of relying on cookies
+type TokenResponse struct {
+	AccessToken  string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int    `json:"expires_in" example:"900"`
+}
+
+// RefreshTokenRequest represents the optional request body for refreshing or
+// revoking a refresh token that is not sent as a cookie
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// LoginMFARequest represents the request body for completing an MFA login
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Code     string `json:"code" validate:"required" example:"123456"`
+}
+
+// VerifyEmailRequest represents the request body for email verification
+type VerifyEmailRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john@example.com"`
+	OTP   string `json:"otp" validate:"required" example:"123456"`
+}
+
+// ResendVerificationRequest represents the request body for resending the verification code
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john@example.com"`
 }
 
 // ForgotPasswordRequest represents the request body for forgot password
@@ -63,3 +100,9 @@ type UpdateProfileRequest struct {
 	Name  string `json:"name" validate:"omitempty" example:"John Doe"`
 	Email string `json:"email" validate:"omitempty,email" example:"john@example.com"`
 }
+
+// ClientInfo carries request metadata recorded alongside issued refresh tokens
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
@@ -0,0 +1,18 @@
+package dto
+
+// MFACodeRequest represents a request carrying a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required" example:"123456"`
+}
+
+// MFAEnrollResponse represents the secret to load into an authenticator app
+type MFAEnrollResponse struct {
+	Secret          string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	ProvisioningURI string `json:"provisioning_uri" example:"otpauth://totp/Boilerplate%20Go%20Gin:john@example.com?algorithm=SHA1&digits=6&issuer=Boilerplate+Go+Gin&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+// MFARecoveryCodesResponse represents freshly generated recovery codes.
+// They are only shown once.
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7m2p-x9q4r,h3n8t-w6y2z"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+// OutboxListQuery represents the query parameters of GET /mail/outbox
+type OutboxListQuery struct {
+	Page    int    `form:"page" validate:"omitempty,min=1" example:"1"`
+	PerPage int    `form:"per_page" validate:"omitempty,min=1,max=100" example:"10"`
+	Status  string `form:"status" validate:"omitempty,oneof=pending sent dead" example:"dead"`
+}
+
+// RequeueResponse represents the number of dead messages requeued
+type RequeueResponse struct {
+	Requeued int64 `json:"requeued" example:"3"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+// CreateRoleRequest represents the request body for creating a role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=100" example:"editor"`
+	Description string   `json:"description" validate:"max=255" example:"Can manage content"`
+	Permissions []string `json:"permissions" example:"users:read"`
+}
+
+// UpdateRoleRequest represents the request body for updating a role. The
+// permissions replace the ones the role had.
+type UpdateRoleRequest struct {
+	Description string   `json:"description" validate:"max=255" example:"Can manage content"`
+	Permissions []string `json:"permissions" example:"users:read"`
+}
+
+// CreatePermissionRequest represents the request body for creating a permission
+type CreatePermissionRequest struct {
+	Name        string `json:"name" validate:"required,max=100" example:"reports:read"`
+	Description string `json:"description" validate:"max=255" example:"View reports"`
+}
+
+// AssignRolesRequest represents the request body for setting the roles of a user
+type AssignRolesRequest struct {
+	Roles []string `json:"roles" validate:"required" example:"admin"`
+}
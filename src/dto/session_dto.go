@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+// SessionResponse represents an active sign-in of the current user
+type SessionResponse struct {
+	ID         string    `json:"id" example:"8c5f0a56-5c3c-4a4e-9a77-3f4c5c2b1e10"`
+	Device     string    `json:"device" example:"Chrome on Windows"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/126.0"`
+	IP         string    `json:"ip" example:"203.0.113.10"`
+	LastSeenAt time.Time `json:"last_seen_at" example:"2024-01-01T10:00:00Z"`
+	CreatedAt  time.Time `json:"created_at" example:"2024-01-01T09:00:00Z"`
+	Current    bool      `json:"current" example:"true"`
+}
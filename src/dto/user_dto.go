@@ -0,0 +1,14 @@
+package dto
+
+// UserListQuery represents the query parameters of GET /users
+type UserListQuery struct {
+	Page         int    `form:"page" validate:"omitempty,min=1" example:"1"`
+	PerPage      int    `form:"per_page" validate:"omitempty,min=1,max=100" example:"10"`
+	Role         string `form:"role" example:"admin"`
+	Name         string `form:"name" example:"john"`
+	Email        string `form:"email" example:"example.com"`
+	Status       string `form:"status" validate:"omitempty,oneof=active deleted all" example:"active"`
+	CreatedFrom  string `form:"created_from" validate:"omitempty,datetime=2006-01-02" example:"2026-01-01"`
+	CreatedUntil string `form:"created_until" validate:"omitempty,datetime=2006-01-02" example:"2026-12-31"`
+	Sort         string `form:"sort" example:"-created_at,name"`
+}
@@ -1,32 +1,116 @@
 package errorhandler
 
 import (
-	"restApi-GoGin/src/dto"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"restApi-GoGin/src/logger"
 	"restApi-GoGin/src/utils"
 
 	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
 )
 
+// ErrorHandler writes err as an ErrorResponse and aborts the request. Typed
+// client errors from this package keep their message; gorm.ErrRecordNotFound
+// is mapped to 404, validator errors to 400 with one FieldError per failed
+// rule, and InternalServerError or any other error becomes a 500 without
+// exposing its message, which often holds driver errors or SQL. The response
+// carries the request ID; server errors are logged with it, so the ID a
+// client reports leads to the underlying error.
 func ErrorHandler(c *gin.Context, err error) {
-	var statusCode int
-
-	switch err.(type) {
-	case *NotFoundError:
-		statusCode = 404
-	case *BadRequestError:
-		statusCode = 400
-	case *ForbiddenError:
-		statusCode = 403
-	case *UnauthorizedError:
-		statusCode = 401
-	case *InternalServerError:
-		statusCode = 500
+	_ = c.Error(err)
+
+	var locked *LockedError
+	if errors.As(err, &locked) && locked.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(locked.RetryAfter))
+	}
+
+	statusCode, code, message := resolve(err)
+	response := ErrorResponse{
+		Code:    statusCode,
+		Status:  "error",
+		Error:   code,
+		Message: message,
+	}
+
+	if c.Request != nil {
+		ctx := c.Request.Context()
+		response.RequestID = logger.RequestID(ctx)
+		if statusCode >= http.StatusInternalServerError {
+			logger.FromContext(ctx).Error("request failed", "route", c.FullPath(), "error", err)
+		}
+	}
+
+	var validation validator.ValidationErrors
+	if errors.As(err, &validation) {
+		response.Errors = fieldErrors(validation, utils.ValidationTranslator(c.GetHeader("Accept-Language")))
+	}
+
+	c.AbortWithStatusJSON(statusCode, response)
+}
+
+// fieldErrors converts validation errors to FieldErrors with messages from
+// translator.
+func fieldErrors(validation validator.ValidationErrors, translator ut.Translator) []FieldError {
+	result := make([]FieldError, 0, len(validation))
+	for _, fe := range validation {
+		// The namespace starts with the struct name, e.g. "RegisterRequest.email".
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		if field == "" {
+			field = fe.Field()
+		}
+
+		result = append(result, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(translator),
+		})
 	}
 
-	response := utils.Response(dto.ResponseParams{
-		StatusCode: statusCode,
-		Message:    err.Error(),
-	})
+	return result
+}
+
+// internalMessage is the only message clients see for server errors.
+const internalMessage = "internal server error"
+
+func resolve(err error) (int, string, string) {
+	var (
+		notFound        *NotFoundError
+		badRequest      *BadRequestError
+		forbidden       *ForbiddenError
+		unauthorized    *UnauthorizedError
+		tooManyRequests *TooManyRequestsError
+		locked          *LockedError
+		internal        *InternalServerError
+		validation      validator.ValidationErrors
+	)
+
+	switch {
+	case errors.As(err, &notFound):
+		return http.StatusNotFound, CodeNotFound, notFound.Message
+	case errors.As(err, &badRequest):
+		return http.StatusBadRequest, CodeBadRequest, badRequest.Message
+	case errors.As(err, &forbidden):
+		return http.StatusForbidden, CodeForbidden, forbidden.Message
+	case errors.As(err, &unauthorized):
+		return http.StatusUnauthorized, CodeUnauthorized, unauthorized.Message
+	case errors.As(err, &tooManyRequests):
+		return http.StatusTooManyRequests, CodeTooManyRequests, tooManyRequests.Message
+	case errors.As(err, &locked):
+		return http.StatusLocked, CodeLocked, locked.Message
+	case errors.As(err, &internal):
+		return http.StatusInternalServerError, CodeInternal, internalMessage
+	case errors.As(err, &validation):
+		return http.StatusBadRequest, CodeValidationFailed, "request validation failed"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound, CodeNotFound, "record not found"
+	}
 
-	c.JSON(statusCode, response)
+	return http.StatusInternalServerError, CodeInternal, internalMessage
 }
@@ -1,10 +1,37 @@
 package errorhandler
 
+// Machine-readable error codes sent in the error field of ErrorResponse.
+// Clients should branch on these rather than on the message.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeLocked           = "locked"
+	CodeTooManyRequests  = "too_many_requests"
+	CodeInternal         = "internal_error"
+)
+
 // ErrorResponse represents a standardized error response
 type ErrorResponse struct {
-	Code    int    `json:"code" example:"400"`
-	Status  string `json:"status" example:"error"`
-	Message string `json:"message" example:"Bad request"`
+	Code    int          `json:"code" example:"400"`
+	Status  string       `json:"status" example:"error"`
+	Error   string       `json:"error" example:"bad_request"`
+	Message string       `json:"message" example:"Bad request"`
+	Errors  []FieldError `json:"errors,omitempty"`
+	// RequestID identifies the request in the server logs.
+	RequestID string `json:"request_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+}
+
+// FieldError describes one failed validation rule of a request field. Field
+// is the JSON name of the field and Message is translated according to the
+// Accept-Language header.
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Rule    string `json:"rule" example:"email"`
+	Param   string `json:"param,omitempty" example:""`
+	Message string `json:"message" example:"email must be a valid email address"`
 }
 
 // NotFoundError represents a 404 Not Found error
@@ -32,6 +59,18 @@ type ForbiddenError struct {
 	Message string
 }
 
+// TooManyRequestsError represents a 429 Too Many Requests error
+type TooManyRequestsError struct {
+	Message string
+}
+
+// LockedError represents a 423 Locked error returned while an account or
+// client is temporarily locked out. RetryAfter is in seconds.
+type LockedError struct {
+	Message    string
+	RetryAfter int
+}
+
 func (e *NotFoundError) Error() string {
 	return e.Message
 }
@@ -51,3 +90,11 @@ func (e *UnauthorizedError) Error() string {
 func (e *ForbiddenError) Error() string {
 	return e.Message
 }
+
+func (e *TooManyRequestsError) Error() string {
+	return e.Message
+}
+
+func (e *LockedError) Error() string {
+	return e.Message
+}
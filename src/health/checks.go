@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+
+	"restApi-GoGin/src/migration"
+
+	"gorm.io/gorm"
+)
+
+// Database pings the connection pool of db.
+func Database(db *gorm.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// Migrations fails while the schema is behind the migrations embedded in the
+// binary, such as when the server was started with -migrate=false ahead of
+// a migrate run.
+func Migrations(db *gorm.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		migrator, err := migration.NewMigrator(db, nil)
+		if err != nil {
+			return err
+		}
+
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations, next is %d %s", len(pending), pending[0].Version, pending[0].Name)
+		}
+		return nil
+	})
+}
+
+// SMTP connects to the mail server at addr and waits for its greeting,
+// without authenticating or sending anything. With implicitTLS the
+// connection is made over TLS, as for port 465.
+func SMTP(addr string, implicitTLS bool) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		var conn net.Conn
+		var err error
+		dialer := &net.Dialer{}
+		if implicitTLS {
+			conn, err = (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", addr)
+		} else {
+			conn, err = dialer.DialContext(ctx, "tcp", addr)
+		}
+		if err != nil {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+
+		host, _, _ := net.SplitHostPort(addr)
+		client, err := smtp.NewClient(conn, host)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		defer client.Close()
+
+		return client.Quit()
+	})
+}
@@ -0,0 +1,185 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check statuses. A failing optional check is reported as StatusWarn and
+// does not fail readiness.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// DefaultTimeout bounds a check that was registered without a timeout.
+const DefaultTimeout = 2 * time.Second
+
+// Checker reports whether a dependency is usable. Check should return
+// promptly once ctx is done.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Options tune how a registered check runs.
+type Options struct {
+	// Timeout bounds each run of the check. Zero means DefaultTimeout.
+	Timeout time.Duration
+	// Optional checks are reported but do not fail readiness.
+	Optional bool
+}
+
+// CheckResult is the outcome of one check.
+type CheckResult struct {
+	Status    string  `json:"status" example:"ok"`
+	LatencyMs float64 `json:"latency_ms" example:"1.25"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every registered check.
+type Report struct {
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Healthy reports whether every required check passed.
+func (r Report) Healthy() bool {
+	return r.Status != StatusFail
+}
+
+type registration struct {
+	name    string
+	checker Checker
+	options Options
+}
+
+// Registry holds the checks run by readiness probes.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]registration
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]registration)}
+}
+
+// Register adds checker under name, replacing any check already registered
+// with that name.
+func (r *Registry) Register(name string, checker Checker, options Options) {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = registration{name: name, checker: checker, options: options}
+}
+
+// Names lists the registered checks in alphabetical order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run runs every check concurrently, each bounded by its own timeout.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]registration, 0, len(r.checks))
+	for _, check := range r.checks {
+		checks = append(checks, check)
+	}
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	for i, check := range checks {
+		result := results[i]
+		report.Checks[check.name] = result
+		if result.Status == StatusFail {
+			report.Status = StatusFail
+		} else if result.Status == StatusWarn && report.Status == StatusOK {
+			report.Status = StatusWarn
+		}
+	}
+
+	return report
+}
+
+func run(ctx context.Context, check registration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, check.options.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.checker.Check(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	result := CheckResult{
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusFail
+		if check.options.Optional {
+			result.Status = StatusWarn
+		}
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// checks is the registry served by /readyz.
+var checks = NewRegistry()
+
+// Register adds a check to the registry served by /readyz.
+func Register(name string, checker Checker, options Options) {
+	checks.Register(name, checker, options)
+}
+
+// Default returns the registry served by /readyz.
+func Default() *Registry {
+	return checks
+}
+
+var draining atomic.Bool
+
+// SetDraining marks the process as shutting down. Readiness fails from then
+// on so load balancers stop routing new requests to it.
+func SetDraining() {
+	draining.Store(true)
+}
+
+// Draining reports whether the process is shutting down.
+func Draining() bool {
+	return draining.Load()
+}
@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/repository"
+)
+
+// Names of the built-in jobs.
+const (
+	PurgeExpiredCodes    = "purge_expired_codes"
+	PurgeExpiredSessions = "purge_expired_sessions"
+	PurgeDeletedUsers    = "purge_deleted_users"
+)
+
+// purgeBatchSize is the number of users deleted per transaction, which keeps
+// locks short when many accounts expire at once.
+const purgeBatchSize = 500
+
+// RegisterBuiltins registers the built-in cleanup jobs:
+//   - purge_expired_codes erases expired one-time codes and reset tokens.
+//   - purge_expired_sessions deletes expired sessions and refresh tokens.
+//   - purge_deleted_users permanently deletes users soft-deleted more than
+//     retention ago.
+func RegisterBuiltins(registry Registry, users repository.UserRepository, sessions repository.SessionRepository, retention time.Duration, log *slog.Logger) {
+	registry.Register(PurgeExpiredCodes, func(ctx context.Context, _ json.RawMessage) error {
+		cleared, err := users.ClearExpiredCodes(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		log.Info("purged expired codes", "users", cleared)
+		return nil
+	})
+
+	registry.Register(PurgeExpiredSessions, func(ctx context.Context, _ json.RawMessage) error {
+		deleted, err := sessions.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		log.Info("purged expired sessions", "sessions", deleted)
+		return nil
+	})
+
+	registry.Register(PurgeDeletedUsers, func(ctx context.Context, _ json.RawMessage) error {
+		deletedBefore := time.Now().Add(-retention)
+		var total int64
+		for {
+			purged, err := users.PurgeDeleted(ctx, deletedBefore, purgeBatchSize)
+			total += purged
+			if err != nil {
+				return err
+			}
+			if purged < purgeBatchSize {
+				break
+			}
+		}
+		log.Info("purged deleted users", "users", total, "deleted_before", deletedBefore)
+		return nil
+	})
+}
+
+// BuiltinSchedules returns the cron expressions of the built-in jobs set by
+// the SCHEDULE_* settings, leaving out those disabled with an empty one.
+func BuiltinSchedules(cfg *config.Config) map[string]string {
+	schedules := map[string]string{}
+	for name, spec := range map[string]string{
+		PurgeExpiredCodes:    cfg.SCHEDULE_PURGE_CODES,
+		PurgeExpiredSessions: cfg.SCHEDULE_PURGE_SESSIONS,
+		PurgeDeletedUsers:    cfg.SCHEDULE_PURGE_USERS,
+	} {
+		if spec != "" {
+			schedules[name] = spec
+		}
+	}
+	return schedules
+}
@@ -0,0 +1,126 @@
+// Package jobs runs work outside the request path. Jobs are queued in the
+// database with Queue.Enqueue, run by the handlers registered under their
+// name by the worker of NewWorker, and retried with backoff when they fail.
+// The scheduler of NewScheduler enqueues jobs on cron schedules.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Handler runs one job. payload is the JSON the job was enqueued with, and
+// is empty when it had none. Returning an error, or panicking, fails the
+// attempt, and the job is retried until it runs out of attempts.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Registry holds the handlers of jobs by name.
+type Registry interface {
+	Register(name string, handler Handler)
+	Lookup(name string) (Handler, bool)
+	Names() []string
+}
+
+type registry struct {
+	handlers map[string]Handler
+}
+
+// NewRegistry returns an empty set of job handlers.
+func NewRegistry() *registry {
+	return &registry{handlers: map[string]Handler{}}
+}
+
+// Register makes handler run the jobs named name. It panics when name is
+// taken, as that is a programming error.
+func (r *registry) Register(name string, handler Handler) {
+	if _, ok := r.handlers[name]; ok {
+		panic(fmt.Sprintf("jobs: %q is registered twice", name))
+	}
+	r.handlers[name] = handler
+}
+
+// Names returns the registered job names in order.
+func (r *registry) Names() []string {
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Lookup returns the handler registered as name.
+func (r *registry) Lookup(name string) (Handler, bool) {
+	handler, ok := r.handlers[name]
+	return handler, ok
+}
+
+// EnqueueOptions configure one job. The zero value runs the job as soon as
+// a worker is free.
+type EnqueueOptions struct {
+	// RunAt delays the job until then.
+	RunAt time.Time
+	// UniqueKey, when set, skips the job while another job with the same key
+	// is pending.
+	UniqueKey string
+	// MaxAttempts overrides the number of attempts of the queue.
+	MaxAttempts int
+}
+
+type Queue interface {
+	Enqueue(ctx context.Context, name string, payload any, options EnqueueOptions) (bool, error)
+}
+
+type queue struct {
+	repo        repository.JobRepository
+	maxAttempts int
+}
+
+// NewQueue returns a Queue that stores jobs in repo, each tried up to
+// maxAttempts times unless its options say otherwise.
+func NewQueue(repo repository.JobRepository, maxAttempts int) *queue {
+	return &queue{repo: repo, maxAttempts: maxAttempts}
+}
+
+// Enqueue stores a job to be run by the handler registered as name with
+// payload encoded as JSON. It reports false when the job was skipped for its
+// unique key. Called with the context of repository.Transactor.Transaction,
+// the job is only enqueued if the transaction commits.
+func (q *queue) Enqueue(ctx context.Context, name string, payload any, options EnqueueOptions) (enqueued bool, err error) {
+	ctx, span := tracing.Start(ctx, "job.enqueue", attribute.String("job.name", name))
+	defer tracing.End(span, &err)
+
+	job := &models.Job{
+		Name:        name,
+		Status:      models.JobPending,
+		MaxAttempts: q.maxAttempts,
+		RunAt:       options.RunAt,
+	}
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return false, fmt.Errorf("encoding the payload of job %s: %w", name, err)
+		}
+		job.Payload = string(encoded)
+	}
+	if options.UniqueKey != "" {
+		job.UniqueKey = &options.UniqueKey
+	}
+	if options.MaxAttempts > 0 {
+		job.MaxAttempts = options.MaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+
+	return q.repo.Enqueue(ctx, job)
+}
@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"restApi-GoGin/src/cron"
+	"restApi-GoGin/src/repository"
+)
+
+type entry struct {
+	name     string
+	spec     string
+	schedule *cron.Schedule
+}
+
+type scheduler struct {
+	repo       repository.JobRepository
+	queue      Queue
+	transactor repository.Transactor
+	interval   time.Duration
+	log        *slog.Logger
+	entries    []entry
+	saved      bool
+}
+
+// NewScheduler returns a scheduler that enqueues jobs on queue when their
+// schedules come due, checking every interval. The next run of each
+// schedule is kept in repo, so replicas share the schedules: each run is
+// enqueued by one of them, and a run missed while no replica was up is
+// enqueued once when one starts.
+func NewScheduler(repo repository.JobRepository, queue Queue, transactor repository.Transactor, interval time.Duration, log *slog.Logger) *scheduler {
+	return &scheduler{repo: repo, queue: queue, transactor: transactor, interval: interval, log: log}
+}
+
+// Add schedules the job name on the cron expression spec, read by
+// cron.Parse and evaluated in the local time zone. A run is skipped when the
+// previous one is still pending.
+func (s *scheduler) Add(name, spec string) error {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("scheduling job %s: %w", name, err)
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("scheduling job %s: %q never runs", name, spec)
+	}
+
+	s.entries = append(s.entries, entry{name: name, spec: spec, schedule: schedule})
+	return nil
+}
+
+// Run enqueues due jobs until ctx is done.
+func (s *scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *scheduler) tick(ctx context.Context, now time.Time) {
+	if !s.saved {
+		for _, entry := range s.entries {
+			if err := s.repo.SaveSchedule(ctx, entry.name, entry.spec, entry.schedule.Next(now)); err != nil {
+				if ctx.Err() == nil {
+					s.log.Error("saving job schedule failed", "job", entry.name, "error", err)
+				}
+				return
+			}
+		}
+		s.saved = true
+	}
+
+	due, err := s.repo.DueSchedules(ctx, now)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("reading job schedules failed", "error", err)
+		}
+		return
+	}
+
+	for _, schedule := range due {
+		for _, entry := range s.entries {
+			if entry.name == schedule.Name {
+				s.enqueue(ctx, entry, now)
+			}
+		}
+	}
+}
+
+// enqueue moves the schedule of entry on and enqueues its job in one
+// transaction, so a run is neither lost nor enqueued twice.
+func (s *scheduler) enqueue(ctx context.Context, entry entry, now time.Time) {
+	err := s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		claimed, err := s.repo.ClaimSchedule(ctx, entry.name, now, entry.schedule.Next(now))
+		if err != nil || !claimed {
+			return err
+		}
+
+		enqueued, err := s.queue.Enqueue(ctx, entry.name, nil, EnqueueOptions{UniqueKey: "schedule:" + entry.name})
+		if err == nil && !enqueued {
+			s.log.Warn("skipping scheduled job, the previous run is still pending", "job", entry.name)
+		}
+		return err
+	})
+	if err != nil && ctx.Err() == nil {
+		s.log.Error("enqueueing scheduled job failed", "job", entry.name, "error", err)
+	}
+}
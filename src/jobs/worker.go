@@ -0,0 +1,181 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/metrics"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
+	"restApi-GoGin/src/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Options configure NewWorker and NewScheduler.
+type Options struct {
+	// Workers is the number of jobs run concurrently.
+	Workers int
+	// PollInterval is how long the worker waits when no job is due, and how
+	// often the scheduler looks for due schedules.
+	PollInterval time.Duration
+	// MaxAttempts is the number of attempts of a job enqueued without its
+	// own.
+	MaxAttempts int
+	// BaseBackoff is the delay after the first failure. It doubles with each
+	// further failure up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Timeout bounds one attempt. The lease on a claimed job is twice as
+	// long, so it is not claimed again while still running.
+	Timeout time.Duration
+}
+
+// OptionsFromConfig reads the JOB_* settings.
+func OptionsFromConfig(cfg *config.Config) Options {
+	return Options{
+		Workers:      cfg.JOB_WORKERS,
+		PollInterval: cfg.JOB_POLL_INTERVAL,
+		MaxAttempts:  cfg.JOB_MAX_ATTEMPTS,
+		BaseBackoff:  cfg.JOB_BASE_BACKOFF,
+		MaxBackoff:   cfg.JOB_MAX_BACKOFF,
+		Timeout:      cfg.JOB_TIMEOUT,
+	}
+}
+
+type worker struct {
+	repo     repository.JobRepository
+	registry Registry
+	options  Options
+	log      *slog.Logger
+}
+
+// NewWorker returns a worker that runs the jobs of repo with the handlers of
+// registry. Several replicas may run one each: a job is leased to one worker
+// at a time. A job runs at least once, as one whose lease runs out before
+// its handler returns is run again, so handlers must be safe to repeat.
+func NewWorker(repo repository.JobRepository, registry Registry, options Options, log *slog.Logger) *worker {
+	return &worker{repo: repo, registry: registry, options: options, log: log}
+}
+
+// Run runs due jobs until ctx is done, then waits for the jobs in progress
+// to finish.
+func (w *worker) Run(ctx context.Context) {
+	queue := make(chan models.Job)
+	var wg sync.WaitGroup
+	for range max(w.options.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				w.run(job)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(queue)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		claimed := w.dispatch(ctx, queue)
+		// Keep going while there is a backlog, otherwise wait for new jobs.
+		if claimed < max(w.options.Workers, 1) {
+			timer.Reset(w.options.PollInterval)
+		} else {
+			timer.Reset(0)
+		}
+	}
+}
+
+// dispatch claims a batch of due jobs and hands them to the pool. It returns
+// the number claimed.
+func (w *worker) dispatch(ctx context.Context, queue chan<- models.Job) int {
+	jobs, err := w.repo.Claim(ctx, w.registry.Names(), time.Now(), 2*w.options.Timeout, max(w.options.Workers, 1))
+	if err != nil && ctx.Err() == nil {
+		w.log.Error("claiming jobs failed", "error", err)
+	}
+	for _, job := range jobs {
+		// The lease of a job not handed over before shutdown runs out, and
+		// another worker picks it up.
+		select {
+		case queue <- job:
+		case <-ctx.Done():
+			return len(jobs)
+		}
+	}
+	return len(jobs)
+}
+
+// run runs one attempt of job and records the outcome. It does not use the
+// context of Run, so shutdown lets jobs in progress finish.
+func (w *worker) run(job models.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.options.Timeout)
+	defer cancel()
+
+	var err error
+	ctx, span := tracing.Start(ctx, "job.run",
+		attribute.String("job.name", job.Name),
+		attribute.Int64("job.id", job.Id),
+		attribute.Int("job.attempt", job.Attempts+1),
+	)
+	defer tracing.End(span, &err)
+
+	start := time.Now()
+	err = w.call(ctx, job)
+	duration := time.Since(start)
+	log := w.log.With("job", job.Name, "job_id", job.Id)
+
+	if err == nil {
+		metrics.JobRun(job.Name, metrics.JobDone, duration)
+		log.Debug("job done", "duration", duration)
+		if completeErr := w.repo.Complete(context.Background(), job.Id); completeErr != nil {
+			log.Error("recording job completion failed", "error", completeErr)
+		}
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	log = log.With("attempts", job.Attempts, "error", err)
+	if job.Attempts >= job.MaxAttempts {
+		job.Status = models.JobDead
+		metrics.JobRun(job.Name, metrics.JobDead, duration)
+		log.Error("job is dead after its last attempt")
+	} else {
+		job.RunAt = time.Now().Add(utils.Backoff(job.Attempts, w.options.BaseBackoff, w.options.MaxBackoff))
+		metrics.JobRun(job.Name, metrics.JobRetry, duration)
+		log.Warn("job failed, will retry", "run_at", job.RunAt)
+	}
+
+	if markErr := w.repo.MarkFailed(context.Background(), &job); markErr != nil {
+		log.Error("recording job failure failed", "mark_error", markErr)
+	}
+}
+
+// call runs the handler of job, turning a panic into an error.
+func (w *worker) call(ctx context.Context, job models.Job) (err error) {
+	handler, ok := w.registry.Lookup(job.Name)
+	if !ok {
+		return fmt.Errorf("no handler for job %s", job.Name)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job %s panicked: %v", job.Name, recovered)
+		}
+	}()
+	return handler(ctx, json.RawMessage(job.Payload))
+}
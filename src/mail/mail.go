@@ -0,0 +1,149 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"restApi-GoGin/src/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Drivers selected by MAIL_DRIVER.
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// Template names.
+const (
+	TemplateEmailVerification = "email_verification"
+	TemplatePasswordReset     = "password_reset"
+)
+
+// ErrNotConfigured is returned by the default sender until SetDefault is
+// called.
+var ErrNotConfigured = errors.New("no mailer configured")
+
+// Message is an email ready to send. Text is the plain text alternative of
+// HTML; either may be empty.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Sender renders a template in the locale of the request and mails it.
+type Sender interface {
+	SendTemplate(ctx context.Context, to, name string, data any) error
+}
+
+// Options configure New.
+type Options struct {
+	// Driver is DriverSMTP, DriverFile or DriverMemory.
+	Driver string
+	// From is the sender of messages that do not set one.
+	From string
+	SMTP SMTPOptions
+	// Dir is the maildir of DriverFile.
+	Dir string
+}
+
+// New returns the mailer of options.Driver.
+func New(options Options) (Mailer, error) {
+	var mailer Mailer
+	switch options.Driver {
+	case DriverSMTP:
+		mailer = NewSMTP(options.SMTP)
+	case DriverFile:
+		file, err := NewFile(options.Dir)
+		if err != nil {
+			return nil, err
+		}
+		mailer = file
+	case DriverMemory:
+		mailer = NewMemory()
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", options.Driver)
+	}
+	return &defaultFrom{mailer: mailer, from: options.From}, nil
+}
+
+// defaultFrom fills in the sender of messages that have none.
+type defaultFrom struct {
+	mailer Mailer
+	from   string
+}
+
+func (m *defaultFrom) Send(ctx context.Context, msg *Message) error {
+	if msg.From == "" {
+		msg.From = m.from
+	}
+	return m.mailer.Send(ctx, msg)
+}
+
+type sender struct {
+	mailer    Mailer
+	templates *Templates
+}
+
+// NewSender returns a Sender that renders with templates and sends with
+// mailer.
+func NewSender(mailer Mailer, templates *Templates) *sender {
+	return &sender{mailer: mailer, templates: templates}
+}
+
+func (s *sender) SendTemplate(ctx context.Context, to, name string, data any) (err error) {
+	ctx, span := tracing.Start(ctx, "mail.send", attribute.String("mail.template", name))
+	defer tracing.End(span, &err)
+
+	msg, err := s.templates.Render(Locale(ctx), name, data)
+	if err != nil {
+		return err
+	}
+	msg.To = []string{to}
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("sending %s email: %w", name, err)
+	}
+	return nil
+}
+
+var (
+	defaultSenderMu sync.RWMutex
+	defaultSender   Sender
+)
+
+// SetDefault sets the sender returned by Default.
+func SetDefault(s Sender) {
+	defaultSenderMu.Lock()
+	defer defaultSenderMu.Unlock()
+	defaultSender = s
+}
+
+// Default returns the sender set by SetDefault. Before that, every send
+// fails with ErrNotConfigured.
+func Default() Sender {
+	defaultSenderMu.RLock()
+	defer defaultSenderMu.RUnlock()
+	if defaultSender == nil {
+		return unconfigured{}
+	}
+	return defaultSender
+}
+
+type unconfigured struct{}
+
+func (unconfigured) SendTemplate(context.Context, string, string, any) error {
+	return ErrNotConfigured
+}
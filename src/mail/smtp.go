@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"time"
+)
+
+// TLS modes selected by SMTP_TLS.
+const (
+	// TLSStartTLS upgrades a plain connection, usually on port 587, and
+	// fails when the server does not offer STARTTLS.
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects with TLS from the start, usually on port 465.
+	TLSImplicit = "tls"
+	// TLSNone sends in the clear, for local servers such as Mailpit.
+	TLSNone = "none"
+)
+
+// smtpTimeout bounds a send when ctx has no deadline.
+const smtpTimeout = 30 * time.Second
+
+// SMTPOptions configure NewSMTP.
+type SMTPOptions struct {
+	Host string
+	Port string
+	// Username and Password authenticate with PLAIN when Username is set.
+	Username string
+	Password string
+	// TLS is TLSStartTLS, TLSImplicit or TLSNone.
+	TLS string
+	// TLSConfig overrides the TLS settings, such as the trusted roots.
+	TLSConfig *tls.Config
+}
+
+type smtpMailer struct {
+	options SMTPOptions
+}
+
+// NewSMTP returns a mailer that relays through an SMTP server.
+func NewSMTP(options SMTPOptions) *smtpMailer {
+	return &smtpMailer{options: options}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg *Message) error {
+	from, err := netmail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", msg.From, err)
+	}
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.options.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.options.Username, m.options.Password, m.options.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial connects and, depending on the TLS mode, secures the connection.
+func (m *smtpMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.options.Host, m.options.Port)
+	tlsConfig := m.options.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: m.options.Host}
+	}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{}
+	if m.options.TLS == TLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.options.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if m.options.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("%s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
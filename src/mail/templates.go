@@ -0,0 +1,198 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed templates
+var embedded embed.FS
+
+// OTPData is the data of the one-time code templates. ExpiresIn should be
+// the lifetime actually given to the code.
+type OTPData struct {
+	Name      string
+	Code      string
+	ExpiresIn time.Duration
+}
+
+type localeKey struct{}
+
+// WithLocale returns a copy of ctx carrying the preferred languages of the
+// recipient, as in an Accept-Language header.
+func WithLocale(ctx context.Context, acceptLanguage string) context.Context {
+	return context.WithValue(ctx, localeKey{}, acceptLanguage)
+}
+
+// Locale returns the preferred languages stored by WithLocale, or "".
+func Locale(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+var funcs = map[string]any{
+	"minutes": func(d time.Duration) int { return int(d.Round(time.Minute).Minutes()) },
+}
+
+// template is one email in one locale. The text template defines the
+// subject and the text body, the HTML template the HTML body.
+type template struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// Templates holds the email templates of every locale. Each locale is a
+// directory of <name>.html and <name>.txt pairs. Each file defines
+// "content", which layout.html or layout.txt at the root wraps, and the
+// text file also defines "subject".
+type Templates struct {
+	fallback string
+	locales  []string
+	matcher  language.Matcher
+	byLocale map[string]map[string]*template
+}
+
+// LoadTemplates parses the templates embedded in the binary. Locales without
+// a template fall back to fallback, which must have them all.
+func LoadTemplates(fallback string) (*Templates, error) {
+	fsys, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplates(fsys, fallback)
+}
+
+// ParseTemplates parses the templates of fsys. See Templates for the layout.
+func ParseTemplates(fsys fs.FS, fallback string) (*Templates, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Templates{fallback: fallback, byLocale: make(map[string]map[string]*template)}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := entry.Name()
+		templates, err := parseLocale(fsys, locale)
+		if err != nil {
+			return nil, err
+		}
+		t.byLocale[locale] = templates
+	}
+
+	if _, ok := t.byLocale[fallback]; !ok {
+		return nil, fmt.Errorf("no email templates for the fallback locale %q", fallback)
+	}
+	for locale, templates := range t.byLocale {
+		for name := range templates {
+			if _, ok := t.byLocale[fallback][name]; !ok {
+				return nil, fmt.Errorf("email template %s/%s has no %s variant", locale, name, fallback)
+			}
+		}
+	}
+
+	// The fallback goes first, as the matcher picks the first locale when
+	// none matches.
+	t.locales = append(t.locales, fallback)
+	for locale := range t.byLocale {
+		if locale != fallback {
+			t.locales = append(t.locales, locale)
+		}
+	}
+	slices.Sort(t.locales[1:])
+
+	tags := make([]language.Tag, 0, len(t.locales))
+	for _, locale := range t.locales {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("email template locale %q: %w", locale, err)
+		}
+		tags = append(tags, tag)
+	}
+	t.matcher = language.NewMatcher(tags)
+
+	return t, nil
+}
+
+func parseLocale(fsys fs.FS, locale string) (map[string]*template, error) {
+	files, err := fs.Glob(fsys, path.Join(locale, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*template)
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".html")
+		textFile := path.Join(locale, name+".txt")
+
+		html, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFS(fsys, "layout.html", file)
+		if err != nil {
+			return nil, err
+		}
+		text, err := texttemplate.New("layout.txt").Funcs(funcs).ParseFS(fsys, "layout.txt", textFile)
+		if err != nil {
+			return nil, err
+		}
+		if text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("%s does not define a subject", textFile)
+		}
+		templates[name] = &template{html: html, text: text}
+	}
+	return templates, nil
+}
+
+// Locales lists the locales with templates, the fallback first.
+func (t *Templates) Locales() []string {
+	return slices.Clone(t.locales)
+}
+
+// Render renders the template name in the locale that best matches
+// acceptLanguage, such as "id-ID,id;q=0.9,en;q=0.8".
+func (t *Templates) Render(acceptLanguage, name string, data any) (*Message, error) {
+	tmpl, ok := t.byLocale[t.match(acceptLanguage)][name]
+	if !ok {
+		tmpl, ok = t.byLocale[t.fallback][name]
+	}
+	if !ok {
+		return nil, fmt.Errorf("no email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+func (t *Templates) match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return t.fallback
+	}
+	_, index, _ := t.matcher.Match(tags...)
+	return t.locales[index]
+}
@@ -0,0 +1,18 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var auditEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "audit_entries_total",
+	Help:      "Audit log entries by action and result: success, or error when the entry could not be written.",
+}, []string{"action", "result"})
+
+// AuditEntry records the result of writing one audit log entry.
+func AuditEntry(action string, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+	}
+	auditEntries.WithLabelValues(action, result).Inc()
+}
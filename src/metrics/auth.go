@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"errors"
+
+	"restApi-GoGin/src/errorhandler"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of authentication attempts, used as the result label.
+const (
+	ResultSuccess     = "success"
+	ResultFailure     = "failure"
+	ResultLocked      = "locked"
+	ResultError       = "error"
+	ResultMFARequired = "mfa_required"
+)
+
+// Purposes of one-time codes, used as the purpose label.
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+)
+
+// Lockout scopes, used as the scope label.
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+var (
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_logins_total",
+		Help:      "Login attempts by method (password or mfa) and result.",
+	}, []string{"method", "result"})
+
+	otpsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_otps_sent_total",
+		Help:      "One-time codes mailed, by purpose.",
+	}, []string{"purpose"})
+
+	otpVerifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_otp_verifications_total",
+		Help:      "One-time code verifications by purpose and result.",
+	}, []string{"purpose", "result"})
+
+	tokenRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_token_refreshes_total",
+		Help:      "Refresh token rotations by result.",
+	}, []string{"result"})
+
+	refreshTokenReuse = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_refresh_token_reuse_total",
+		Help:      "Replayed refresh tokens, each of which revoked its session.",
+	})
+
+	lockouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_lockouts_total",
+		Help:      "Accounts and client IPs locked after repeated failures, by scope.",
+	}, []string{"scope"})
+)
+
+// Result classifies the error returned by an authentication step: nil is a
+// success, a lockout is locked, internal errors are errors and anything else,
+// such as a wrong password or code, is a failure.
+func Result(err error) string {
+	var locked *errorhandler.LockedError
+
+	switch {
+	case err == nil:
+		return ResultSuccess
+	case errors.As(err, &locked):
+		return ResultLocked
+	case isClientError(err):
+		return ResultFailure
+	}
+
+	return ResultError
+}
+
+func isClientError(err error) bool {
+	var (
+		notFound        *errorhandler.NotFoundError
+		badRequest      *errorhandler.BadRequestError
+		forbidden       *errorhandler.ForbiddenError
+		unauthorized    *errorhandler.UnauthorizedError
+		tooManyRequests *errorhandler.TooManyRequestsError
+	)
+
+	return errors.As(err, &notFound) || errors.As(err, &badRequest) || errors.As(err, &forbidden) ||
+		errors.As(err, &unauthorized) || errors.As(err, &tooManyRequests)
+}
+
+// Login records a login attempt. A password login that succeeded but still
+// needs a second factor is recorded as mfa_required.
+func Login(method string, mfaRequired bool, err error) {
+	result := Result(err)
+	if result == ResultSuccess && mfaRequired {
+		result = ResultMFARequired
+	}
+	logins.WithLabelValues(method, result).Inc()
+}
+
+// OTPSent records a one-time code mailed for purpose.
+func OTPSent(purpose string) {
+	otpsSent.WithLabelValues(purpose).Inc()
+}
+
+// OTPVerification records the verification of a one-time code.
+func OTPVerification(purpose string, err error) {
+	otpVerifications.WithLabelValues(purpose, Result(err)).Inc()
+}
+
+// TokenRefresh records a refresh token rotation.
+func TokenRefresh(err error) {
+	tokenRefreshes.WithLabelValues(Result(err)).Inc()
+}
+
+// RefreshTokenReuse records a replayed refresh token.
+func RefreshTokenReuse() {
+	refreshTokenReuse.Inc()
+}
+
+// Lockout records an account or client IP becoming locked.
+func Lockout(scope string) {
+	lockouts.WithLabelValues(scope).Inc()
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// startKey holds the start time of a statement in its gorm instance.
+const startKey = "metrics:start"
+
+var dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "db_query_duration_seconds",
+	Help:      "Database statement latency by operation and table.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "table"})
+
+var (
+	dbStatsMu sync.Mutex
+	dbStats   prometheus.Collector
+)
+
+// InstrumentDB times every statement of db through gorm callbacks and
+// exports the statistics of its connection pool. Instrumenting another
+// database replaces the pool statistics of the previous one.
+func InstrumentDB(db *gorm.DB) error {
+	callback := db.Callback()
+	steps := []struct {
+		operation     string
+		before, after callbackRegistrar
+	}{
+		{"create", callback.Create().Before("gorm:create"), callback.Create().After("gorm:create")},
+		{"query", callback.Query().Before("gorm:query"), callback.Query().After("gorm:query")},
+		{"update", callback.Update().Before("gorm:update"), callback.Update().After("gorm:update")},
+		{"delete", callback.Delete().Before("gorm:delete"), callback.Delete().After("gorm:delete")},
+		{"row", callback.Row().Before("gorm:row"), callback.Row().After("gorm:row")},
+		{"raw", callback.Raw().Before("gorm:raw"), callback.Raw().After("gorm:raw")},
+	}
+	for _, step := range steps {
+		if err := step.before.Register("metrics:before_"+step.operation, startTimer); err != nil {
+			return err
+		}
+		if err := step.after.Register("metrics:after_"+step.operation, observe(step.operation)); err != nil {
+			return err
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	dbStatsMu.Lock()
+	defer dbStatsMu.Unlock()
+	if dbStats != nil {
+		Registry.Unregister(dbStats)
+	}
+	dbStats = collectors.NewDBStatsCollector(sqlDB, db.Dialector.Name())
+	return Registry.Register(dbStats)
+}
+
+// callbackRegistrar is the part of a gorm callback processor that places a
+// callback before or after a named one.
+type callbackRegistrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		dbQueryDuration.WithLabelValues(operation, db.Statement.Table).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Outcomes of outbox deliveries, used as the result label.
+const (
+	OutboxSent  = "sent"
+	OutboxRetry = "retry"
+	OutboxDead  = "dead"
+)
+
+var outboxDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "mail_outbox_deliveries_total",
+	Help:      "Outbox delivery attempts by result: sent, retry after a failure, or dead after the last attempt.",
+}, []string{"result"})
+
+// OutboxDelivery records the result of one outbox delivery attempt.
+func OutboxDelivery(result string) {
+	outboxDeliveries.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name of the application.
+const namespace = "app"
+
+// Registry holds the application metrics together with the Go runtime and
+// process collectors. It is served by Handler.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests being served.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		httpInFlight,
+		logins,
+		otpsSent,
+		otpVerifications,
+		tokenRefreshes,
+		refreshTokenReuse,
+		lockouts,
+		outboxDeliveries,
+		jobRuns,
+		jobDuration,
+		auditEntries,
+		dbQueryDuration,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// unmatchedRoute labels requests that matched no route, so scanners probing
+// random paths cannot blow up the number of series.
+const unmatchedRoute = "unmatched"
+
+// RequestStarted counts a request as in flight until the returned function
+// records its route, status and duration.
+func RequestStarted(method string) func(route string, status int) {
+	start := time.Now()
+	httpInFlight.Inc()
+
+	return func(route string, status int) {
+		httpInFlight.Dec()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		statusLabel := strconv.Itoa(status)
+		httpRequests.WithLabelValues(method, route, statusLabel).Inc()
+		httpDuration.WithLabelValues(method, route, statusLabel).Observe(time.Since(start).Seconds())
+	}
+}
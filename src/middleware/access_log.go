@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog logs one line per request with the route template rather than
+// the raw path, so requests to the same endpoint group together. Server
+// errors are logged at error level. It replaces gin.Logger and goes after
+// RequestID.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if user, ok := c.Get("user"); ok {
+			if user, ok := user.(*models.User); ok {
+				attrs = append(attrs, slog.Int("user_id", user.Id))
+			}
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		ctx := c.Request.Context()
+		logger.FromContext(ctx).LogAttrs(ctx, level, "request", attrs...)
+	}
+}
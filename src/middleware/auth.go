@@ -1,84 +1,94 @@
 package middleware
 
 import (
-	"net/http"
+	"time"
 
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/models"
 	"restApi-GoGin/src/repository"
 	"restApi-GoGin/src/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Auth(authRepo repository.AuthRepository) gin.HandlerFunc {
+// sessionTouchInterval limits how often a session's last seen time is written.
+const sessionTouchInterval = time.Minute
+
+func Auth(authRepo repository.AuthRepository, sessionRepo repository.SessionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenStr, err := c.Cookie("accessToken")
-		if err != nil || tokenStr == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Missing or invalid token in cookie"})
-			c.Abort()
+		user, ok := authenticate(c, authRepo, sessionRepo)
+		if !ok {
 			return
 		}
 
-		claims, err := utils.VerifyAccessToken(tokenStr)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token"})
-			c.Abort()
-			return
-		}
+		c.Set("user", user)
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.UserActor(user)))
+		c.Next()
+	}
+}
 
-		user, err := authRepo.GetUserById(claims.UserId)
-		if err != nil || user == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
-			c.Abort()
+// VerifiedEmail rejects users whose email address is not verified yet. It
+// must run after Auth and is a no-op when verification is turned off.
+func VerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.EmailVerificationPolicy() == config.EmailVerificationOff {
+			c.Next()
 			return
 		}
 
-		if user.DeletedAt != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "User account has been deleted"})
-			c.Abort()
+		userObj, _ := c.Get("user")
+		user, ok := userObj.(*models.User)
+		if !ok || user.EmailVerifiedAt == nil {
+			errorhandler.ErrorHandler(c, &errorhandler.ForbiddenError{Message: "Access denied: email verification required"})
 			return
 		}
 
-		c.Set("user", user)
 		c.Next()
 	}
 }
 
-func AuthAccess(authRepo repository.AuthRepository) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		tokenStr, err := c.Cookie("accessToken")
-		if err != nil || tokenStr == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Missing or invalid token in cookie"})
-			c.Abort()
-			return
-		}
+// authenticate resolves the user and session behind the access token found by
+// DefaultTokenSources. It writes the error response and aborts the request
+// when it returns false.
+func authenticate(c *gin.Context, authRepo repository.AuthRepository, sessionRepo repository.SessionRepository) (*models.User, bool) {
+	tokenStr := ExtractToken(c, DefaultTokenSources...)
+	if tokenStr == "" {
+		errorhandler.ErrorHandler(c, &errorhandler.UnauthorizedError{Message: "Missing access token"})
+		return nil, false
+	}
 
-		claims, err := utils.VerifyAccessToken(tokenStr)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token"})
-			c.Abort()
-			return
-		}
+	claims, err := utils.VerifyAccessToken(tokenStr)
+	if err != nil {
+		errorhandler.ErrorHandler(c, &errorhandler.UnauthorizedError{Message: "Invalid or expired token"})
+		return nil, false
+	}
 
-		user, err := authRepo.GetUserById(claims.UserId)
-		if err != nil || user == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
-			c.Abort()
-			return
-		}
+	session, err := sessionRepo.GetById(c.Request.Context(), claims.SessionId)
+	if err != nil || session == nil || session.UserId != claims.UserId {
+		errorhandler.ErrorHandler(c, &errorhandler.UnauthorizedError{Message: "Session not found"})
+		return nil, false
+	}
 
-		if user.DeletedAt != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "User account has been deleted"})
-			c.Abort()
-			return
-		}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		errorhandler.ErrorHandler(c, &errorhandler.UnauthorizedError{Message: "Session has been revoked"})
+		return nil, false
+	}
 
-		if user.Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"message": "Access denied: admin only"})
-			c.Abort()
-			return
-		}
+	// Deleted users are not found, which logs them out everywhere.
+	user, err := authRepo.GetUserById(c.Request.Context(), claims.UserId)
+	if err != nil || user == nil {
+		errorhandler.ErrorHandler(c, &errorhandler.UnauthorizedError{Message: "User not found"})
+		return nil, false
+	}
 
-		c.Set("user", user)
-		c.Next()
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		_ = sessionRepo.Touch(c.Request.Context(), session.Id, c.ClientIP())
 	}
+
+	c.Set("sessionId", session.Id)
+	c.Set("roles", claims.Roles)
+	c.Set("permissions", claims.Permissions)
+	return user, true
 }
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"restApi-GoGin/src/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Client stores the IP address and user agent of the client in the request
+// context, where the audit log picks them up.
+func Client() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(audit.WithClient(c.Request.Context(), c.ClientIP(), c.Request.UserAgent()))
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"runtime/debug"
+
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Errors turns panics, and errors that handlers attach with c.Error without
+// writing a response, into the standard error envelope. It replaces
+// gin.Recovery and goes after RequestID and AccessLog.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.FromContext(c.Request.Context()).Error("panic recovered", "panic", r, "stack", string(debug.Stack()))
+				if c.Writer.Written() {
+					c.Abort()
+					return
+				}
+				errorhandler.ErrorHandler(c, &errorhandler.InternalServerError{Message: "internal server error"})
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			errorhandler.ErrorHandler(c, c.Errors.Last().Err)
+		}
+	}
+}
+
+// NotFound answers unknown routes with the standard error envelope.
+func NotFound() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		errorhandler.ErrorHandler(c, &errorhandler.NotFoundError{Message: "route not found"})
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"restApi-GoGin/src/mail"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale stores the Accept-Language header of the request in its context,
+// so emails sent while handling it use the client's language.
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if acceptLanguage := c.GetHeader("Accept-Language"); acceptLanguage != "" {
+			c.Request = c.Request.WithContext(mail.WithLocale(c.Request.Context(), acceptLanguage))
+		}
+		c.Next()
+	}
+}
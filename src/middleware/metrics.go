@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the count and latency of requests by route template and
+// status. It goes after RequestID.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		done := metrics.RequestStarted(c.Request.Method)
+		c.Next()
+		done(c.FullPath(), c.Writer.Status())
+	}
+}
+
+// StaticToken only lets through requests with an "Authorization: Bearer"
+// header equal to token. An empty token lets every request through.
+func StaticToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		presented := BearerHeader()(c)
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			errorhandler.ErrorHandler(c, &errorhandler.UnauthorizedError{Message: "invalid metrics token"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestID gives every request an ID, taken from the X-Request-ID header
+// when a client or proxy sent a valid one, and echoes it in the response. The
+// request context carries the ID and a logger tagged with it and with the
+// trace ID, so every log line of the request can be correlated. It goes right
+// after the tracing middleware.
+func RequestID(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(logger.RequestIDHeader)
+		if !logger.ValidRequestID(id) {
+			id = logger.NewRequestID()
+		}
+		c.Header(logger.RequestIDHeader, id)
+
+		ctx := logger.WithRequestID(c.Request.Context(), id)
+		requestLog := log.With("request_id", id)
+		if traceID := tracing.TraceID(ctx); traceID != "" {
+			requestLog = requestLog.With("trace_id", traceID)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request_id", id))
+		}
+		ctx = logger.WithContext(ctx, requestLog)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
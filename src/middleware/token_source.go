@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenSource extracts a raw access token from a request. It returns an empty
+// string when the request does not carry a token in that location.
+type TokenSource func(c *gin.Context) string
+
+// DefaultTokenSources is the order in which Auth and AuthAccess look for the
+// access token: the Authorization header first, so API clients are never
+// shadowed by a stale browser cookie, then the accessToken cookie, and
+// finally the access_token query parameter of websocket handshakes.
+var DefaultTokenSources = []TokenSource{
+	BearerHeader(),
+	Cookie("accessToken"),
+	WebSocketQuery("access_token"),
+}
+
+// BearerHeader reads a token from an "Authorization: Bearer <token>" header.
+func BearerHeader() TokenSource {
+	return func(c *gin.Context) string {
+		scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+
+		return strings.TrimSpace(token)
+	}
+}
+
+// Cookie reads a token from the named cookie.
+func Cookie(name string) TokenSource {
+	return func(c *gin.Context) string {
+		token, err := c.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		return token
+	}
+}
+
+// WebSocketQuery reads a token from the named query parameter. Browsers cannot
+// set headers on a websocket handshake, so the parameter is only honoured on
+// upgrade requests to keep tokens out of ordinary URLs and access logs.
+func WebSocketQuery(name string) TokenSource {
+	return func(c *gin.Context) string {
+		if !strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			return ""
+		}
+
+		return c.Query(name)
+	}
+}
+
+// ExtractToken returns the first non-empty token found by sources.
+func ExtractToken(c *gin.Context, sources ...TokenSource) string {
+	for _, source := range sources {
+		if token := source(c); token != "" {
+			return token
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,502 @@
+package migration
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// files holds the migrations of each dialect in sql/<dialect>.
+//
+//go:embed sql
+var files embed.FS
+
+// lockName is the advisory lock held while migrations run, so replicas that
+// start together apply each migration once. PostgreSQL advisory locks take a
+// number instead of a name, so lockKey is used there.
+const (
+	lockName    = "schema_migrations"
+	lockKey     = 7_238_402_115
+	lockTimeout = 5 * time.Minute
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change read from
+// sql/<dialect>/<version>_<name>.up.sql and the matching .down.sql file.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied.
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations table.
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies and rolls back the embedded migrations. With DryRun set it
+// writes the SQL it would run to Out and changes nothing.
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration
+	DryRun     bool
+	Out        io.Writer
+
+	// locked is set on the copy handed out by Locked, whose db is the
+	// connection holding the lock.
+	locked bool
+}
+
+// NewMigrator loads the migrations written for the dialect of db.
+func NewMigrator(db *gorm.DB, out io.Writer) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := Embedded(dialect)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s migrations: %w", dialect, err)
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+		Out:        out,
+	}, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up() error {
+	return m.run(func(conn *gorm.DB, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(conn, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the n most recently applied migrations.
+func (m *Migrator) Down(n int) error {
+	return m.run(func(conn *gorm.DB, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && n > 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.rollback(conn, migration); err != nil {
+				return err
+			}
+			n--
+		}
+		return nil
+	})
+}
+
+// To migrates up or down until version is the latest applied migration.
+// Version 0 rolls back everything.
+func (m *Migrator) To(version int64) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("migration %d does not exist", version)
+	}
+
+	return m.run(func(conn *gorm.DB, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; ok && migration.Version > version {
+				if err := m.rollback(conn, migration); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+				if err := m.apply(conn, migration); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status lists every migration in order with the time it was applied.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if at, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Pending lists the migrations that have not been applied, in order.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Baseline records every migration up to version as applied without running
+// it, for databases whose schema already matches that version.
+func (m *Migrator) Baseline(version int64) error {
+	return m.run(func(conn *gorm.DB, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			if err := m.record(conn, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Locked holds the migration lock on a single connection while fn runs, so
+// setup around the migrations, like seeding, also runs on one replica at a
+// time. fn gets that connection and a Migrator using it, which must be used
+// instead of m while the lock is held. The lock is skipped in dry-run mode
+// since nothing is written.
+func (m *Migrator) Locked(fn func(conn *gorm.DB, m *Migrator) error) error {
+	if m.locked || m.DryRun {
+		return fn(m.db, m)
+	}
+
+	return m.db.Connection(func(conn *gorm.DB) error {
+		// A new session, so every query on conn starts a fresh statement.
+		conn = conn.Session(&gorm.Session{})
+
+		unlock, err := m.lock(conn)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		locked := *m
+		locked.db = conn
+		locked.locked = true
+		return fn(conn, &locked)
+	})
+}
+
+// run holds the migration lock while fn runs with the applied migrations.
+func (m *Migrator) run(fn func(conn *gorm.DB, applied map[int64]time.Time) error) error {
+	return m.Locked(func(conn *gorm.DB, m *Migrator) error {
+		if !m.DryRun {
+			if err := m.createTable(conn); err != nil {
+				return err
+			}
+		}
+
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		return fn(conn, applied)
+	})
+}
+
+func (m *Migrator) apply(conn *gorm.DB, migration Migration) error {
+	m.printf("-- %06d %s (up)\n", migration.Version, migration.Name)
+	err := m.transaction(conn, func(tx *gorm.DB) error {
+		if err := m.exec(tx, migration.Up); err != nil {
+			return err
+		}
+		return m.record(tx, migration)
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d %s up: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) rollback(conn *gorm.DB, migration Migration) error {
+	m.printf("-- %06d %s (down)\n", migration.Version, migration.Name)
+	err := m.transaction(conn, func(tx *gorm.DB) error {
+		if err := m.exec(tx, migration.Down); err != nil {
+			return err
+		}
+		if m.DryRun {
+			return nil
+		}
+		return tx.Delete(&schemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d %s down: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// transaction runs fn in a transaction where DDL is transactional, so a
+// failed PostgreSQL or SQLite migration leaves no trace. MySQL commits DDL
+// implicitly, so there a migration that fails partway must be fixed by hand.
+func (m *Migrator) transaction(conn *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if m.dialect == "mysql" || m.DryRun {
+		return fn(conn)
+	}
+	return conn.Transaction(fn)
+}
+
+func (m *Migrator) record(conn *gorm.DB, migration Migration) error {
+	if m.DryRun {
+		return nil
+	}
+
+	return conn.Create(&schemaMigration{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		AppliedAt: time.Now(),
+	}).Error
+}
+
+// exec runs the statements of a migration one at a time, since the MySQL
+// driver rejects multi-statement queries.
+func (m *Migrator) exec(conn *gorm.DB, sql string) error {
+	for _, statement := range SplitStatements(sql) {
+		m.printf("%s;\n", statement)
+		if m.DryRun {
+			continue
+		}
+		if err := conn.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) createTable(conn *gorm.DB) error {
+	if conn.Migrator().HasTable(&schemaMigration{}) {
+		return nil
+	}
+	return conn.Migrator().CreateTable(&schemaMigration{})
+}
+
+func (m *Migrator) applied(conn *gorm.DB) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+	if !conn.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+
+	var rows []schemaMigration
+	if err := conn.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) printf(format string, args ...any) {
+	if m.Out != nil {
+		fmt.Fprintf(m.Out, format, args...)
+	}
+}
+
+// lock takes the advisory lock, waiting up to lockTimeout for another
+// replica to finish. SQLite has no advisory locks and is not shared between
+// replicas, so nothing is locked there.
+func (m *Migrator) lock(conn *gorm.DB) (func(), error) {
+	switch m.dialect {
+	case "mysql":
+		var acquired *int
+		err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&acquired).Error
+		if err != nil {
+			return nil, err
+		}
+		if acquired == nil || *acquired != 1 {
+			return nil, fmt.Errorf("timed out waiting for migration lock %q", lockName)
+		}
+
+		return func() {
+			conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+		}, nil
+	case "postgres":
+		deadline := time.Now().Add(lockTimeout)
+		for {
+			var acquired bool
+			if err := conn.Raw("SELECT pg_try_advisory_lock(?)", lockKey).Scan(&acquired).Error; err != nil {
+				return nil, err
+			}
+			if acquired {
+				break
+			}
+			if time.Now().After(deadline) {
+				return nil, fmt.Errorf("timed out waiting for migration lock %d", lockKey)
+			}
+			time.Sleep(time.Second)
+		}
+
+		return func() {
+			conn.Exec("SELECT pg_advisory_unlock(?)", lockKey)
+		}, nil
+	default:
+		return func() {}, nil
+	}
+}
+
+// Embedded returns the migrations shipped for dialect, sorted by version.
+func Embedded(dialect string) ([]Migration, error) {
+	return Load(files, path.Join("sql", dialect))
+}
+
+// Load reads the migrations in dir of fsys, sorted by version.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s does not match <version>_<name>.(up|down).sql", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		target := &migration.Down
+		if match[3] == "up" {
+			target = &migration.Up
+		}
+		if *target != "" {
+			return nil, fmt.Errorf("migration %d has two %s files", version, match[3])
+		}
+		*target = string(content)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d %s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// SplitStatements splits a migration file into statements at the semicolons
+// that end them. Semicolons inside quoted strings, quoted identifiers and
+// comments do not end a statement, and comments are left out.
+func SplitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(sql[i+1:], c) + i + 1
+			if end == i {
+				end = len(sql) - 1
+			}
+			current.WriteString(sql[i : end+1])
+			i = end
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			i += end - 1
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 2
+			}
+			current.WriteByte(' ')
+			i += end + 3
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
@@ -0,0 +1,120 @@
+CREATE TABLE `permissions` (
+  `id` bigint AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `description` varchar(255),
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_permissions_name` (`name`)
+);
+
+CREATE TABLE `roles` (
+  `id` bigint AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `description` varchar(255),
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_roles_name` (`name`)
+);
+
+CREATE TABLE `role_permissions` (
+  `role_id` bigint,
+  `permission_id` bigint,
+  PRIMARY KEY (`role_id`, `permission_id`),
+  CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles` (`id`),
+  CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions` (`id`)
+);
+
+CREATE TABLE `users` (
+  `id` bigint AUTO_INCREMENT,
+  `name` longtext NOT NULL,
+  `email` varchar(191) NOT NULL,
+  `password` longtext NOT NULL,
+  `otp_code` longtext,
+  `otp_code_exp` datetime(3) NULL,
+  `otp_attempts` bigint NOT NULL DEFAULT 0,
+  `reset_token` longtext,
+  `reset_token_exp` datetime(3) NULL,
+  `mfa_enabled` boolean DEFAULT false,
+  `mfa_secret` longtext,
+  `mfa_last_step` bigint,
+  `email_verified_at` datetime(3) NULL,
+  `email_verify_code` longtext,
+  `email_verify_code_exp` datetime(3) NULL,
+  `email_verify_sent_at` datetime(3) NULL,
+  `email_verify_attempts` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_users_deleted_at` (`deleted_at`),
+  CONSTRAINT `uni_users_email` UNIQUE (`email`)
+);
+
+CREATE TABLE `user_roles` (
+  `user_id` bigint,
+  `role_id` bigint,
+  PRIMARY KEY (`user_id`, `role_id`),
+  CONSTRAINT `fk_user_roles_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `fk_user_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles` (`id`)
+);
+
+CREATE TABLE `refresh_tokens` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint NOT NULL,
+  `family_id` varchar(36) NOT NULL,
+  `jti_hash` varchar(64) NOT NULL,
+  `user_agent` varchar(255),
+  `ip` varchar(45),
+  `issued_at` datetime(3) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `revoked_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_refresh_tokens_user_id` (`user_id`),
+  INDEX `idx_refresh_tokens_family_id` (`family_id`),
+  UNIQUE INDEX `idx_refresh_tokens_jti_hash` (`jti_hash`),
+  INDEX `idx_refresh_tokens_expires_at` (`expires_at`),
+  INDEX `idx_refresh_tokens_revoked_at` (`revoked_at`)
+);
+
+CREATE TABLE `sessions` (
+  `id` varchar(36),
+  `user_id` bigint NOT NULL,
+  `user_agent` varchar(255),
+  `ip` varchar(45),
+  `last_seen_at` datetime(3) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `revoked_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_sessions_user_id` (`user_id`),
+  INDEX `idx_sessions_expires_at` (`expires_at`),
+  INDEX `idx_sessions_revoked_at` (`revoked_at`)
+);
+
+CREATE TABLE `recovery_codes` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint NOT NULL,
+  `code_hash` varchar(64) NOT NULL,
+  `used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_recovery_codes_user_id` (`user_id`),
+  INDEX `idx_recovery_codes_code_hash` (`code_hash`)
+);
+
+CREATE TABLE `auth_throttles` (
+  `id` bigint AUTO_INCREMENT,
+  `throttle_key` varchar(255) NOT NULL,
+  `failures` bigint NOT NULL DEFAULT 0,
+  `last_failure_at` datetime(3) NOT NULL,
+  `locked_until` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_auth_throttles_key` (`throttle_key`)
+);
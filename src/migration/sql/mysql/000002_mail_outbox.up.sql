@@ -0,0 +1,18 @@
+CREATE TABLE `mail_outbox` (
+  `id` bigint AUTO_INCREMENT,
+  `template` varchar(100) NOT NULL,
+  `recipient` varchar(255) NOT NULL,
+  `subject` varchar(255) NOT NULL,
+  `html` text,
+  `text` text,
+  `status` varchar(20) NOT NULL,
+  `attempts` bigint NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime(3) NOT NULL,
+  `locked_until` datetime(3) NULL,
+  `last_error` text,
+  `sent_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_mail_outbox_status_next_attempt_at` (`status`, `next_attempt_at`)
+);
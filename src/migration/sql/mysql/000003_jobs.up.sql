@@ -0,0 +1,26 @@
+CREATE TABLE `jobs` (
+  `id` bigint AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `payload` text,
+  `unique_key` varchar(255) NULL,
+  `status` varchar(20) NOT NULL,
+  `attempts` bigint NOT NULL DEFAULT 0,
+  `max_attempts` bigint NOT NULL,
+  `run_at` datetime(3) NOT NULL,
+  `locked_until` datetime(3) NULL,
+  `last_error` text,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_jobs_unique_key` (`unique_key`),
+  INDEX `idx_jobs_status_run_at` (`status`, `run_at`)
+);
+
+CREATE TABLE `job_schedules` (
+  `name` varchar(100),
+  `spec` varchar(100) NOT NULL,
+  `next_run_at` datetime(3) NOT NULL,
+  `last_run_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`name`)
+);
@@ -0,0 +1,21 @@
+CREATE TABLE `audit_logs` (
+  `id` bigint NOT NULL,
+  `action` varchar(100) NOT NULL,
+  `actor_id` bigint NULL,
+  `actor` varchar(255),
+  `target_type` varchar(50),
+  `target_id` varchar(64),
+  `changes` text,
+  `reason` varchar(255),
+  `ip` varchar(45),
+  `user_agent` varchar(255),
+  `request_id` varchar(128),
+  `created_at` datetime(3) NOT NULL,
+  `prev_hash` varchar(64) NOT NULL,
+  `hash` varchar(64) NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_audit_logs_action` (`action`),
+  INDEX `idx_audit_logs_actor_id` (`actor_id`),
+  INDEX `idx_audit_logs_target` (`target_type`, `target_id`),
+  INDEX `idx_audit_logs_created_at` (`created_at`)
+);
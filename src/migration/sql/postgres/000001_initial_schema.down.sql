@@ -0,0 +1,9 @@
+DROP TABLE "auth_throttles";
+DROP TABLE "recovery_codes";
+DROP TABLE "sessions";
+DROP TABLE "refresh_tokens";
+DROP TABLE "user_roles";
+DROP TABLE "users";
+DROP TABLE "role_permissions";
+DROP TABLE "roles";
+DROP TABLE "permissions";
@@ -0,0 +1,120 @@
+CREATE TABLE "permissions" (
+  "id" bigserial,
+  "name" varchar(100) NOT NULL,
+  "description" varchar(255),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_permissions_name" ON "permissions" ("name");
+
+CREATE TABLE "roles" (
+  "id" bigserial,
+  "name" varchar(100) NOT NULL,
+  "description" varchar(255),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_roles_name" ON "roles" ("name");
+
+CREATE TABLE "role_permissions" (
+  "role_id" bigint,
+  "permission_id" bigint,
+  PRIMARY KEY ("role_id","permission_id"),
+  CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions"("id"),
+  CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id")
+);
+
+CREATE TABLE "users" (
+  "id" bigserial,
+  "name" text NOT NULL,
+  "email" text NOT NULL,
+  "password" text NOT NULL,
+  "otp_code" text,
+  "otp_code_exp" timestamptz,
+  "otp_attempts" bigint NOT NULL DEFAULT 0,
+  "reset_token" text,
+  "reset_token_exp" timestamptz,
+  "mfa_enabled" boolean DEFAULT false,
+  "mfa_secret" text,
+  "mfa_last_step" bigint,
+  "email_verified_at" timestamptz,
+  "email_verify_code" text,
+  "email_verify_code_exp" timestamptz,
+  "email_verify_sent_at" timestamptz,
+  "email_verify_attempts" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+CREATE INDEX "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE "user_roles" (
+  "user_id" bigint,
+  "role_id" bigint,
+  PRIMARY KEY ("user_id","role_id"),
+  CONSTRAINT "fk_user_roles_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+  CONSTRAINT "fk_user_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id")
+);
+
+CREATE TABLE "refresh_tokens" (
+  "id" bigserial,
+  "user_id" bigint NOT NULL,
+  "family_id" varchar(36) NOT NULL,
+  "jti_hash" varchar(64) NOT NULL,
+  "user_agent" varchar(255),
+  "ip" varchar(45),
+  "issued_at" timestamptz NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_refresh_tokens_revoked_at" ON "refresh_tokens" ("revoked_at");
+CREATE INDEX "idx_refresh_tokens_expires_at" ON "refresh_tokens" ("expires_at");
+CREATE UNIQUE INDEX "idx_refresh_tokens_jti_hash" ON "refresh_tokens" ("jti_hash");
+CREATE INDEX "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+CREATE INDEX "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE "sessions" (
+  "id" varchar(36),
+  "user_id" bigint NOT NULL,
+  "user_agent" varchar(255),
+  "ip" varchar(45),
+  "last_seen_at" timestamptz NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_sessions_revoked_at" ON "sessions" ("revoked_at");
+CREATE INDEX "idx_sessions_expires_at" ON "sessions" ("expires_at");
+CREATE INDEX "idx_sessions_user_id" ON "sessions" ("user_id");
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial,
+  "user_id" bigint NOT NULL,
+  "code_hash" varchar(64) NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_recovery_codes_code_hash" ON "recovery_codes" ("code_hash");
+CREATE INDEX "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+
+CREATE TABLE "auth_throttles" (
+  "id" bigserial,
+  "throttle_key" varchar(255) NOT NULL,
+  "failures" bigint NOT NULL DEFAULT 0,
+  "last_failure_at" timestamptz NOT NULL,
+  "locked_until" timestamptz,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_auth_throttles_key" ON "auth_throttles" ("throttle_key");
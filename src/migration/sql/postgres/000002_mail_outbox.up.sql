@@ -0,0 +1,18 @@
+CREATE TABLE "mail_outbox" (
+  "id" bigserial,
+  "template" varchar(100) NOT NULL,
+  "recipient" varchar(255) NOT NULL,
+  "subject" varchar(255) NOT NULL,
+  "html" text,
+  "text" text,
+  "status" varchar(20) NOT NULL,
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL,
+  "locked_until" timestamptz,
+  "last_error" text,
+  "sent_at" timestamptz,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_mail_outbox_status_next_attempt_at" ON "mail_outbox" ("status", "next_attempt_at");
@@ -0,0 +1,26 @@
+CREATE TABLE "jobs" (
+  "id" bigserial,
+  "name" varchar(100) NOT NULL,
+  "payload" text,
+  "unique_key" varchar(255),
+  "status" varchar(20) NOT NULL,
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "max_attempts" bigint NOT NULL,
+  "run_at" timestamptz NOT NULL,
+  "locked_until" timestamptz,
+  "last_error" text,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_jobs_unique_key" ON "jobs" ("unique_key");
+CREATE INDEX "idx_jobs_status_run_at" ON "jobs" ("status", "run_at");
+
+CREATE TABLE "job_schedules" (
+  "name" varchar(100),
+  "spec" varchar(100) NOT NULL,
+  "next_run_at" timestamptz NOT NULL,
+  "last_run_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("name")
+);
@@ -0,0 +1,21 @@
+CREATE TABLE "audit_logs" (
+  "id" bigint NOT NULL,
+  "action" varchar(100) NOT NULL,
+  "actor_id" bigint,
+  "actor" varchar(255),
+  "target_type" varchar(50),
+  "target_id" varchar(64),
+  "changes" text,
+  "reason" varchar(255),
+  "ip" varchar(45),
+  "user_agent" varchar(255),
+  "request_id" varchar(128),
+  "created_at" timestamptz NOT NULL,
+  "prev_hash" varchar(64) NOT NULL,
+  "hash" varchar(64) NOT NULL,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_audit_logs_action" ON "audit_logs" ("action");
+CREATE INDEX "idx_audit_logs_actor_id" ON "audit_logs" ("actor_id");
+CREATE INDEX "idx_audit_logs_target" ON "audit_logs" ("target_type", "target_id");
+CREATE INDEX "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
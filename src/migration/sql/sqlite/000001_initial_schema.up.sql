@@ -0,0 +1,114 @@
+CREATE TABLE `permissions` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `name` text NOT NULL,
+  `description` text,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+CREATE UNIQUE INDEX `idx_permissions_name` ON `permissions`(`name`);
+
+CREATE TABLE `roles` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `name` text NOT NULL,
+  `description` text,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+CREATE UNIQUE INDEX `idx_roles_name` ON `roles`(`name`);
+
+CREATE TABLE `role_permissions` (
+  `role_id` integer,
+  `permission_id` integer,
+  PRIMARY KEY (`role_id`,`permission_id`),
+  CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`),
+  CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`)
+);
+
+CREATE TABLE `users` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `name` text NOT NULL,
+  `email` text NOT NULL,
+  `password` text NOT NULL,
+  `otp_code` text,
+  `otp_code_exp` datetime,
+  `otp_attempts` integer NOT NULL DEFAULT 0,
+  `reset_token` text,
+  `reset_token_exp` datetime,
+  `mfa_enabled` numeric DEFAULT false,
+  `mfa_secret` text,
+  `mfa_last_step` integer,
+  `email_verified_at` datetime,
+  `email_verify_code` text,
+  `email_verify_code_exp` datetime,
+  `email_verify_sent_at` datetime,
+  `email_verify_attempts` integer NOT NULL DEFAULT 0,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  CONSTRAINT `uni_users_email` UNIQUE (`email`)
+);
+CREATE INDEX `idx_users_deleted_at` ON `users`(`deleted_at`);
+
+CREATE TABLE `user_roles` (
+  `user_id` integer,
+  `role_id` integer,
+  PRIMARY KEY (`user_id`,`role_id`),
+  CONSTRAINT `fk_user_roles_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+  CONSTRAINT `fk_user_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`)
+);
+
+CREATE TABLE `refresh_tokens` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `family_id` text NOT NULL,
+  `jti_hash` text NOT NULL,
+  `user_agent` text,
+  `ip` text,
+  `issued_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `revoked_at` datetime,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+CREATE INDEX `idx_refresh_tokens_revoked_at` ON `refresh_tokens`(`revoked_at`);
+CREATE INDEX `idx_refresh_tokens_expires_at` ON `refresh_tokens`(`expires_at`);
+CREATE UNIQUE INDEX `idx_refresh_tokens_jti_hash` ON `refresh_tokens`(`jti_hash`);
+CREATE INDEX `idx_refresh_tokens_family_id` ON `refresh_tokens`(`family_id`);
+CREATE INDEX `idx_refresh_tokens_user_id` ON `refresh_tokens`(`user_id`);
+
+CREATE TABLE `sessions` (
+  `id` text,
+  `user_id` integer NOT NULL,
+  `user_agent` text,
+  `ip` text,
+  `last_seen_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `revoked_at` datetime,
+  `created_at` datetime,
+  `updated_at` datetime,
+  PRIMARY KEY (`id`)
+);
+CREATE INDEX `idx_sessions_revoked_at` ON `sessions`(`revoked_at`);
+CREATE INDEX `idx_sessions_expires_at` ON `sessions`(`expires_at`);
+CREATE INDEX `idx_sessions_user_id` ON `sessions`(`user_id`);
+
+CREATE TABLE `recovery_codes` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `code_hash` text NOT NULL,
+  `used_at` datetime,
+  `created_at` datetime
+);
+CREATE INDEX `idx_recovery_codes_code_hash` ON `recovery_codes`(`code_hash`);
+CREATE INDEX `idx_recovery_codes_user_id` ON `recovery_codes`(`user_id`);
+
+CREATE TABLE `auth_throttles` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `throttle_key` text NOT NULL,
+  `failures` integer NOT NULL DEFAULT 0,
+  `last_failure_at` datetime NOT NULL,
+  `locked_until` datetime,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+CREATE UNIQUE INDEX `idx_auth_throttles_key` ON `auth_throttles`(`throttle_key`);
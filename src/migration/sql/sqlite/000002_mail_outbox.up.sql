@@ -0,0 +1,17 @@
+CREATE TABLE `mail_outbox` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `template` text NOT NULL,
+  `recipient` text NOT NULL,
+  `subject` text NOT NULL,
+  `html` text,
+  `text` text,
+  `status` text NOT NULL,
+  `attempts` integer NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime NOT NULL,
+  `locked_until` datetime,
+  `last_error` text,
+  `sent_at` datetime,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+CREATE INDEX `idx_mail_outbox_status_next_attempt_at` ON `mail_outbox`(`status`, `next_attempt_at`);
@@ -0,0 +1,25 @@
+CREATE TABLE `jobs` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `name` text NOT NULL,
+  `payload` text,
+  `unique_key` text,
+  `status` text NOT NULL,
+  `attempts` integer NOT NULL DEFAULT 0,
+  `max_attempts` integer NOT NULL,
+  `run_at` datetime NOT NULL,
+  `locked_until` datetime,
+  `last_error` text,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+CREATE UNIQUE INDEX `idx_jobs_unique_key` ON `jobs`(`unique_key`);
+CREATE INDEX `idx_jobs_status_run_at` ON `jobs`(`status`, `run_at`);
+
+CREATE TABLE `job_schedules` (
+  `name` text,
+  `spec` text NOT NULL,
+  `next_run_at` datetime NOT NULL,
+  `last_run_at` datetime,
+  `updated_at` datetime,
+  PRIMARY KEY (`name`)
+);
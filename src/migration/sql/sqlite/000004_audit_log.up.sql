@@ -0,0 +1,21 @@
+CREATE TABLE `audit_logs` (
+  `id` integer NOT NULL,
+  `action` text NOT NULL,
+  `actor_id` integer,
+  `actor` text,
+  `target_type` text,
+  `target_id` text,
+  `changes` text,
+  `reason` text,
+  `ip` text,
+  `user_agent` text,
+  `request_id` text,
+  `created_at` datetime NOT NULL,
+  `prev_hash` text NOT NULL,
+  `hash` text NOT NULL,
+  PRIMARY KEY (`id`)
+);
+CREATE INDEX `idx_audit_logs_action` ON `audit_logs`(`action`);
+CREATE INDEX `idx_audit_logs_actor_id` ON `audit_logs`(`actor_id`);
+CREATE INDEX `idx_audit_logs_target` ON `audit_logs`(`target_type`, `target_id`);
+CREATE INDEX `idx_audit_logs_created_at` ON `audit_logs`(`created_at`);
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AuditLog is one entry of the audit log. Entries form a hash chain: Id is
+// the position in the chain, starting at 1, and Hash covers every field and
+// the Hash of the entry before, so editing, removing or reordering entries
+// breaks every hash after them.
+type AuditLog struct {
+	Id int64 `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	// Action names what happened, e.g. "user.update".
+	Action string `gorm:"size:100;not null;index:idx_audit_logs_action" json:"action"`
+	// ActorId is the user who acted, if known. Actor is their email, the
+	// email given in a failed login, or the tool used outside the API.
+	ActorId    *int   `gorm:"index:idx_audit_logs_actor_id" json:"actor_id"`
+	Actor      string `gorm:"size:255" json:"actor"`
+	TargetType string `gorm:"size:50;index:idx_audit_logs_target,priority:1" json:"target_type"`
+	TargetId   string `gorm:"size:64;index:idx_audit_logs_target,priority:2" json:"target_id"`
+	// Changes is a JSON object mapping each changed field to its "from" and
+	// "to" values, with secrets redacted.
+	Changes   string    `gorm:"type:text" json:"changes,omitempty"`
+	Reason    string    `gorm:"size:255" json:"reason,omitempty"`
+	IP        string    `gorm:"column:ip;size:45" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	RequestId string    `gorm:"size:128" json:"request_id"`
+	CreatedAt time.Time `gorm:"not null;index:idx_audit_logs_created_at" json:"created_at"`
+	PrevHash  string    `gorm:"size:64;not null" json:"prev_hash"`
+	Hash      string    `gorm:"size:64;not null" json:"hash"`
+}
+
+// ChainHash returns the hash of the entry: the hex SHA-256 of its fields,
+// PrevHash included, in a fixed JSON layout. CreatedAt is hashed in UTC at
+// millisecond precision, the finest every database keeps.
+func (e *AuditLog) ChainHash() string {
+	data, _ := json.Marshal(struct {
+		Id         int64  `json:"id"`
+		PrevHash   string `json:"prev_hash"`
+		Action     string `json:"action"`
+		ActorId    *int   `json:"actor_id"`
+		Actor      string `json:"actor"`
+		TargetType string `json:"target_type"`
+		TargetId   string `json:"target_id"`
+		Changes    string `json:"changes"`
+		Reason     string `json:"reason"`
+		IP         string `json:"ip"`
+		UserAgent  string `json:"user_agent"`
+		RequestId  string `json:"request_id"`
+		CreatedAt  string `json:"created_at"`
+	}{
+		Id:         e.Id,
+		PrevHash:   e.PrevHash,
+		Action:     e.Action,
+		ActorId:    e.ActorId,
+		Actor:      e.Actor,
+		TargetType: e.TargetType,
+		TargetId:   e.TargetId,
+		Changes:    e.Changes,
+		Reason:     e.Reason,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		RequestId:  e.RequestId,
+		CreatedAt:  e.CreatedAt.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// AuthThrottle counts failed authentication attempts for one key, such as an
+// account email or a client IP, and records when the key is locked until.
+type AuthThrottle struct {
+	Id            int        `gorm:"primaryKey" json:"id"`
+	Key           string     `gorm:"column:throttle_key;size:255;not null;uniqueIndex" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null" json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Job statuses. A pending job is retried until it succeeds, when it is
+// deleted, or runs out of attempts and goes dead, where it stays for
+// inspection.
+const (
+	JobPending = "pending"
+	JobDead    = "dead"
+)
+
+// Job is a unit of background work run by the job worker. Payload holds the
+// JSON given to the handler. While a job is pending its UniqueKey, when set,
+// keeps another job with the same key from being enqueued.
+type Job struct {
+	Id          int64      `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"size:100;not null" json:"name"`
+	Payload     string     `gorm:"type:text" json:"payload"`
+	UniqueKey   *string    `gorm:"size:255;uniqueIndex" json:"unique_key,omitempty"`
+	Status      string     `gorm:"size:20;not null" json:"status"`
+	Attempts    int        `gorm:"not null" json:"attempts"`
+	MaxAttempts int        `gorm:"not null" json:"max_attempts"`
+	RunAt       time.Time  `gorm:"not null" json:"run_at"`
+	LockedUntil *time.Time `json:"-"`
+	LastError   string     `gorm:"type:text" json:"last_error"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// JobSchedule records when a scheduled job next runs. Replicas share it, so
+// each run is enqueued once however many schedulers are running.
+type JobSchedule struct {
+	Name      string     `gorm:"primaryKey;size:100" json:"name"`
+	Spec      string     `gorm:"size:100;not null" json:"spec"`
+	NextRunAt time.Time  `gorm:"not null" json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Outbox message statuses. A pending message is retried until it is sent or
+// runs out of attempts and goes dead, where it waits for an admin to
+// requeue it.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead"
+)
+
+// OutboxMessage is a rendered email queued for delivery by the outbox worker.
+// The bodies are erased once it is sent since they may hold one-time codes.
+type OutboxMessage struct {
+	Id            int64      `gorm:"primaryKey" json:"id"`
+	Template      string     `gorm:"size:100;not null" json:"template"`
+	Recipient     string     `gorm:"size:255;not null" json:"recipient"`
+	Subject       string     `gorm:"size:255;not null" json:"subject"`
+	HTML          string     `gorm:"column:html;type:text" json:"-"`
+	Text          string     `gorm:"column:text;type:text" json:"-"`
+	Status        string     `gorm:"size:20;not null" json:"status"`
+	Attempts      int        `gorm:"not null" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null" json:"next_attempt_at"`
+	LockedUntil   *time.Time `json:"-"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "mail_outbox"
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Permission names checked by middleware.RequirePermission. They follow the
+// "resource:action" convention.
+const (
+	PermissionUsersRead      = "users:read"
+	PermissionUsersCreate    = "users:create"
+	PermissionUsersUpdate    = "users:update"
+	PermissionUsersDelete    = "users:delete"
+	PermissionUsersUnlock    = "users:unlock"
+	PermissionUsersPurge     = "users:purge"
+	PermissionSessionsRevoke = "sessions:revoke"
+	PermissionRolesManage    = "roles:manage"
+	PermissionMailManage     = "mail:manage"
+	PermissionAuditRead      = "audit:read"
+)
+
+// DefaultPermissions are created by the migration if they do not exist yet.
+var DefaultPermissions = []Permission{
+	{Name: PermissionUsersRead, Description: "List and view users"},
+	{Name: PermissionUsersCreate, Description: "Create users"},
+	{Name: PermissionUsersUpdate, Description: "Update any user"},
+	{Name: PermissionUsersDelete, Description: "Delete any user"},
+	{Name: PermissionUsersUnlock, Description: "Clear brute-force lockouts"},
+	{Name: PermissionUsersPurge, Description: "Permanently delete deleted users"},
+	{Name: PermissionSessionsRevoke, Description: "Revoke the sessions of any user"},
+	{Name: PermissionRolesManage, Description: "Manage roles, permissions and role assignments"},
+	{Name: PermissionMailManage, Description: "Inspect and requeue outgoing mail"},
+	{Name: PermissionAuditRead, Description: "Query, export and verify the audit log"},
+}
+
+type Permission struct {
+	Id          int       `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Description string    `gorm:"size:255" json:"description"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
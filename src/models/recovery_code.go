@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RecoveryCode is a single-use MFA backup code. Only its SHA-256 hash is kept.
+type RecoveryCode struct {
+	Id        int        `gorm:"primaryKey" json:"id"`
+	UserId    int        `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null;index" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RefreshToken stores one issued refresh token. Tokens minted from the same
+// login share a FamilyId so the whole chain can be revoked when a rotated
+// token is replayed.
+type RefreshToken struct {
+	Id        int        `gorm:"primaryKey" json:"id"`
+	UserId    int        `gorm:"not null;index" json:"user_id"`
+	FamilyId  string     `gorm:"size:36;not null;index" json:"family_id"`
+	JtiHash   string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	UserAgent string     `gorm:"size:255" json:"user_agent"`
+	IP        string     `gorm:"column:ip;size:45" json:"ip"`
+	IssuedAt  time.Time  `gorm:"not null" json:"issued_at"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt *time.Time `gorm:"index" json:"revoked_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// DefaultRoles are created by the migration if they do not exist yet.
+// RoleAdmin is granted every default permission, including ones added in
+// later releases.
+var DefaultRoles = []Role{
+	{Name: RoleAdmin, Description: "Full access to administrative endpoints"},
+	{Name: RoleUser, Description: "Regular account without administrative access"},
+}
+
+type Role struct {
+	Id          int          `gorm:"primaryKey" json:"id"`
+	Name        string       `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Description string       `gorm:"size:255" json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions"`
+	CreatedAt   time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Session represents one signed-in device. Its Id is the family id shared by
+// every refresh token issued for that login.
+type Session struct {
+	Id         string     `gorm:"primaryKey;size:36" json:"id"`
+	UserId     int        `gorm:"not null;index" json:"user_id"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	IP         string     `gorm:"column:ip;size:45" json:"ip"`
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -1,18 +1,59 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
-	Id            int        `gorm:"primaryKey" json:"id"`
-	Name          string     `gorm:"not null" json:"name"`
-	Email         string     `gorm:"unique; not null" json:"email"`
-	Password      string     `gorm:"not null" json:"password"`
-	Role          string     `gorm:"default:user" json:"role"`
-	OTPCode       *string    `gorm:"column:otp_code" json:"-"`
-	OTPCodeExp    *time.Time `gorm:"column:otp_code_exp" json:"-"`
-	ResetToken    *string    `gorm:"column:reset_token" json:"-"`
-	ResetTokenExp *time.Time `gorm:"column:reset_token_exp" json:"-"`
-	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt     *time.Time `gorm:"index" json:"-"`
+	Id                  int        `gorm:"primaryKey" json:"id"`
+	Name                string     `gorm:"not null" json:"name"`
+	Email               string     `gorm:"unique; not null" json:"email"`
+	Password            string     `gorm:"not null" json:"password"`
+	Roles               []Role     `gorm:"many2many:user_roles" json:"roles,omitempty"`
+	OTPCode             *string    `gorm:"column:otp_code" json:"-"`
+	OTPCodeExp          *time.Time `gorm:"column:otp_code_exp" json:"-"`
+	OTPAttempts         int        `gorm:"column:otp_attempts;not null;default:0" json:"-"`
+	ResetToken          *string    `gorm:"column:reset_token" json:"-"`
+	ResetTokenExp       *time.Time `gorm:"column:reset_token_exp" json:"-"`
+	MFAEnabled          bool       `gorm:"column:mfa_enabled;default:false" json:"mfa_enabled"`
+	MFASecret           *string    `gorm:"column:mfa_secret" json:"-"`
+	MFALastStep         *int64     `gorm:"column:mfa_last_step" json:"-"`
+	EmailVerifiedAt     *time.Time `gorm:"column:email_verified_at" json:"email_verified_at"`
+	EmailVerifyCode     *string    `gorm:"column:email_verify_code" json:"-"`
+	EmailVerifyCodeExp  *time.Time `gorm:"column:email_verify_code_exp" json:"-"`
+	EmailVerifySentAt   *time.Time `gorm:"column:email_verify_sent_at" json:"-"`
+	EmailVerifyAttempts int        `gorm:"column:email_verify_attempts;not null;default:0" json:"-"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	// DeletedAt is when the user was soft deleted, or null.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"`
+}
+
+// RoleNames returns the names of the user's loaded roles.
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		names = append(names, role.Name)
+	}
+
+	return names
+}
+
+// PermissionNames returns the distinct permissions granted by the user's
+// loaded roles.
+func (u *User) PermissionNames() []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, role := range u.Roles {
+		for _, permission := range role.Permissions {
+			if !seen[permission.Name] {
+				seen[permission.Name] = true
+				names = append(names, permission.Name)
+			}
+		}
+	}
+
+	return names
 }
@@ -0,0 +1,46 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"restApi-GoGin/src/mail"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type sender struct {
+	repo      repository.OutboxRepository
+	templates *mail.Templates
+}
+
+// NewSender returns a mail.Sender that renders the message and queues it in
+// the outbox instead of sending it. Called with the context of
+// repository.Transactor.Transaction, the message is only queued when the
+// transaction commits, and is delivered later by the worker of NewWorker.
+func NewSender(repo repository.OutboxRepository, templates *mail.Templates) *sender {
+	return &sender{repo: repo, templates: templates}
+}
+
+func (s *sender) SendTemplate(ctx context.Context, to, name string, data any) (err error) {
+	ctx, span := tracing.Start(ctx, "outbox.enqueue", attribute.String("mail.template", name))
+	defer tracing.End(span, &err)
+
+	msg, err := s.templates.Render(mail.Locale(ctx), name, data)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Create(ctx, &models.OutboxMessage{
+		Template:      name,
+		Recipient:     to,
+		Subject:       msg.Subject,
+		HTML:          msg.HTML,
+		Text:          msg.Text,
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	})
+}
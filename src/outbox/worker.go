@@ -0,0 +1,164 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/mail"
+	"restApi-GoGin/src/metrics"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
+	"restApi-GoGin/src/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Options configure NewWorker.
+type Options struct {
+	// Workers is the number of messages delivered concurrently.
+	Workers int
+	// PollInterval is how long the worker waits when the outbox is empty.
+	PollInterval time.Duration
+	// MaxAttempts is the number of deliveries tried before a message goes
+	// dead.
+	MaxAttempts int
+	// BaseBackoff is the delay after the first failure. It doubles with each
+	// further failure up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// SendTimeout bounds one delivery. The lease on a claimed message is
+	// twice as long, so it is not claimed again while still being sent.
+	SendTimeout time.Duration
+}
+
+// OptionsFromConfig reads the MAIL_OUTBOX_* settings.
+func OptionsFromConfig(cfg *config.Config) Options {
+	return Options{
+		Workers:      cfg.MAIL_OUTBOX_WORKERS,
+		PollInterval: cfg.MAIL_OUTBOX_POLL_INTERVAL,
+		MaxAttempts:  cfg.MAIL_OUTBOX_MAX_ATTEMPTS,
+		BaseBackoff:  cfg.MAIL_OUTBOX_BASE_BACKOFF,
+		MaxBackoff:   cfg.MAIL_OUTBOX_MAX_BACKOFF,
+		SendTimeout:  cfg.MAIL_OUTBOX_SEND_TIMEOUT,
+	}
+}
+
+type worker struct {
+	repo    repository.OutboxRepository
+	mailer  mail.Mailer
+	options Options
+	log     *slog.Logger
+}
+
+// NewWorker returns a worker that delivers the outbox of repo with mailer.
+// Several replicas may run one each: a message is leased to one worker at a
+// time. Delivery is at least once, as a message whose lease runs out while
+// the mail server is still accepting it is sent again.
+func NewWorker(repo repository.OutboxRepository, mailer mail.Mailer, options Options, log *slog.Logger) *worker {
+	return &worker{repo: repo, mailer: mailer, options: options, log: log}
+}
+
+// Run delivers due messages until ctx is done, then waits for the
+// deliveries in progress to finish.
+func (w *worker) Run(ctx context.Context) {
+	queue := make(chan models.OutboxMessage)
+	var wg sync.WaitGroup
+	for range max(w.options.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for message := range queue {
+				w.deliver(message)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(queue)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		claimed := w.dispatch(ctx, queue)
+		// Keep going while there is a backlog, otherwise wait for new mail.
+		if claimed < max(w.options.Workers, 1) {
+			timer.Reset(w.options.PollInterval)
+		} else {
+			timer.Reset(0)
+		}
+	}
+}
+
+// dispatch claims a batch of due messages and hands them to the pool. It
+// returns the number claimed.
+func (w *worker) dispatch(ctx context.Context, queue chan<- models.OutboxMessage) int {
+	messages, err := w.repo.Claim(ctx, time.Now(), 2*w.options.SendTimeout, max(w.options.Workers, 1))
+	if err != nil && ctx.Err() == nil {
+		w.log.Error("claiming outbox messages failed", "error", err)
+	}
+	for _, message := range messages {
+		// The lease of a message not handed over before shutdown runs out,
+		// and another worker picks it up.
+		select {
+		case queue <- message:
+		case <-ctx.Done():
+			return len(messages)
+		}
+	}
+	return len(messages)
+}
+
+// deliver sends one message and records the outcome. It does not use the
+// context of Run, so shutdown lets deliveries in progress finish.
+func (w *worker) deliver(message models.OutboxMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.options.SendTimeout)
+	defer cancel()
+
+	var err error
+	ctx, span := tracing.Start(ctx, "outbox.deliver",
+		attribute.Int64("outbox.message_id", message.Id),
+		attribute.String("mail.template", message.Template),
+		attribute.Int("outbox.attempt", message.Attempts+1),
+	)
+	defer tracing.End(span, &err)
+
+	err = w.mailer.Send(ctx, &mail.Message{
+		To:      []string{message.Recipient},
+		Subject: message.Subject,
+		HTML:    message.HTML,
+		Text:    message.Text,
+	})
+	if err == nil {
+		metrics.OutboxDelivery(metrics.OutboxSent)
+		if markErr := w.repo.MarkSent(context.Background(), message.Id, time.Now()); markErr != nil {
+			w.log.Error("recording outbox delivery failed", "message_id", message.Id, "error", markErr)
+		}
+		return
+	}
+
+	message.Attempts++
+	message.LastError = err.Error()
+	log := w.log.With("message_id", message.Id, "template", message.Template, "attempts", message.Attempts, "error", err)
+	if message.Attempts >= w.options.MaxAttempts {
+		message.Status = models.OutboxDead
+		metrics.OutboxDelivery(metrics.OutboxDead)
+		log.Error("outbox message is dead after its last attempt")
+	} else {
+		message.NextAttemptAt = time.Now().Add(utils.Backoff(message.Attempts, w.options.BaseBackoff, w.options.MaxBackoff))
+		metrics.OutboxDelivery(metrics.OutboxRetry)
+		log.Warn("outbox delivery failed, will retry", "next_attempt_at", message.NextAttemptAt)
+	}
+
+	if markErr := w.repo.MarkFailed(context.Background(), &message); markErr != nil {
+		w.log.Error("recording outbox failure failed", "message_id", message.Id, "error", markErr)
+	}
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"restApi-GoGin/src/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxAppendAttempts bounds how often Append retries when other entries keep
+// taking the next position in the chain.
+const maxAppendAttempts = 10
+
+// auditBatchSize is the number of entries Each loads at a time.
+const auditBatchSize = 500
+
+// ErrAuditContention is returned by Append when the entry found no free
+// position in the chain after maxAppendAttempts tries.
+var ErrAuditContention = errors.New("audit log: too many concurrent appends")
+
+type AuditRepository interface {
+	Append(ctx context.Context, entry *models.AuditLog) error
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, int64, error)
+	Each(ctx context.Context, filter AuditFilter, fn func(entry *models.AuditLog) error) error
+}
+
+// AuditFilter narrows the entries returned by List and Each. Zero fields
+// match everything, and Until is exclusive.
+type AuditFilter struct {
+	Action     string
+	ActorId    *int
+	TargetType string
+	TargetId   string
+	RequestId  string
+	From       *time.Time
+	Until      *time.Time
+	Offset     int
+	Limit      int
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *auditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+// Append adds entry at the end of the chain, setting its Id, PrevHash and
+// Hash. Positions are claimed with an insert that does nothing when the Id
+// is taken, so concurrent appends cannot fork the chain: the loser reads the
+// new end and tries again. Append ignores any transaction of ctx, as the
+// entry has to be written even when the action it records failed, and a
+// transaction's snapshot could keep returning a stale end.
+func (r *auditRepository) Append(ctx context.Context, entry *models.AuditLog) error {
+	db := r.db.WithContext(ctx)
+	for range maxAppendAttempts {
+		var last models.AuditLog
+		if err := db.Select("id", "hash").Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+
+		entry.Id = last.Id + 1
+		entry.PrevHash = last.Hash
+		entry.Hash = entry.ChainHash()
+
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil
+		}
+	}
+	return ErrAuditContention
+}
+
+// List returns one page of the entries matching filter, newest first,
+// together with the number of matching entries across all pages.
+func (r *auditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuditLog{}).Scopes(filter.scope).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLog
+	err := query.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&entries).Error
+	return entries, total, err
+}
+
+// Each calls fn with every entry matching filter, oldest first, loading
+// auditBatchSize entries at a time. Offset and Limit are ignored. It stops at
+// the first error fn returns.
+func (r *auditRepository) Each(ctx context.Context, filter AuditFilter, fn func(entry *models.AuditLog) error) error {
+	var after int64
+	for {
+		var batch []models.AuditLog
+		err := r.db.WithContext(ctx).Scopes(filter.scope).
+			Where("id > ?", after).
+			Order("id").
+			Limit(auditBatchSize).
+			Find(&batch).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if len(batch) < auditBatchSize {
+			return nil
+		}
+		after = batch[len(batch)-1].Id
+	}
+}
+
+func (f AuditFilter) scope(db *gorm.DB) *gorm.DB {
+	if f.Action != "" {
+		db = db.Where("action = ?", f.Action)
+	}
+	if f.ActorId != nil {
+		db = db.Where("actor_id = ?", *f.ActorId)
+	}
+	if f.TargetType != "" {
+		db = db.Where("target_type = ?", f.TargetType)
+	}
+	if f.TargetId != "" {
+		db = db.Where("target_id = ?", f.TargetId)
+	}
+	if f.RequestId != "" {
+		db = db.Where("request_id = ?", f.RequestId)
+	}
+	if f.From != nil {
+		db = db.Where("created_at >= ?", *f.From)
+	}
+	if f.Until != nil {
+		db = db.Where("created_at < ?", *f.Until)
+	}
+	return db
+}
@@ -1,15 +1,16 @@
 package repository
 
 import (
+	"context"
 	"restApi-GoGin/src/models"
 
 	"gorm.io/gorm"
 )
 
 type AuthRepository interface {
-	EmailExists(email string) bool
-	Register(user *models.User) error
-	GetUserById(id int) (*models.User, error)
+	EmailExists(ctx context.Context, email string) bool
+	Register(ctx context.Context, user *models.User) error
+	GetUserById(ctx context.Context, id int) (*models.User, error)
 }
 
 type authRepository struct {
@@ -22,22 +23,24 @@ func NewAuthRepository(db *gorm.DB) *authRepository {
 	}
 }
 
-func (r *authRepository) EmailExists(email string) bool {
+// EmailExists reports whether a user has the email. Soft-deleted users keep
+// their address until they are purged, so it cannot be registered again.
+func (r *authRepository) EmailExists(ctx context.Context, email string) bool {
 	var user models.User
-	err := r.db.First(&user, "email = ?", email).Error
+	err := conn(ctx, r.db).Unscoped().First(&user, "email = ?", email).Error
 
 	return err == nil
 }
 
-func (r *authRepository) Register(user *models.User) error {
-	err := r.db.Create(&user).Error
+func (r *authRepository) Register(ctx context.Context, user *models.User) error {
+	err := conn(ctx, r.db).Create(&user).Error
 
 	return err
 }
 
-func (r *authRepository) GetUserById(id int) (*models.User, error) {
+func (r *authRepository) GetUserById(ctx context.Context, id int) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("id = ? AND deleted_at IS NULL", id).First(&user).Error
+	err := conn(ctx, r.db).First(&user, id).Error
 
 	return &user, err
 }
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"restApi-GoGin/src/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccountThrottleKey returns the throttle key shared by every authentication
+// attempt against one email address, whether or not the account exists.
+func AccountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+type AuthThrottleRepository interface {
+	GetByKeys(ctx context.Context, keys []string) ([]models.AuthThrottle, error)
+	RegisterFailure(ctx context.Context, key string, now time.Time, windowStart time.Time) (*models.AuthThrottle, error)
+	Lock(ctx context.Context, key string, now time.Time, until time.Time) (bool, error)
+	DeleteByKeys(ctx context.Context, keys []string) error
+}
+
+type authThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthThrottleRepository(db *gorm.DB) *authThrottleRepository {
+	return &authThrottleRepository{
+		db: db,
+	}
+}
+
+func (r *authThrottleRepository) GetByKeys(ctx context.Context, keys []string) ([]models.AuthThrottle, error) {
+	var throttles []models.AuthThrottle
+	err := conn(ctx, r.db).Where("throttle_key IN ?", keys).Find(&throttles).Error
+
+	return throttles, err
+}
+
+// RegisterFailure counts one failed attempt for key and returns the throttle
+// as it is after the write. The count is increased by the database in a
+// single upsert, so concurrent failures are never lost. It starts again at one
+// when the key is not locked and its last failure came before windowStart.
+func (r *authThrottleRepository) RegisterFailure(ctx context.Context, key string, now time.Time, windowStart time.Time) (*models.AuthThrottle, error) {
+	throttle := models.AuthThrottle{Key: key, Failures: 1, LastFailureAt: now}
+	err := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "throttle_key"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"failures": gorm.Expr(
+				"CASE WHEN (auth_throttles.locked_until IS NULL OR auth_throttles.locked_until <= ?) "+
+					"AND auth_throttles.last_failure_at < ? THEN 1 ELSE auth_throttles.failures + 1 END",
+				now, windowStart,
+			),
+			"last_failure_at": now,
+			"updated_at":      now,
+		}),
+	}).Create(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// The upsert does not return the row it updated on every driver.
+	var stored models.AuthThrottle
+	if err := conn(ctx, r.db).Where("throttle_key = ?", key).First(&stored).Error; err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// Lock locks key until the given time unless it is already locked for
+// longer. It reports whether key was unlocked at now, i.e. whether this call
+// started a new lockout rather than extending one.
+func (r *authThrottleRepository) Lock(ctx context.Context, key string, now time.Time, until time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.AuthThrottle{}).
+		Where("throttle_key = ? AND (locked_until IS NULL OR locked_until <= ?)", key, now).
+		UpdateColumn("locked_until", until)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.RowsAffected > 0, result.Error
+	}
+
+	err := conn(ctx, r.db).Model(&models.AuthThrottle{}).
+		Where("throttle_key = ? AND locked_until < ?", key, until).
+		UpdateColumn("locked_until", until).Error
+	return false, err
+}
+
+func (r *authThrottleRepository) DeleteByKeys(ctx context.Context, keys []string) error {
+	return conn(ctx, r.db).Where("throttle_key IN ?", keys).Delete(&models.AuthThrottle{}).Error
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"restApi-GoGin/src/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository interface {
+	Enqueue(ctx context.Context, job *models.Job) (bool, error)
+	Claim(ctx context.Context, names []string, now time.Time, lease time.Duration, limit int) ([]models.Job, error)
+	Complete(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, job *models.Job) error
+	List(ctx context.Context, status string) ([]models.Job, error)
+	SaveSchedule(ctx context.Context, name, spec string, nextRunAt time.Time) error
+	DueSchedules(ctx context.Context, now time.Time) ([]models.JobSchedule, error)
+	ClaimSchedule(ctx context.Context, name string, now, nextRunAt time.Time) (bool, error)
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *jobRepository {
+	return &jobRepository{
+		db: db,
+	}
+}
+
+// Enqueue inserts job and reports whether it was inserted. It is not when a
+// pending job holds the same unique key. Called inside
+// Transactor.Transaction, the job only exists if the transaction commits.
+func (r *jobRepository) Enqueue(ctx context.Context, job *models.Job) (bool, error) {
+	result := conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Claim leases up to limit pending jobs named one of names that are due, the
+// same way as OutboxRepository.Claim. Limiting the names leaves jobs a
+// worker has no handler for, such as new jobs during a rolling deploy, to
+// workers that do.
+func (r *jobRepository) Claim(ctx context.Context, names []string, now time.Time, lease time.Duration, limit int) ([]models.Job, error) {
+	db := conn(ctx, r.db)
+	claimable := func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? AND name IN ? AND run_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", models.JobPending, names, now, now)
+	}
+
+	var candidates []models.Job
+	err := db.Scopes(claimable).Order("run_at").Order("id").Limit(limit).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lockedUntil := now.Add(lease)
+	claimed := make([]models.Job, 0, len(candidates))
+	for _, job := range candidates {
+		result := db.Model(&models.Job{}).Scopes(claimable).
+			Where("id = ?", job.Id).
+			Update("locked_until", lockedUntil)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.LockedUntil = &lockedUntil
+			claimed = append(claimed, job)
+		}
+	}
+	return claimed, nil
+}
+
+// Complete deletes a job that succeeded, freeing its unique key.
+func (r *jobRepository) Complete(ctx context.Context, id int64) error {
+	return conn(ctx, r.db).Delete(&models.Job{}, id).Error
+}
+
+// MarkFailed saves the status, attempts, next run and error of a failed job
+// and releases the lease. A dead job gives up its unique key, so the same
+// work can be enqueued again.
+func (r *jobRepository) MarkFailed(ctx context.Context, job *models.Job) error {
+	updates := map[string]any{
+		"status":       job.Status,
+		"attempts":     job.Attempts,
+		"run_at":       job.RunAt,
+		"last_error":   job.LastError,
+		"locked_until": nil,
+	}
+	if job.Status == models.JobDead {
+		updates["unique_key"] = nil
+	}
+	return conn(ctx, r.db).Model(&models.Job{}).Where("id = ?", job.Id).Updates(updates).Error
+}
+
+// List returns the jobs with status, or every job when status is empty, in
+// the order they run.
+func (r *jobRepository) List(ctx context.Context, status string) ([]models.Job, error) {
+	query := conn(ctx, r.db)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var jobs []models.Job
+	err := query.Order("run_at").Order("id").Find(&jobs).Error
+	return jobs, err
+}
+
+// SaveSchedule records the schedule of a job. A new schedule, or one whose
+// spec changed, next runs at nextRunAt; an unchanged one keeps its next run.
+func (r *jobRepository) SaveSchedule(ctx context.Context, name, spec string, nextRunAt time.Time) error {
+	db := conn(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.JobSchedule{
+		Name:      name,
+		Spec:      spec,
+		NextRunAt: nextRunAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Model(&models.JobSchedule{}).
+		Where("name = ? AND spec <> ?", name, spec).
+		Updates(map[string]any{"spec": spec, "next_run_at": nextRunAt}).Error
+}
+
+func (r *jobRepository) DueSchedules(ctx context.Context, now time.Time) ([]models.JobSchedule, error) {
+	var schedules []models.JobSchedule
+	err := conn(ctx, r.db).Where("next_run_at <= ?", now).Order("next_run_at").Find(&schedules).Error
+	return schedules, err
+}
+
+// ClaimSchedule moves a due schedule on to nextRunAt and reports whether this
+// call did so. When several schedulers find the same run due only one
+// update matches, and only that one enqueues the job.
+func (r *jobRepository) ClaimSchedule(ctx context.Context, name string, now, nextRunAt time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.JobSchedule{}).
+		Where("name = ? AND next_run_at <= ?", name, now).
+		Updates(map[string]any{"next_run_at": nextRunAt, "last_run_at": now})
+	return result.RowsAffected == 1, result.Error
+}
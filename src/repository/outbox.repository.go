@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"restApi-GoGin/src/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	Create(ctx context.Context, message *models.OutboxMessage) error
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxMessage, error)
+	MarkSent(ctx context.Context, id int64, sentAt time.Time) error
+	MarkFailed(ctx context.Context, message *models.OutboxMessage) error
+	List(ctx context.Context, filter OutboxFilter) ([]models.OutboxMessage, int64, error)
+	GetById(ctx context.Context, id int64) (*models.OutboxMessage, error)
+	Requeue(ctx context.Context, id int64, now time.Time) (bool, error)
+	RequeueDead(ctx context.Context, now time.Time) (int64, error)
+}
+
+// OutboxFilter narrows the messages returned by List. An empty Status
+// matches every status.
+type OutboxFilter struct {
+	Status string
+	Offset int
+	Limit  int
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *outboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+// Create queues message. Called inside Transactor.Transaction, the message is
+// only queued if the transaction commits.
+func (r *outboxRepository) Create(ctx context.Context, message *models.OutboxMessage) error {
+	return conn(ctx, r.db).Create(message).Error
+}
+
+// Claim leases up to limit pending messages that are due, so no other worker
+// picks them up until now+lease. Each row is claimed with a conditional
+// update rather than a row lock, which works the same on every driver: when
+// two workers race for a row only one update matches.
+func (r *outboxRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxMessage, error) {
+	db := conn(ctx, r.db)
+	claimable := func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", models.OutboxPending, now, now)
+	}
+
+	var candidates []models.OutboxMessage
+	err := db.Scopes(claimable).Order("next_attempt_at").Order("id").Limit(limit).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lockedUntil := now.Add(lease)
+	claimed := make([]models.OutboxMessage, 0, len(candidates))
+	for _, message := range candidates {
+		result := db.Model(&models.OutboxMessage{}).Scopes(claimable).
+			Where("id = ?", message.Id).
+			Update("locked_until", lockedUntil)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			message.LockedUntil = &lockedUntil
+			claimed = append(claimed, message)
+		}
+	}
+	return claimed, nil
+}
+
+// MarkSent records the delivery and erases the bodies.
+func (r *outboxRepository) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
+	return conn(ctx, r.db).Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]any{
+		"status":       models.OutboxSent,
+		"sent_at":      sentAt,
+		"locked_until": nil,
+		"last_error":   "",
+		"html":         "",
+		"text":         "",
+	}).Error
+}
+
+// MarkFailed saves the status, attempts, next attempt and error of a failed
+// delivery and releases the lease.
+func (r *outboxRepository) MarkFailed(ctx context.Context, message *models.OutboxMessage) error {
+	return conn(ctx, r.db).Model(&models.OutboxMessage{}).Where("id = ?", message.Id).Updates(map[string]any{
+		"status":          message.Status,
+		"attempts":        message.Attempts,
+		"next_attempt_at": message.NextAttemptAt,
+		"last_error":      message.LastError,
+		"locked_until":    nil,
+	}).Error
+}
+
+// List returns one page of the messages matching filter, newest first,
+// together with the number of matching messages across all pages.
+func (r *outboxRepository) List(ctx context.Context, filter OutboxFilter) ([]models.OutboxMessage, int64, error) {
+	query := conn(ctx, r.db).Model(&models.OutboxMessage{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var messages []models.OutboxMessage
+	err := query.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&messages).Error
+	return messages, total, err
+}
+
+func (r *outboxRepository) GetById(ctx context.Context, id int64) (*models.OutboxMessage, error) {
+	var message models.OutboxMessage
+	err := conn(ctx, r.db).First(&message, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+// Requeue makes a dead message pending again with a fresh set of attempts.
+// It reports false when the message is not dead.
+func (r *outboxRepository) Requeue(ctx context.Context, id int64, now time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, models.OutboxDead).
+		Updates(requeued(now))
+	return result.RowsAffected == 1, result.Error
+}
+
+// RequeueDead requeues every dead message and returns how many there were.
+func (r *outboxRepository) RequeueDead(ctx context.Context, now time.Time) (int64, error) {
+	result := conn(ctx, r.db).Model(&models.OutboxMessage{}).
+		Where("status = ?", models.OutboxDead).
+		Updates(requeued(now))
+	return result.RowsAffected, result.Error
+}
+
+func requeued(now time.Time) map[string]any {
+	return map[string]any{
+		"status":          models.OutboxPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"locked_until":    nil,
+	}
+}
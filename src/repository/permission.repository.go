@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"restApi-GoGin/src/models"
+
+	"gorm.io/gorm"
+)
+
+type PermissionRepository interface {
+	GetAll() ([]models.Permission, error)
+	GetById(id int) (*models.Permission, error)
+	GetByNames(names []string) ([]models.Permission, error)
+	Create(permission *models.Permission) error
+	Delete(id int) error
+}
+
+type permissionRepository struct {
+	db *gorm.DB
+}
+
+func NewPermissionRepository(db *gorm.DB) *permissionRepository {
+	return &permissionRepository{
+		db: db,
+	}
+}
+
+func (r *permissionRepository) GetAll() ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.db.Order("name").Find(&permissions).Error
+
+	return permissions, err
+}
+
+func (r *permissionRepository) GetById(id int) (*models.Permission, error) {
+	var permission models.Permission
+	err := r.db.First(&permission, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &permission, nil
+}
+
+func (r *permissionRepository) GetByNames(names []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.db.Where("name IN ?", names).Find(&permissions).Error
+
+	return permissions, err
+}
+
+func (r *permissionRepository) Create(permission *models.Permission) error {
+	return r.db.Create(permission).Error
+}
+
+// Delete removes the permission and revokes it from every role.
+func (r *permissionRepository) Delete(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM role_permissions WHERE permission_id = ?", id).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.Permission{}, id).Error
+	})
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"restApi-GoGin/src/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userId int, codeHashes []string) error
+	Consume(ctx context.Context, userId int, codeHash string) (bool, error)
+	DeleteByUser(ctx context.Context, userId int) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) *recoveryCodeRepository {
+	return &recoveryCodeRepository{
+		db: db,
+	}
+}
+
+// Replace discards every existing code of the user and stores the new set.
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userId int, codeHashes []string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, models.RecoveryCode{UserId: userId, CodeHash: hash})
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume marks an unused code as used and reports whether one matched.
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userId int, codeHash string) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Limit(1).
+		Update("used_at", time.Now())
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *recoveryCodeRepository) DeleteByUser(ctx context.Context, userId int) error {
+	return conn(ctx, r.db).Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"restApi-GoGin/src/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByJtiHash(ctx context.Context, jtiHash string) (*models.RefreshToken, error)
+	Revoke(ctx context.Context, id int) (bool, error)
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *refreshTokenRepository {
+	return &refreshTokenRepository{
+		db: db,
+	}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return conn(ctx, r.db).Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetByJtiHash(ctx context.Context, jtiHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := conn(ctx, r.db).Where("jti_hash = ?", jtiHash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke marks a single token as revoked. It reports false when the token was
+// already revoked, which lets callers treat a lost race as token reuse.
+func (r *refreshTokenRepository) Revoke(ctx context.Context, id int) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+
+	return result.RowsAffected > 0, result.Error
+}
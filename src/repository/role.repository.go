@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"restApi-GoGin/src/models"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	GetAll() ([]models.Role, error)
+	GetById(id int) (*models.Role, error)
+	GetByNames(names []string) ([]models.Role, error)
+	Create(role *models.Role) error
+	Update(role *models.Role) error
+	Delete(id int) error
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *roleRepository {
+	return &roleRepository{
+		db: db,
+	}
+}
+
+func (r *roleRepository) GetAll() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Order("name").Find(&roles).Error
+
+	return roles, err
+}
+
+func (r *roleRepository) GetById(id int) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions").First(&role, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) GetByNames(names []string) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Where("name IN ?", names).Find(&roles).Error
+
+	return roles, err
+}
+
+func (r *roleRepository) Create(role *models.Role) error {
+	return r.db.Create(role).Error
+}
+
+// Update saves the role and replaces its permissions with role.Permissions.
+func (r *roleRepository) Update(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return err
+		}
+
+		if len(role.Permissions) == 0 {
+			return tx.Model(role).Association("Permissions").Clear()
+		}
+
+		return tx.Model(role).Association("Permissions").Replace(role.Permissions)
+	})
+}
+
+// Delete removes the role together with its permission grants and user
+// assignments.
+func (r *roleRepository) Delete(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		role := models.Role{Id: id}
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM user_roles WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&role).Error
+	})
+}
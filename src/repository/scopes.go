@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The helpers below build conditions through gorm clauses rather than raw
+// SQL, so identifiers are quoted for whichever driver is in use.
+
+// onlyDeleted keeps rows of table that are soft deleted. The query has to be
+// Unscoped, or gorm keeps only the rows that are not.
+func onlyDeleted(table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Neq{Column: clause.Column{Table: table, Name: "deleted_at"}, Value: nil})
+	}
+}
+
+// containsFold matches rows whose column contains s, ignoring case. MySQL
+// compares case-insensitively by default but PostgreSQL and SQLite do not,
+// so both sides are lowered. The escape character is given explicitly since
+// SQLite has no default one.
+func containsFold(table string, column string, s string) clause.Expression {
+	return clause.Expr{
+		SQL:  "LOWER(?) LIKE ? ESCAPE '!'",
+		Vars: []any{clause.Column{Table: table, Name: column}, "%" + escapeLike(strings.ToLower(s)) + "%"},
+	}
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
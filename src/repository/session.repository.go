@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"restApi-GoGin/src/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	Refresh(ctx context.Context, id string, expiresAt time.Time, ip string) (bool, error)
+	GetById(ctx context.Context, id string) (*models.Session, error)
+	GetActiveByUser(ctx context.Context, userId int) ([]models.Session, error)
+	Touch(ctx context.Context, id string, ip string) error
+	Revoke(ctx context.Context, id string) error
+	RevokeAllByUser(ctx context.Context, userId int, exceptId string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *sessionRepository {
+	return &sessionRepository{
+		db: db,
+	}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return conn(ctx, r.db).Create(session).Error
+}
+
+// Refresh extends a session that is still active. It reports false when the
+// session was revoked in the meantime, which it leaves revoked.
+func (r *sessionRepository) Refresh(ctx context.Context, id string, expiresAt time.Time, ip string) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]any{"last_seen_at": time.Now(), "expires_at": expiresAt, "ip": ip})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *sessionRepository) GetById(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	err := conn(ctx, r.db).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetActiveByUser(ctx context.Context, userId int) ([]models.Session, error) {
+	var sessions []models.Session
+	err := conn(ctx, r.db).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+
+	return sessions, err
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id string, ip string) error {
+	return conn(ctx, r.db).Model(&models.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"last_seen_at": time.Now(), "ip": ip}).Error
+}
+
+// Revoke ends a session and revokes every refresh token issued for it.
+func (r *sessionRepository) Revoke(ctx context.Context, id string) error {
+	now := time.Now()
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error
+	})
+}
+
+// RevokeAllByUser ends every session of a user except exceptId, which may be
+// empty to revoke them all.
+func (r *sessionRepository) RevokeAllByUser(ctx context.Context, userId int, exceptId string) error {
+	now := time.Now()
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		sessions := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userId)
+		tokens := tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userId)
+
+		if exceptId != "" {
+			sessions = sessions.Where("id <> ?", exceptId)
+			tokens = tokens.Where("family_id <> ?", exceptId)
+		}
+
+		if err := sessions.Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		return tokens.Update("revoked_at", now).Error
+	})
+}
+
+// DeleteExpired deletes the sessions and refresh tokens that expired before
+// now, revoked or not, and returns the number of sessions deleted. An
+// expired refresh token is refused whatever its state, so nothing is lost.
+func (r *sessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("expires_at < ?", now).Delete(&models.Session{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
@@ -1,20 +1,69 @@
 package repository
 
 import (
+	"context"
 	"restApi-GoGin/src/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
-	UpdateUser(user *models.User) error
-	GetAllUsers() ([]models.User, error)
-	GetUserByEmail(email string) (*models.User, error)
-	GetUserByID(id int) (*models.User, error)
-	CreateUser(user *models.User) error
-	DeleteUser(id int) error
+	UpdateUser(ctx context.Context, user *models.User) error
+	GetAllUsers(ctx context.Context, filter UserFilter) ([]models.User, int64, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id int) error
+	GetDeletedUserByID(ctx context.Context, id int) (*models.User, error)
+	RestoreUser(ctx context.Context, id int) error
+	PurgeUser(ctx context.Context, id int) error
+	ReplaceRoles(ctx context.Context, user *models.User, roles []models.Role) error
+	AddCodeAttempt(ctx context.Context, id int, code OneTimeCode, max int) (bool, error)
+	ClearCode(ctx context.Context, id int, code OneTimeCode) error
+	UseTOTPStep(ctx context.Context, id int, step int64) (bool, error)
+	ClearExpiredCodes(ctx context.Context, now time.Time) (int64, error)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int64, error)
 }
 
+// UserFilter narrows and orders the users returned by GetAllUsers. Name and
+// Email match substrings; Sort columns must be checked by the caller.
+type UserFilter struct {
+	Role         string
+	Name         string
+	Email        string
+	Status       string
+	CreatedFrom  *time.Time
+	CreatedUntil *time.Time
+	Sort         []UserSort
+	Offset       int
+	Limit        int
+}
+
+// UserSort orders GetAllUsers by one column.
+type UserSort struct {
+	Column string
+	Desc   bool
+}
+
+// User statuses accepted by UserFilter.Status. The empty status means
+// UserStatusActive.
+const (
+	UserStatusActive  = "active"
+	UserStatusDeleted = "deleted"
+	UserStatusAll     = "all"
+)
+
+// OneTimeCode names a kind of one-time code stored on the user, together
+// with its expiry and attempt counter.
+type OneTimeCode string
+
+const (
+	CodeOTP         OneTimeCode = "otp"
+	CodeEmailVerify OneTimeCode = "email_verify"
+)
+
 type userRepository struct {
 	db *gorm.DB
 }
@@ -25,20 +74,68 @@ func NewUserRepository(db *gorm.DB) *userRepository {
 	}
 }
 
-func (r *userRepository) UpdateUser(user *models.User) error {
-	return r.db.Save(user).Error
+// UpdateUser saves the user's own columns. Role assignments are changed with
+// ReplaceRoles only.
+func (r *userRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Save(user).Error
 }
 
-func (r *userRepository) GetAllUsers() ([]models.User, error) {
+// GetAllUsers returns one page of the users matching filter together with
+// the number of matching users across all pages.
+func (r *userRepository) GetAllUsers(ctx context.Context, filter UserFilter) ([]models.User, int64, error) {
+	query := conn(ctx, r.db).Model(&models.User{})
+
+	// gorm leaves soft-deleted users out unless the query is Unscoped.
+	switch filter.Status {
+	case UserStatusAll:
+		query = query.Unscoped()
+	case UserStatusDeleted:
+		query = query.Unscoped().Scopes(onlyDeleted("users"))
+	}
+
+	if filter.Role != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM user_roles JOIN roles ON roles.id = user_roles.role_id "+
+			"WHERE user_roles.user_id = users.id AND roles.name = ?)", filter.Role)
+	}
+	if filter.Name != "" {
+		query = query.Where(containsFold("users", "name", filter.Name))
+	}
+	if filter.Email != "" {
+		query = query.Where(containsFold("users", "email", filter.Email))
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("users.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedUntil != nil {
+		query = query.Where("users.created_at < ?", *filter.CreatedUntil)
+	}
+
+	// Start a new session so counting does not leak into the page query.
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for _, sort := range filter.Sort {
+		query = query.Order(clause.OrderByColumn{
+			Column: clause.Column{Table: "users", Name: sort.Column},
+			Desc:   sort.Desc,
+		})
+	}
+	// A unique tie-breaker keeps pages stable when sort values repeat.
+	query = query.Order("users.id")
+
 	var users []models.User
-	err := r.db.Find(&users).Error
+	err := query.Preload("Roles").Offset(filter.Offset).Limit(filter.Limit).Find(&users).Error
 
-	return users, err
+	return users, total, err
 }
 
-func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := conn(ctx, r.db).Preload("Roles.Permissions").Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -48,9 +145,9 @@ func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) GetUserByID(id int) (*models.User, error) {
+func (r *userRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := conn(ctx, r.db).Preload("Roles.Permissions").First(&user, id).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -60,10 +157,165 @@ func (r *userRepository) GetUserByID(id int) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) CreateUser(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) CreateUser(ctx context.Context, user *models.User) error {
+	return conn(ctx, r.db).Create(user).Error
+}
+
+// DeleteUser soft deletes the user. Their rows stay until RestoreUser brings
+// them back or PurgeUser or PurgeDeleted removes them.
+func (r *userRepository) DeleteUser(ctx context.Context, id int) error {
+	return conn(ctx, r.db).Delete(&models.User{}, id).Error
 }
 
-func (r *userRepository) DeleteUser(id int) error {
-	return r.db.Delete(&models.User{}, id).Error
+// GetDeletedUserByID returns the soft-deleted user with id, or nil when there
+// is none.
+func (r *userRepository) GetDeletedUserByID(ctx context.Context, id int) (*models.User, error) {
+	var user models.User
+	err := conn(ctx, r.db).Unscoped().Scopes(onlyDeleted("users")).Preload("Roles.Permissions").First(&user, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RestoreUser undoes the soft delete of the user.
+func (r *userRepository) RestoreUser(ctx context.Context, id int) error {
+	return conn(ctx, r.db).Unscoped().Model(&models.User{}).
+		Scopes(onlyDeleted("users")).
+		Where("id = ?", id).
+		UpdateColumn("deleted_at", nil).Error
+}
+
+// PurgeUser permanently deletes the soft-deleted user with id together with
+// their dependent rows, see purgeUsers.
+func (r *userRepository) PurgeUser(ctx context.Context, id int) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var ids []int
+		err := tx.Unscoped().Model(&models.User{}).Scopes(onlyDeleted("users")).Where("id = ?", id).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		_, err = purgeUsers(tx, ids)
+		return err
+	})
+}
+
+func (r *userRepository) ReplaceRoles(ctx context.Context, user *models.User, roles []models.Role) error {
+	db := conn(ctx, r.db)
+	if len(roles) == 0 {
+		return db.Model(user).Association("Roles").Clear()
+	}
+
+	return db.Model(user).Association("Roles").Replace(roles)
+}
+
+// AddCodeAttempt counts one attempt at the user's code unless max attempts
+// were made already, and reports whether it was counted. The database does
+// the counting, so concurrent guesses cannot exceed max.
+func (r *userRepository) AddCodeAttempt(ctx context.Context, id int, code OneTimeCode, max int) (bool, error) {
+	attempts := string(code) + "_attempts"
+	result := conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND "+attempts+" < ?", id, max).
+		UpdateColumn(attempts, gorm.Expr(attempts+" + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// ClearCode erases the user's code so it can no longer be used. The attempt
+// counter is kept until a new code is issued.
+func (r *userRepository) ClearCode(ctx context.Context, id int, code OneTimeCode) error {
+	return conn(ctx, r.db).Model(&models.User{}).Where("id = ?", id).UpdateColumns(map[string]any{
+		string(code) + "_code":     nil,
+		string(code) + "_code_exp": nil,
+	}).Error
+}
+
+// UseTOTPStep records step as the user's last used TOTP step unless that
+// step or a later one was used already, and reports whether it was recorded.
+// The database compares the steps, so a code replayed in parallel is only
+// accepted once.
+func (r *userRepository) UseTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND (mfa_last_step IS NULL OR mfa_last_step < ?)", id, step).
+		UpdateColumn("mfa_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ClearExpiredCodes erases the one-time codes and reset tokens that expired
+// before now, and returns the number of users changed.
+func (r *userRepository) ClearExpiredCodes(ctx context.Context, now time.Time) (int64, error) {
+	var cleared int64
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		expirations := []struct {
+			column  string
+			updates map[string]any
+		}{
+			{"otp_code_exp", map[string]any{"otp_code": nil, "otp_code_exp": nil, "otp_attempts": 0}},
+			{"reset_token_exp", map[string]any{"reset_token": nil, "reset_token_exp": nil}},
+			{"email_verify_code_exp", map[string]any{"email_verify_code": nil, "email_verify_code_exp": nil, "email_verify_attempts": 0}},
+		}
+		for _, expiration := range expirations {
+			// Codes of soft-deleted users are cleared as well.
+			result := tx.Unscoped().Model(&models.User{}).Where(expiration.column+" < ?", now).UpdateColumns(expiration.updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			cleared += result.RowsAffected
+		}
+		return nil
+	})
+	return cleared, err
+}
+
+// PurgeDeleted permanently deletes up to limit users soft-deleted before
+// deletedBefore, together with their role assignments, sessions, refresh
+// tokens and recovery codes. It returns the number of users deleted; call it
+// again until that is less than limit.
+func (r *userRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	var purged int64
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var ids []int
+		err := tx.Unscoped().Model(&models.User{}).Where("deleted_at < ?", deletedBefore).Order("id").Limit(limit).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		purged, err = purgeUsers(tx, ids)
+		return err
+	})
+	return purged, err
+}
+
+// purgeUsers permanently deletes the users with ids and their role
+// assignments, sessions, refresh tokens and recovery codes, along with the
+// queued mail and lockout counters that hold their email addresses, and
+// returns the number of users deleted. tx must be a transaction.
+func purgeUsers(tx *gorm.DB, ids []int) (int64, error) {
+	for _, table := range []string{"user_roles", "sessions", "refresh_tokens", "recovery_codes"} {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE user_id IN ?", ids).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	var emails []string
+	if err := tx.Unscoped().Model(&models.User{}).Where("id IN ?", ids).Pluck("email", &emails).Error; err != nil {
+		return 0, err
+	}
+	keys := make([]string, 0, len(emails))
+	for _, email := range emails {
+		keys = append(keys, AccountThrottleKey(email))
+	}
+	if len(emails) > 0 {
+		if err := tx.Where("recipient IN ?", emails).Delete(&models.OutboxMessage{}).Error; err != nil {
+			return 0, err
+		}
+		if err := tx.Where("throttle_key IN ?", keys).Delete(&models.AuthThrottle{}).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.User{})
+	return result.RowsAffected, result.Error
 }
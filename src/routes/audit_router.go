@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AuditRouter(api *gin.RouterGroup) {
+	authRepository := repository.NewAuthRepository(config.DB)
+	sessionRepository := repository.NewSessionRepository(config.DB)
+	auditRepository := repository.NewAuditRepository(config.DB)
+	auditService := services.NewAuditService(auditRepository)
+	auditController := controllers.NewAuditController(auditService)
+
+	audit := api.Group(
+		"/audit",
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionAuditRead),
+	)
+	audit.GET("", auditController.ListEntries)
+	audit.GET("/export", auditController.ExportEntries)
+	audit.GET("/verify", auditController.Verify)
+}
@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"restApi-GoGin/src/audit"
 	"restApi-GoGin/src/config"
 	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/mail"
 	"restApi-GoGin/src/repository"
 	"restApi-GoGin/src/services"
 
@@ -12,11 +14,21 @@ import (
 func AuthRouter(api *gin.RouterGroup) {
 	authRepository := repository.NewAuthRepository(config.DB)
 	userRepository := repository.NewUserRepository(config.DB)
-	authService := services.NewAuthService(authRepository, userRepository)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(config.DB)
+	sessionRepository := repository.NewSessionRepository(config.DB)
+	recoveryCodeRepository := repository.NewRecoveryCodeRepository(config.DB)
+	authThrottleRepository := repository.NewAuthThrottleRepository(config.DB)
+	roleRepository := repository.NewRoleRepository(config.DB)
+	auditRecorder := audit.NewRecorder(repository.NewAuditRepository(config.DB))
+	lockoutService := services.NewLockoutService(authThrottleRepository, userRepository)
+	authService := services.NewAuthService(authRepository, userRepository, refreshTokenRepository, sessionRepository, recoveryCodeRepository, lockoutService, roleRepository, mail.Default(), repository.NewTransactor(config.DB), auditRecorder)
 	authController := controllers.NewAuthController(authService)
 
 	api.POST("/register", authController.Register)
+	api.POST("/verify-email", authController.VerifyEmail)
+	api.POST("/resend-verification", authController.ResendVerification)
 	api.POST("/login", authController.Login)
+	api.POST("/login/mfa", authController.LoginMFA)
 	api.POST("/logout", authController.Logout)
 	api.POST("/refresh-token", authController.RefreshToken)
 	api.POST("/forgot-password", authController.ForgotPassword)
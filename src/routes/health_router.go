@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthRouter is mounted on the root router, outside /api, where
+// orchestrators probe it. /readyz runs the checks in health.Default.
+func HealthRouter(router *gin.Engine) {
+	healthController := controllers.NewHealthController(health.Default())
+
+	router.GET("/healthz", healthController.Live)
+	router.GET("/readyz", healthController.Ready)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"restApi-GoGin/src/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSRouter is mounted on the root router rather than under /api because
+// JWKS clients look for the document at its well-known location.
+func JWKSRouter(router *gin.Engine) {
+	jwksController := controllers.NewJWKSController()
+
+	router.GET("/.well-known/jwks.json", jwksController.JWKS)
+}
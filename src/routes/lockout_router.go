@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func LockoutRouter(api *gin.RouterGroup) {
+	authRepository := repository.NewAuthRepository(config.DB)
+	userRepository := repository.NewUserRepository(config.DB)
+	sessionRepository := repository.NewSessionRepository(config.DB)
+	authThrottleRepository := repository.NewAuthThrottleRepository(config.DB)
+	lockoutService := services.NewLockoutService(authThrottleRepository, userRepository)
+	lockoutController := controllers.NewLockoutController(lockoutService)
+
+	api.POST(
+		"/user/:id/unlock",
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionUsersUnlock),
+		lockoutController.UnlockUser,
+	)
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/metrics"
+	"restApi-GoGin/src/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsRouter serves Prometheus metrics on the root router, outside /api,
+// where scrapers expect them. Setting METRICS_TOKEN requires scrapers to send
+// it as a bearer token.
+func MetricsRouter(router *gin.Engine) {
+	router.GET("/metrics", middleware.StaticToken(config.Current().METRICS_TOKEN), gin.WrapH(metrics.Handler()))
+}
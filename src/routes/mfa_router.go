@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func MFARouter(api *gin.RouterGroup) {
+	authRepository := repository.NewAuthRepository(config.DB)
+	userRepository := repository.NewUserRepository(config.DB)
+	sessionRepository := repository.NewSessionRepository(config.DB)
+	recoveryCodeRepository := repository.NewRecoveryCodeRepository(config.DB)
+	mfaService := services.NewMFAService(userRepository, recoveryCodeRepository)
+	mfaController := controllers.NewMFAController(mfaService)
+
+	mfa := api.Group("/mfa", middleware.Auth(authRepository, sessionRepository), middleware.VerifiedEmail())
+	mfa.POST("/enroll", mfaController.Enroll)
+	mfa.POST("/confirm", mfaController.Confirm)
+	mfa.POST("/disable", mfaController.Disable)
+	mfa.POST("/recovery-codes", mfaController.RegenerateRecoveryCodes)
+}
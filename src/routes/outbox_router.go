@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func OutboxRouter(api *gin.RouterGroup) {
+	authRepository := repository.NewAuthRepository(config.DB)
+	sessionRepository := repository.NewSessionRepository(config.DB)
+	outboxRepository := repository.NewOutboxRepository(config.DB)
+	outboxService := services.NewOutboxService(outboxRepository)
+	outboxController := controllers.NewOutboxController(outboxService)
+
+	outbox := api.Group(
+		"/mail/outbox",
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionMailManage),
+	)
+	outbox.GET("", outboxController.ListMessages)
+	outbox.POST("/requeue", outboxController.RequeueDead)
+	outbox.GET("/:id", outboxController.GetMessage)
+	outbox.POST("/:id/requeue", outboxController.Requeue)
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RoleRouter(api *gin.RouterGroup) {
+	authRepository := repository.NewAuthRepository(config.DB)
+	userRepository := repository.NewUserRepository(config.DB)
+	sessionRepository := repository.NewSessionRepository(config.DB)
+	roleRepository := repository.NewRoleRepository(config.DB)
+	permissionRepository := repository.NewPermissionRepository(config.DB)
+	auditRecorder := audit.NewRecorder(repository.NewAuditRepository(config.DB))
+	roleService := services.NewRoleService(roleRepository, permissionRepository, userRepository, auditRecorder)
+	roleController := controllers.NewRoleController(roleService)
+
+	manage := api.Group(
+		"",
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionRolesManage),
+	)
+	manage.GET("/roles", roleController.ListRoles)
+	manage.POST("/roles", roleController.CreateRole)
+	manage.GET("/roles/:id", roleController.GetRole)
+	manage.PUT("/roles/:id", roleController.UpdateRole)
+	manage.DELETE("/roles/:id", roleController.DeleteRole)
+	manage.GET("/permissions", roleController.ListPermissions)
+	manage.POST("/permissions", roleController.CreatePermission)
+	manage.DELETE("/permissions/:id", roleController.DeletePermission)
+	manage.PUT("/user/:id/roles", roleController.AssignRoles)
+}
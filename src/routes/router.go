@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+
+	_ "restApi-GoGin/docs"
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/utils"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// NewRouter builds the router with every API route registered. Requests are
+// logged to log. The repositories behind the routes use config.DB, which must
+// be loaded before the router serves requests, and mail goes through
+// mail.Default, which must be set before the router is built.
+func NewRouter(log *slog.Logger) *gin.Engine {
+	router := gin.New()
+	router.Use(
+		otelgin.Middleware(config.Current().OTEL_SERVICE_NAME, otelgin.WithFilter(traced)),
+		middleware.RequestID(log),
+		middleware.Metrics(),
+		middleware.AccessLog(),
+		middleware.Errors(),
+		middleware.Locale(),
+		middleware.Client(),
+	)
+	router.NoRoute(middleware.NotFound())
+
+	router.Use(cors.New(cors.Config{
+		AllowOrigins:     config.Current().CORSAllowedOrigins(),
+		AllowCredentials: true,
+		// AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
+		// AllowHeaders:     []string{"Authorization", "Content-Type"},
+		// MaxAge:           12 * time.Hour,
+	}))
+
+	JWKSRouter(router)
+	HealthRouter(router)
+	MetricsRouter(router)
+
+	api := router.Group("/api")
+
+	api.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, utils.Response(dto.ResponseParams{
+			StatusCode: http.StatusOK,
+			Message:    "pong",
+		}))
+	})
+
+	AuthRouter(api)
+	UserRouter(api)
+	SessionRouter(api)
+	MFARouter(api)
+	LockoutRouter(api)
+	RoleRouter(api)
+	OutboxRouter(api)
+	AuditRouter(api)
+
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.DefaultModelsExpandDepth(-1)))
+
+	return router
+}
+
+// traced leaves probes and scrapes out of traces, since they would drown out
+// real requests.
+func traced(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/readyz", "/metrics":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SessionRouter(api *gin.RouterGroup) {
+	authRepository := repository.NewAuthRepository(config.DB)
+	userRepository := repository.NewUserRepository(config.DB)
+	sessionRepository := repository.NewSessionRepository(config.DB)
+	sessionService := services.NewSessionService(sessionRepository, userRepository)
+	sessionController := controllers.NewSessionController(sessionService)
+
+	api.GET(
+		"/sessions",
+		middleware.Auth(authRepository, sessionRepository),
+		sessionController.ListSessions,
+	)
+	api.DELETE(
+		"/sessions/others",
+		middleware.Auth(authRepository, sessionRepository),
+		sessionController.RevokeOtherSessions,
+	)
+	api.DELETE(
+		"/sessions/:id",
+		middleware.Auth(authRepository, sessionRepository),
+		sessionController.RevokeSession,
+	)
+	api.DELETE(
+		"/user/:id/sessions",
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionSessionsRevoke),
+		sessionController.RevokeUserSessions,
+	)
+}
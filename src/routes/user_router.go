@@ -1,9 +1,11 @@
 package routes
 
 import (
+	"restApi-GoGin/src/audit"
 	"restApi-GoGin/src/config"
 	"restApi-GoGin/src/controllers"
 	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
 	"restApi-GoGin/src/repository"
 	"restApi-GoGin/src/services"
 
@@ -13,46 +15,68 @@ import (
 func UserRouter(api *gin.RouterGroup) {
 	authRepository := repository.NewAuthRepository(config.DB)
 	userRepository := repository.NewUserRepository(config.DB)
-	userService := services.NewUserService(userRepository)
+	sessionRepository := repository.NewSessionRepository(config.DB)
+	roleRepository := repository.NewRoleRepository(config.DB)
+	auditRecorder := audit.NewRecorder(repository.NewAuditRepository(config.DB))
+	userService := services.NewUserService(userRepository, roleRepository, repository.NewTransactor(config.DB), auditRecorder)
 	userController := controllers.NewUserController(userService)
 
 	api.POST(
 		"/user",
-		middleware.Auth(authRepository),
-		middleware.AuthAccess(authRepository),
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionUsersCreate),
 		userController.CreateUser,
 	)
 	api.GET(
 		"/users",
-		middleware.Auth(authRepository),
-		middleware.AuthAccess(authRepository),
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionUsersRead),
 		userController.GetAllUsers,
 	)
+	api.GET(
+		"/users/deleted",
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionUsersRead),
+		userController.GetDeletedUsers,
+	)
 	api.GET("/user/searchByEmail",
-		middleware.Auth(authRepository),
-		middleware.AuthAccess(authRepository),
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionUsersRead),
 		userController.GetUserByEmail,
 	)
 	api.GET(
 		"/user/:id",
-		middleware.Auth(authRepository),
-		middleware.AuthAccess(authRepository),
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionUsersRead),
 		userController.GetUserByID,
 	)
 	api.PUT(
 		"/user/:id",
-		middleware.Auth(authRepository),
-		middleware.AuthAccess(authRepository),
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionUsersUpdate),
 		userController.UpdateUser,
 	)
 	api.PUT(
 		"/user/profile",
-		middleware.Auth(authRepository),
+		middleware.Auth(authRepository, sessionRepository),
 		userController.UpdateProfile,
 	)
 	api.DELETE(
 		"/user/:id",
-		middleware.Auth(authRepository),
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.VerifiedEmail(),
 		userController.DeleteUser,
 	)
+	api.POST(
+		"/user/:id/restore",
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionUsersDelete),
+		userController.RestoreUser,
+	)
+	api.DELETE(
+		"/user/:id/purge",
+		middleware.Auth(authRepository, sessionRepository),
+		middleware.RequirePermission(models.PermissionUsersPurge),
+		userController.PurgeUser,
+	)
 }
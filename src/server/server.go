@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/health"
+)
+
+// Timeouts configures the HTTP server and its shutdown.
+type Timeouts struct {
+	Read         time.Duration
+	ReadHeader   time.Duration
+	Write        time.Duration
+	Idle         time.Duration
+	DrainDelay   time.Duration
+	GracePeriod  time.Duration
+	CloseTimeout time.Duration
+}
+
+// TimeoutsFromConfig reads the HTTP_* and SHUTDOWN_* settings.
+func TimeoutsFromConfig(cfg *config.Config) Timeouts {
+	return Timeouts{
+		Read:         cfg.HTTP_READ_TIMEOUT,
+		ReadHeader:   cfg.HTTP_READ_HEADER_TIMEOUT,
+		Write:        cfg.HTTP_WRITE_TIMEOUT,
+		Idle:         cfg.HTTP_IDLE_TIMEOUT,
+		DrainDelay:   cfg.SHUTDOWN_DRAIN_DELAY,
+		GracePeriod:  cfg.SHUTDOWN_GRACE_PERIOD,
+		CloseTimeout: cfg.SHUTDOWN_CLOSE_TIMEOUT,
+	}
+}
+
+type closer struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+type server struct {
+	http     *http.Server
+	timeouts Timeouts
+	closers  []closer
+	log      *slog.Logger
+}
+
+func NewServer(addr string, handler http.Handler, timeouts Timeouts, log *slog.Logger) *server {
+	return &server{
+		http: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadTimeout:       timeouts.Read,
+			ReadHeaderTimeout: timeouts.ReadHeader,
+			WriteTimeout:      timeouts.Write,
+			IdleTimeout:       timeouts.Idle,
+			ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelWarn),
+		},
+		timeouts: timeouts,
+		log:      log,
+	}
+}
+
+// OnShutdown registers fn to run once in-flight requests have drained.
+// Closers run in reverse order of registration, so something registered
+// early, like the database pool, outlives the workers that use it.
+func (s *server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.closers = append(s.closers, closer{name: name, fn: fn})
+}
+
+// ListenAndServe listens on the server's address and serves until ctx is
+// done, then shuts down gracefully.
+func (s *server) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on listener until ctx is done. Shutdown then happens in steps:
+// readiness starts failing, the server keeps accepting for DrainDelay so
+// load balancers notice, stops accepting and waits up to GracePeriod for
+// in-flight requests, and finally runs the OnShutdown closers, which share
+// their own CloseTimeout so a grace period used up by slow requests still
+// leaves them time to flush.
+func (s *server) Serve(ctx context.Context, listener net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.http.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.log.Info("shutting down", "drain_delay", s.timeouts.DrainDelay, "grace_period", s.timeouts.GracePeriod)
+	health.SetDraining()
+	time.Sleep(s.timeouts.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.timeouts.GracePeriod)
+	defer cancel()
+
+	var errs []error
+	if err := s.http.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("draining requests: %w", err))
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), s.timeouts.CloseTimeout)
+	defer cancelClose()
+
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		closer := s.closers[i]
+		if err := closer.fn(closeCtx); err != nil {
+			errs = append(errs, fmt.Errorf("closing %s: %w", closer.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAuditPerPage = 20
+	maxAuditPerPage     = 100
+)
+
+// auditCSVHeader names the columns of the CSV export.
+var auditCSVHeader = []string{
+	"id", "created_at", "action", "actor_id", "actor", "target_type", "target_id",
+	"changes", "reason", "ip", "user_agent", "request_id", "prev_hash", "hash",
+}
+
+type AuditService interface {
+	ListEntries(ctx context.Context, query *dto.AuditListQuery) ([]models.AuditLog, *dto.Paginate, error)
+	ExportEntries(ctx context.Context, query *dto.AuditListQuery) (func(w io.Writer) error, error)
+	Verify(ctx context.Context) (*dto.AuditVerifyResponse, error)
+}
+
+type auditService struct {
+	auditRepository repository.AuditRepository
+}
+
+func NewAuditService(auditRepository repository.AuditRepository) *auditService {
+	return &auditService{auditRepository: auditRepository}
+}
+
+func (s *auditService) ListEntries(ctx context.Context, query *dto.AuditListQuery) (_ []models.AuditLog, _ *dto.Paginate, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.ListEntries")
+	defer tracing.End(span, &err)
+
+	page := max(query.Page, 1)
+	perPage := query.PerPage
+	if perPage <= 0 {
+		perPage = defaultAuditPerPage
+	}
+	perPage = min(perPage, maxAuditPerPage)
+
+	filter, err := auditFilter(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter.Offset = (page - 1) * perPage
+	filter.Limit = perPage
+
+	entries, total, err := s.auditRepository.List(ctx, filter)
+	if err != nil {
+		return nil, nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	paginate := &dto.Paginate{
+		Page:      page,
+		PerPage:   perPage,
+		Total:     int(total),
+		TotalPage: int((total + int64(perPage) - 1) / int64(perPage)),
+	}
+	return entries, paginate, nil
+}
+
+// ExportEntries checks query and returns a function writing every matching
+// entry to w as CSV, oldest first. Nothing is buffered, so the export can be
+// streamed however large it is.
+func (s *auditService) ExportEntries(ctx context.Context, query *dto.AuditListQuery) (func(w io.Writer) error, error) {
+	filter, err := auditFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(w io.Writer) (err error) {
+		ctx, span := tracing.Start(ctx, "AuditService.ExportEntries")
+		defer tracing.End(span, &err)
+
+		out := csv.NewWriter(w)
+		if err := out.Write(auditCSVHeader); err != nil {
+			return err
+		}
+		err = s.auditRepository.Each(ctx, filter, func(entry *models.AuditLog) error {
+			actorId := ""
+			if entry.ActorId != nil {
+				actorId = strconv.Itoa(*entry.ActorId)
+			}
+			return out.Write([]string{
+				strconv.FormatInt(entry.Id, 10),
+				entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+				entry.Action,
+				actorId,
+				csvSafe(entry.Actor),
+				entry.TargetType,
+				csvSafe(entry.TargetId),
+				csvSafe(entry.Changes),
+				csvSafe(entry.Reason),
+				csvSafe(entry.IP),
+				csvSafe(entry.UserAgent),
+				csvSafe(entry.RequestId),
+				entry.PrevHash,
+				entry.Hash,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		out.Flush()
+		return out.Error()
+	}, nil
+}
+
+func (s *auditService) Verify(ctx context.Context) (_ *dto.AuditVerifyResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.Verify")
+	defer tracing.End(span, &err)
+
+	result, err := audit.Verify(ctx, s.auditRepository)
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return &dto.AuditVerifyResponse{
+		Valid:    result.Valid(),
+		Entries:  result.Entries,
+		HeadId:   result.HeadId,
+		HeadHash: result.HeadHash,
+		BrokenAt: result.BrokenAt,
+		Problem:  result.Problem,
+	}, nil
+}
+
+func auditFilter(query *dto.AuditListQuery) (repository.AuditFilter, error) {
+	filter := repository.AuditFilter{
+		Action:     strings.TrimSpace(query.Action),
+		TargetType: query.TargetType,
+		TargetId:   strings.TrimSpace(query.TargetId),
+		RequestId:  strings.TrimSpace(query.RequestId),
+	}
+	if query.ActorId > 0 {
+		filter.ActorId = &query.ActorId
+	}
+
+	if query.From != "" {
+		from, err := time.ParseInLocation(time.DateOnly, query.From, time.Local)
+		if err != nil {
+			return filter, &errorhandler.BadRequestError{Message: "invalid from"}
+		}
+		filter.From = &from
+	}
+	if query.Until != "" {
+		until, err := time.ParseInLocation(time.DateOnly, query.Until, time.Local)
+		if err != nil {
+			return filter, &errorhandler.BadRequestError{Message: "invalid until"}
+		}
+		// until is inclusive, so stop at the start of the next day.
+		until = until.AddDate(0, 0, 1)
+		filter.Until = &until
+	}
+	return filter, nil
+}
+
+// csvSafe keeps a spreadsheet from running a value as a formula. Values
+// such as the user agent or the email of a failed login come from clients.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// userSnapshot is the state of user recorded in the audit log.
+func userSnapshot(user *models.User) audit.Snapshot {
+	roles := user.RoleNames()
+	slices.Sort(roles)
+	return audit.Snapshot{
+		"name":           user.Name,
+		"email":          user.Email,
+		"email_verified": user.EmailVerifiedAt != nil,
+		"roles":          roles,
+		"password":       user.Password,
+	}
+}
+
+// roleSnapshot is the state of role recorded in the audit log.
+func roleSnapshot(role *models.Role) audit.Snapshot {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+	slices.Sort(permissions)
+	return audit.Snapshot{
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": permissions,
+	}
+}
+
+// permissionSnapshot is the state of permission recorded in the audit log.
+func permissionSnapshot(permission *models.Permission) audit.Snapshot {
+	return audit.Snapshot{
+		"name":        permission.Name,
+		"description": permission.Description,
+	}
+}
@@ -1,39 +1,81 @@
 package services
 
 import (
+	"context"
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/config"
 	"restApi-GoGin/src/dto"
 	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/mail"
+	"restApi-GoGin/src/metrics"
 	"restApi-GoGin/src/models"
 	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
 	"restApi-GoGin/src/utils"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	verificationResendInterval = time.Minute
+)
+
+// The flows that start from an email address answer the same for every
+// address, so they cannot be used to find out which ones have an account.
+var (
+	errInvalidVerificationCode = &errorhandler.BadRequestError{Message: "invalid or expired verification code"}
+	errInvalidOTP              = &errorhandler.BadRequestError{Message: "invalid or expired otp"}
+	errInvalidResetToken       = &errorhandler.BadRequestError{Message: "invalid or expired reset token"}
+)
+
 type AuthService interface {
-	Register(req *dto.RegisterRequest) error
-	Login(req *dto.LoginRequest) (*dto.LoginResponse, string, string, error)
-	RefreshToken(refreshToken string) (string, error)
-	ForgotPassword(req *dto.ForgotPasswordRequest) error
-	VerifyOTP(req *dto.VerifyOTPRequest) (*dto.VerifyOTPResponse, error)
-	ResetPassword(req *dto.ResetPasswordRequest) error
+	Register(ctx context.Context, req *dto.RegisterRequest) error
+	VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) error
+	ResendVerification(ctx context.Context, req *dto.ResendVerificationRequest) error
+	Login(ctx context.Context, req *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error)
+	LoginMFA(ctx context.Context, req *dto.LoginMFARequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error)
+	RefreshToken(ctx context.Context, refreshToken string, client *dto.ClientInfo) (string, string, error)
+	Logout(ctx context.Context, refreshToken string) error
+	ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) error
+	VerifyOTP(ctx context.Context, req *dto.VerifyOTPRequest, client *dto.ClientInfo) (*dto.VerifyOTPResponse, error)
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest, client *dto.ClientInfo) error
 }
 
 type authService struct {
-	authRepository repository.AuthRepository
-	userRepository repository.UserRepository
+	authRepository         repository.AuthRepository
+	userRepository         repository.UserRepository
+	refreshTokenRepository repository.RefreshTokenRepository
+	sessionRepository      repository.SessionRepository
+	recoveryCodeRepository repository.RecoveryCodeRepository
+	lockoutService         LockoutService
+	roleRepository         repository.RoleRepository
+	mailer                 mail.Sender
+	transactor             repository.Transactor
+	auditor                audit.Recorder
 }
 
-func NewAuthService(authRepository repository.AuthRepository, userRepository repository.UserRepository) *authService {
+func NewAuthService(authRepository repository.AuthRepository, userRepository repository.UserRepository, refreshTokenRepository repository.RefreshTokenRepository, sessionRepository repository.SessionRepository, recoveryCodeRepository repository.RecoveryCodeRepository, lockoutService LockoutService, roleRepository repository.RoleRepository, mailer mail.Sender, transactor repository.Transactor, auditor audit.Recorder) *authService {
 	return &authService{
-		authRepository: authRepository,
-		userRepository: userRepository,
+		authRepository:         authRepository,
+		userRepository:         userRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		sessionRepository:      sessionRepository,
+		recoveryCodeRepository: recoveryCodeRepository,
+		lockoutService:         lockoutService,
+		roleRepository:         roleRepository,
+		mailer:                 mailer,
+		transactor:             transactor,
+		auditor:                auditor,
 	}
 }
 
-func (s *authService) Register(req *dto.RegisterRequest) error {
-	if emailExist := s.authRepository.EmailExists(req.Email); emailExist {
+func (s *authService) Register(ctx context.Context, req *dto.RegisterRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	defer tracing.End(span, &err)
+
+	if emailExist := s.authRepository.EmailExists(ctx, req.Email); emailExist {
 		return &errorhandler.BadRequestError{Message: "email already exists"}
 	}
 
@@ -46,116 +88,494 @@ func (s *authService) Register(req *dto.RegisterRequest) error {
 		return &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
+	roles, err := s.roleRepository.GetByNames([]string{models.RoleUser})
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
 	user := models.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: passwordHash,
-		Role:     "user",
+		Roles:    roles,
+	}
+
+	code, err := prepareVerificationCode(&user)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if err := s.authRepository.Register(ctx, &user); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	// The account already exists at this point, so a mail failure is not
+	// reported to the client; the code can be requested again.
+	if err := s.sendVerificationCode(ctx, &user, code); err != nil {
+		logger.FromContext(ctx).Warn("sending verification email failed", "user_id", user.Id, "error", err)
+	}
+
+	return nil
+}
+
+func (s *authService) VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.VerifyEmail")
+	defer tracing.End(span, &err)
+	defer func() { metrics.OTPVerification(metrics.PurposeEmailVerification, err) }()
+
+	user, err := s.userRepository.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if user == nil || user.EmailVerifiedAt != nil || user.EmailVerifyCode == nil || user.EmailVerifyCodeExp == nil ||
+		time.Now().After(*user.EmailVerifyCodeExp) {
+		return errInvalidVerificationCode
 	}
 
-	if err := s.authRepository.Register(&user); err != nil {
+	if err := s.useCodeAttempt(ctx, user.Id, repository.CodeEmailVerify, errInvalidVerificationCode); err != nil {
+		return err
+	}
+
+	if err := utils.CompareBcrypt(*user.EmailVerifyCode, req.OTP); err != nil {
+		// Too many wrong guesses burn the code so it cannot be brute forced.
+		if user.EmailVerifyAttempts+1 >= config.Current().OTP_MAX_ATTEMPTS {
+			if err := s.userRepository.ClearCode(ctx, user.Id, repository.CodeEmailVerify); err != nil {
+				return &errorhandler.InternalServerError{Message: err.Error()}
+			}
+		}
+
+		return errInvalidVerificationCode
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	user.EmailVerifyCode = nil
+	user.EmailVerifyCodeExp = nil
+	user.EmailVerifySentAt = nil
+	user.EmailVerifyAttempts = 0
+
+	if err := s.userRepository.UpdateUser(ctx, user); err != nil {
 		return &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
 	return nil
 }
 
-func (s *authService) Login(req *dto.LoginRequest) (*dto.LoginResponse, string, string, error) {
-	var data dto.LoginResponse
+func (s *authService) ResendVerification(ctx context.Context, req *dto.ResendVerificationRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ResendVerification")
+	defer tracing.End(span, &err)
 
-	user, err := s.userRepository.GetUserByEmail(req.Email)
+	user, err := s.userRepository.GetUserByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, "", "", &errorhandler.NotFoundError{Message: "invalid email or password"}
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	// Unknown, verified and recently sent to addresses get the same answer
+	// as the others, without an email.
+	if user == nil || user.EmailVerifiedAt != nil ||
+		user.EmailVerifySentAt != nil && time.Since(*user.EmailVerifySentAt) < verificationResendInterval {
+		return nil
+	}
+
+	code, err := prepareVerificationCode(user)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	// The code is only stored if its email is queued, and the other way
+	// around.
+	err = s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepository.UpdateUser(ctx, user); err != nil {
+			return err
+		}
+		return s.sendVerificationCode(ctx, user, code)
+	})
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (s *authService) Login(ctx context.Context, req *dto.LoginRequest, client *dto.ClientInfo) (response *dto.LoginResponse, accessToken string, refreshToken string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer tracing.End(span, &err)
+	defer func() { metrics.Login("password", response != nil && response.MFARequired, err) }()
+
+	keys := lockoutKeys(req.Email, client)
+	if err := s.lockoutService.Check(ctx, keys...); err != nil {
+		if _, ok := err.(*errorhandler.LockedError); ok {
+			s.recordLoginFailure(ctx, req.Email, nil, "locked out")
+		}
+		return nil, "", "", err
+	}
+
+	user, err := s.userRepository.GetUserByEmail(ctx, req.Email)
+	if err != nil || user == nil {
+		s.recordLoginFailure(ctx, req.Email, nil, "unknown email")
+		return nil, "", "", s.failAttempt(ctx, keys, &errorhandler.NotFoundError{Message: "invalid email or password"})
 	}
 
 	if err := utils.CompareBcrypt(user.Password, req.Password); err != nil {
-		return nil, "", "", &errorhandler.NotFoundError{Message: "invalid email or password"}
+		s.recordLoginFailure(ctx, req.Email, user, "wrong password")
+		return nil, "", "", s.failAttempt(ctx, keys, &errorhandler.NotFoundError{Message: "invalid email or password"})
+	}
+
+	if err := s.lockoutService.Reset(ctx, AccountLockoutKey(req.Email)); err != nil {
+		return nil, "", "", err
+	}
+
+	if user.EmailVerifiedAt == nil && config.EmailVerificationPolicy() == config.EmailVerificationLogin {
+		s.recordLoginFailure(ctx, req.Email, user, "email not verified")
+		return nil, "", "", &errorhandler.ForbiddenError{Message: "email not verified"}
 	}
 
-	accessToken, err := utils.GenerateAccessToken(user)
+	if user.MFAEnabled {
+		mfaToken, err := utils.GenerateMFAToken(user)
+		if err != nil {
+			return nil, "", "", &errorhandler.InternalServerError{Message: err.Error()}
+		}
+
+		return &dto.LoginResponse{MFARequired: true, MFAToken: mfaToken}, "", "", nil
+	}
+
+	return s.startSession(ctx, user, client)
+}
+
+// LoginMFA completes a login that was paused by an MFA challenge.
+func (s *authService) LoginMFA(ctx context.Context, req *dto.LoginMFARequest, client *dto.ClientInfo) (response *dto.LoginResponse, accessToken string, refreshToken string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.LoginMFA")
+	defer tracing.End(span, &err)
+	defer func() { metrics.Login("mfa", false, err) }()
+
+	claims, err := utils.VerifyMFAToken(req.MFAToken)
 	if err != nil {
+		return nil, "", "", &errorhandler.UnauthorizedError{Message: "invalid or expired MFA challenge"}
+	}
+
+	user, err := s.userRepository.GetUserByID(ctx, claims.UserId)
+	if err != nil || user == nil {
+		return nil, "", "", &errorhandler.UnauthorizedError{Message: "invalid or expired MFA challenge"}
+	}
+
+	if !user.MFAEnabled {
+		return nil, "", "", &errorhandler.BadRequestError{Message: "MFA is not enabled"}
+	}
+
+	keys := lockoutKeys(user.Email, client)
+	if err := s.lockoutService.Check(ctx, keys...); err != nil {
+		if _, ok := err.(*errorhandler.LockedError); ok {
+			s.recordLoginFailure(ctx, user.Email, user, "locked out")
+		}
+		return nil, "", "", err
+	}
+
+	if err := verifySecondFactor(ctx, user, req.Code, s.userRepository, s.recoveryCodeRepository); err != nil {
+		if _, ok := err.(*errorhandler.UnauthorizedError); ok {
+			s.recordLoginFailure(ctx, user.Email, user, "invalid second factor")
+			return nil, "", "", s.failAttempt(ctx, keys, err)
+		}
+		return nil, "", "", err
+	}
+
+	if err := s.lockoutService.Reset(ctx, AccountLockoutKey(user.Email)); err != nil {
+		return nil, "", "", err
+	}
+
+	return s.startSession(ctx, user, client)
+}
+
+// recordLoginFailure audits a failed login as email, the account tried.
+// user is nil when no account has that email.
+func (s *authService) recordLoginFailure(ctx context.Context, email string, user *models.User, reason string) {
+	event := audit.Event{
+		Action: audit.ActionLoginFailed,
+		Actor:  &audit.Actor{Name: email},
+		Reason: reason,
+	}
+	if user != nil {
+		event.TargetType = audit.TargetUser
+		event.TargetId = user.Id
+	}
+	s.auditor.Record(ctx, event)
+}
+
+// startSession creates a session for an authenticated user and issues its
+// first access and refresh tokens.
+func (s *authService) startSession(ctx context.Context, user *models.User, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
+	session := models.Session{
+		Id:         uuid.New().String(),
+		UserId:     user.Id,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(config.Current().REFRESH_TOKEN_TTL),
+	}
+
+	if client != nil {
+		session.UserAgent = client.UserAgent
+		session.IP = client.IP
+	}
+
+	if err := s.sessionRepository.Create(ctx, &session); err != nil {
 		return nil, "", "", &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
-	refressToken, err := utils.GenerateRefreshToken(user)
+	accessToken, err := utils.GenerateAccessToken(user, session.Id)
 	if err != nil {
 		return nil, "", "", &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
-	data = dto.LoginResponse{
+	refreshToken, _, err := s.issueRefreshToken(ctx, user, session.Id, client)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionLogin,
+		Actor:      audit.UserActor(user),
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+	})
+
+	data := dto.LoginResponse{
 		ID:    user.Id,
 		Name:  user.Name,
 		Email: user.Email,
-		Role:  user.Role,
+		Roles: user.RoleNames(),
 	}
 
-	return &data, accessToken, refressToken, nil
+	return &data, accessToken, refreshToken, nil
 }
 
-func (s *authService) RefreshToken(refreshToken string) (string, error) {
+// RefreshToken rotates a refresh token: the presented token is revoked and a
+// new one from the same family is issued. Presenting a token that was already
+// rotated is treated as theft and revokes the whole session.
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string, client *dto.ClientInfo) (_ string, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RefreshToken")
+	defer tracing.End(span, &err)
+	defer func() { metrics.TokenRefresh(err) }()
+
 	claims, err := utils.VerifyRefreshToken(refreshToken)
 	if err != nil {
-		return "", &errorhandler.UnauthorizedError{Message: err.Error()}
+		return "", "", &errorhandler.UnauthorizedError{Message: err.Error()}
 	}
 
-	user, err := s.authRepository.GetUserById(claims.UserId)
+	stored, err := s.refreshTokenRepository.GetByJtiHash(ctx, utils.HashToken(claims.ID))
 	if err != nil {
-		return "", &errorhandler.UnauthorizedError{Message: err.Error()}
+		return "", "", &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if stored == nil || stored.UserId != claims.UserId {
+		return "", "", &errorhandler.UnauthorizedError{Message: "invalid refresh token"}
+	}
+
+	if stored.RevokedAt != nil {
+		metrics.RefreshTokenReuse()
+		if err := s.sessionRepository.Revoke(ctx, stored.FamilyId); err != nil {
+			return "", "", &errorhandler.InternalServerError{Message: err.Error()}
+		}
+		return "", "", &errorhandler.UnauthorizedError{Message: "refresh token reuse detected"}
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return "", "", &errorhandler.UnauthorizedError{Message: "refresh token expired"}
 	}
 
-	newAccessToken, err := utils.GenerateAccessToken(user)
+	session, err := s.sessionRepository.GetById(ctx, stored.FamilyId)
 	if err != nil {
-		return "", &errorhandler.InternalServerError{Message: err.Error()}
+		return "", "", &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
-	return newAccessToken, nil
+	if session == nil || session.RevokedAt != nil {
+		return "", "", &errorhandler.UnauthorizedError{Message: "session has been revoked"}
+	}
+
+	revoked, err := s.refreshTokenRepository.Revoke(ctx, stored.Id)
+	if err != nil {
+		return "", "", &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	// Another request rotated this token first, so it is being replayed.
+	if !revoked {
+		metrics.RefreshTokenReuse()
+		if err := s.sessionRepository.Revoke(ctx, stored.FamilyId); err != nil {
+			return "", "", &errorhandler.InternalServerError{Message: err.Error()}
+		}
+		return "", "", &errorhandler.UnauthorizedError{Message: "refresh token reuse detected"}
+	}
+
+	user, err := s.userRepository.GetUserByID(ctx, claims.UserId)
+	if err != nil {
+		return "", "", &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if user == nil {
+		return "", "", &errorhandler.UnauthorizedError{Message: "user not found"}
+	}
+
+	newAccessToken, err := utils.GenerateAccessToken(user, session.Id)
+	if err != nil {
+		return "", "", &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	newRefreshToken, newClaims, err := s.issueRefreshToken(ctx, user, session.Id, client)
+	if err != nil {
+		return "", "", err
+	}
+
+	ip := session.IP
+	if client != nil {
+		ip = client.IP
+	}
+
+	refreshed, err := s.sessionRepository.Refresh(ctx, session.Id, newClaims.ExpiresAt.Time, ip)
+	if err != nil {
+		return "", "", &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	// The session was revoked while the tokens were issued, so the new
+	// refresh token is revoked with it.
+	if !refreshed {
+		if err := s.sessionRepository.Revoke(ctx, session.Id); err != nil {
+			return "", "", &errorhandler.InternalServerError{Message: err.Error()}
+		}
+		return "", "", &errorhandler.UnauthorizedError{Message: "session has been revoked"}
+	}
+
+	return newAccessToken, newRefreshToken, nil
 }
 
-func (s *authService) ForgotPassword(req *dto.ForgotPasswordRequest) error {
-	user, err := s.userRepository.GetUserByEmail(req.Email)
-	if err != nil || user == nil {
-		return &errorhandler.NotFoundError{Message: "user not found"}
+// Logout revokes the session of the given refresh token. Unknown or invalid
+// tokens are ignored so logging out is always possible.
+func (s *authService) Logout(ctx context.Context, refreshToken string) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Logout")
+	defer tracing.End(span, &err)
+
+	claims, err := utils.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil
+	}
+
+	stored, err := s.refreshTokenRepository.GetByJtiHash(ctx, utils.HashToken(claims.ID))
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if stored == nil {
+		return nil
 	}
 
+	if err := s.sessionRepository.Revoke(ctx, stored.FamilyId); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (s *authService) issueRefreshToken(ctx context.Context, user *models.User, familyId string, client *dto.ClientInfo) (string, *utils.JWTRefreshClaims, error) {
+	refreshToken, claims, err := utils.GenerateRefreshToken(user, familyId)
+	if err != nil {
+		return "", nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	record := models.RefreshToken{
+		UserId:    user.Id,
+		FamilyId:  familyId,
+		JtiHash:   utils.HashToken(claims.ID),
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+
+	if client != nil {
+		record.UserAgent = client.UserAgent
+		record.IP = client.IP
+	}
+
+	if err := s.refreshTokenRepository.Create(ctx, &record); err != nil {
+		return "", nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return refreshToken, claims, nil
+}
+
+func (s *authService) ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ForgotPassword")
+	defer tracing.End(span, &err)
+
+	// The OTP is hashed before the lookup so unknown addresses take as long
+	// to answer as known ones.
 	otp := utils.GenerateOTP()
 	hashedOTP, err := utils.HashBcrypt(otp)
 	if err != nil {
 		return &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
-	exp := time.Now().Add(time.Minute * 10)
-	user.OTPCode = &hashedOTP
-	user.OTPCodeExp = &exp
-
-	err = s.userRepository.UpdateUser(user)
+	user, err := s.userRepository.GetUserByEmail(ctx, req.Email)
 	if err != nil {
 		return &errorhandler.InternalServerError{Message: err.Error()}
 	}
+	if user == nil {
+		return nil
+	}
 
-	err = utils.SendEmail(user.Email, "OTP Reset Password", otp)
+	ttl := config.Current().OTP_TTL
+	exp := time.Now().Add(ttl)
+	user.OTPCode = &hashedOTP
+	user.OTPCodeExp = &exp
+	user.OTPAttempts = 0
+
+	err = s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepository.UpdateUser(ctx, user); err != nil {
+			return err
+		}
+		return s.mailer.SendTemplate(ctx, user.Email, mail.TemplatePasswordReset, mail.OTPData{Name: user.Name, Code: otp, ExpiresIn: ttl})
+	})
 	if err != nil {
 		return &errorhandler.InternalServerError{Message: err.Error()}
 	}
+	metrics.OTPSent(metrics.PurposePasswordReset)
+
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionPasswordResetRequest,
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+	})
 
 	return nil
 }
 
-func (s *authService) VerifyOTP(req *dto.VerifyOTPRequest) (*dto.VerifyOTPResponse, error) {
-	user, err := s.userRepository.GetUserByEmail(req.Email)
-	if err != nil || user == nil {
-		return nil, &errorhandler.NotFoundError{Message: "user not found"}
+func (s *authService) VerifyOTP(ctx context.Context, req *dto.VerifyOTPRequest, client *dto.ClientInfo) (_ *dto.VerifyOTPResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.VerifyOTP")
+	defer tracing.End(span, &err)
+	defer func() { metrics.OTPVerification(metrics.PurposePasswordReset, err) }()
+
+	keys := lockoutKeys(req.Email, client)
+	if err := s.lockoutService.Check(ctx, keys...); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepository.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
-	if user.OTPCode == nil || user.OTPCodeExp == nil {
-		return nil, &errorhandler.BadRequestError{Message: "no OTP request found"}
+	if user == nil || user.OTPCode == nil || user.OTPCodeExp == nil || time.Now().After(*user.OTPCodeExp) {
+		return nil, s.failAttempt(ctx, keys, errInvalidOTP)
 	}
 
-	if time.Now().After(*user.OTPCodeExp) {
-		return nil, &errorhandler.BadRequestError{Message: "OTP expired"}
+	if err := s.useCodeAttempt(ctx, user.Id, repository.CodeOTP, errInvalidOTP); err != nil {
+		return nil, s.failAttempt(ctx, keys, err)
 	}
 
 	if err := utils.CompareBcrypt(*user.OTPCode, req.OTP); err != nil {
-		return nil, &errorhandler.BadRequestError{Message: "invalid otp"}
+		// Too many wrong guesses burn the OTP so it cannot be brute forced.
+		if user.OTPAttempts+1 >= config.Current().OTP_MAX_ATTEMPTS {
+			if err := s.userRepository.ClearCode(ctx, user.Id, repository.CodeOTP); err != nil {
+				return nil, &errorhandler.InternalServerError{Message: err.Error()}
+			}
+		}
+
+		return nil, s.failAttempt(ctx, keys, errInvalidOTP)
 	}
 
 	rawResetToken := uuid.New().String()
@@ -167,13 +587,14 @@ func (s *authService) VerifyOTP(req *dto.VerifyOTPRequest) (*dto.VerifyOTPRespon
 	hashedResetToken := string(hashedResetTokenBytes)
 
 	user.ResetToken = &hashedResetToken
-	exp := time.Now().Add(time.Minute * 10)
+	exp := time.Now().Add(config.Current().RESET_TOKEN_TTL)
 	user.ResetTokenExp = &exp
 
 	user.OTPCode = nil
 	user.OTPCodeExp = nil
+	user.OTPAttempts = 0
 
-	if err := s.userRepository.UpdateUser(user); err != nil {
+	if err := s.userRepository.UpdateUser(ctx, user); err != nil {
 		return nil, &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
@@ -182,22 +603,26 @@ func (s *authService) VerifyOTP(req *dto.VerifyOTPRequest) (*dto.VerifyOTPRespon
 	}, nil
 }
 
-func (s *authService) ResetPassword(req *dto.ResetPasswordRequest) error {
-	user, err := s.userRepository.GetUserByEmail(req.Email)
-	if err != nil || user == nil {
-		return &errorhandler.NotFoundError{Message: "user not found"}
+func (s *authService) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest, client *dto.ClientInfo) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ResetPassword")
+	defer tracing.End(span, &err)
+
+	keys := lockoutKeys(req.Email, client)
+	if err := s.lockoutService.Check(ctx, keys...); err != nil {
+		return err
 	}
 
-	if user.ResetToken == nil || user.ResetTokenExp == nil {
-		return &errorhandler.BadRequestError{Message: "no reset token found"}
+	user, err := s.userRepository.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
-	if time.Now().After(*user.ResetTokenExp) {
-		return &errorhandler.BadRequestError{Message: "reset token expired"}
+	if user == nil || user.ResetToken == nil || user.ResetTokenExp == nil || time.Now().After(*user.ResetTokenExp) {
+		return s.failAttempt(ctx, keys, errInvalidResetToken)
 	}
 
 	if err := utils.CompareBcrypt(*user.ResetToken, req.ResetToken); err != nil {
-		return &errorhandler.BadRequestError{Message: "invalid reset token"}
+		return s.failAttempt(ctx, keys, errInvalidResetToken)
 	}
 
 	if req.Password != req.PasswordConfirm {
@@ -209,13 +634,97 @@ func (s *authService) ResetPassword(req *dto.ResetPasswordRequest) error {
 		return &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
+	before := userSnapshot(user)
 	user.Password = passwordHash
 	user.ResetToken = nil
 	user.ResetTokenExp = nil
 
-	if err := s.userRepository.UpdateUser(user); err != nil {
+	if err := s.userRepository.UpdateUser(ctx, user); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	// A password reset signs the user out of every existing session.
+	if err := s.sessionRepository.RevokeAllByUser(ctx, user.Id, ""); err != nil {
 		return &errorhandler.InternalServerError{Message: err.Error()}
 	}
 
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionPasswordReset,
+		Actor:      audit.UserActor(user),
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+		Changes:    audit.Diff(before, userSnapshot(user)),
+	})
+
 	return nil
 }
+
+// prepareVerificationCode stores a hashed verification code on the user and
+// returns the plain code to mail. The caller persists the user.
+func prepareVerificationCode(user *models.User) (string, error) {
+	code := utils.GenerateOTP()
+	hashedCode, err := utils.HashBcrypt(code)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	exp := now.Add(config.Current().OTP_TTL)
+	user.EmailVerifyCode = &hashedCode
+	user.EmailVerifyCodeExp = &exp
+	user.EmailVerifySentAt = &now
+	user.EmailVerifyAttempts = 0
+
+	return code, nil
+}
+
+// sendVerificationCode mails the code stored by prepareVerificationCode,
+// stating the lifetime it was given.
+func (s *authService) sendVerificationCode(ctx context.Context, user *models.User, code string) error {
+	data := mail.OTPData{Name: user.Name, Code: code, ExpiresIn: user.EmailVerifyCodeExp.Sub(*user.EmailVerifySentAt)}
+	if err := s.mailer.SendTemplate(ctx, user.Email, mail.TemplateEmailVerification, data); err != nil {
+		return err
+	}
+
+	metrics.OTPSent(metrics.PurposeEmailVerification)
+	return nil
+}
+
+// useCodeAttempt counts an attempt at the user's one-time code before it is
+// compared. Once OTP_MAX_ATTEMPTS attempts were made the code is burnt and
+// tooMany returned. Counting in the database bounds guesses made in parallel,
+// which all load the same counter; the counter loaded with the user is only
+// used to tell when the last attempt failed.
+func (s *authService) useCodeAttempt(ctx context.Context, userId int, code repository.OneTimeCode, tooMany error) error {
+	counted, err := s.userRepository.AddCodeAttempt(ctx, userId, code, config.Current().OTP_MAX_ATTEMPTS)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+	if counted {
+		return nil
+	}
+
+	if err := s.userRepository.ClearCode(ctx, userId, code); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+	return tooMany
+}
+
+// failAttempt records a failed attempt against the lockout keys and returns
+// err, or an internal error if the attempt could not be recorded.
+func (s *authService) failAttempt(ctx context.Context, keys []string, err error) error {
+	if lockErr := s.lockoutService.RegisterFailure(ctx, keys...); lockErr != nil {
+		return lockErr
+	}
+
+	return err
+}
+
+func lockoutKeys(email string, client *dto.ClientInfo) []string {
+	keys := []string{AccountLockoutKey(email)}
+	if client != nil && client.IP != "" {
+		keys = append(keys, IPLockoutKey(client.IP))
+	}
+
+	return keys
+}
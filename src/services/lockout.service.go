@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"math"
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/metrics"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const ipLockoutPrefix = "ip:"
+
+// AccountLockoutKey returns the throttle key shared by every authentication
+// attempt against one email address, whether or not the account exists.
+func AccountLockoutKey(email string) string {
+	return repository.AccountThrottleKey(email)
+}
+
+// IPLockoutKey returns the throttle key for attempts from one client IP.
+func IPLockoutKey(ip string) string {
+	return ipLockoutPrefix + ip
+}
+
+type LockoutService interface {
+	Check(ctx context.Context, keys ...string) error
+	RegisterFailure(ctx context.Context, keys ...string) error
+	Reset(ctx context.Context, keys ...string) error
+	UnlockUser(ctx context.Context, userId int) error
+}
+
+type lockoutService struct {
+	authThrottleRepository repository.AuthThrottleRepository
+	userRepository         repository.UserRepository
+}
+
+func NewLockoutService(authThrottleRepository repository.AuthThrottleRepository, userRepository repository.UserRepository) *lockoutService {
+	return &lockoutService{
+		authThrottleRepository: authThrottleRepository,
+		userRepository:         userRepository,
+	}
+}
+
+// Check returns a LockedError if any of the keys is currently locked.
+func (s *lockoutService) Check(ctx context.Context, keys ...string) (err error) {
+	ctx, span := tracing.Start(ctx, "LockoutService.Check")
+	defer tracing.End(span, &err)
+
+	throttles, err := s.authThrottleRepository.GetByKeys(ctx, keys)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	var lockedUntil time.Time
+	for _, throttle := range throttles {
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(lockedUntil) {
+			lockedUntil = *throttle.LockedUntil
+		}
+	}
+
+	if remaining := time.Until(lockedUntil); remaining > 0 {
+		return &errorhandler.LockedError{
+			Message:    "too many failed attempts, please try again later",
+			RetryAfter: int(math.Ceil(remaining.Seconds())),
+		}
+	}
+
+	return nil
+}
+
+// RegisterFailure records a failed attempt for every key. Once a key reaches
+// its threshold it is locked, and each further failure doubles the lock
+// duration up to LOCKOUT_MAX_DURATION. The database counts the failures, so
+// concurrent attempts cannot slip past the threshold.
+func (s *lockoutService) RegisterFailure(ctx context.Context, keys ...string) (err error) {
+	ctx, span := tracing.Start(ctx, "LockoutService.RegisterFailure")
+	defer tracing.End(span, &err)
+
+	cfg := config.Current()
+	now := time.Now()
+	for _, key := range keys {
+		throttle, err := s.authThrottleRepository.RegisterFailure(ctx, key, now, now.Add(-cfg.LOCKOUT_WINDOW))
+		if err != nil {
+			return &errorhandler.InternalServerError{Message: err.Error()}
+		}
+
+		threshold, scope := cfg.LOCKOUT_THRESHOLD, metrics.ScopeAccount
+		if strings.HasPrefix(key, ipLockoutPrefix) {
+			threshold, scope = cfg.LOCKOUT_IP_THRESHOLD, metrics.ScopeIP
+		}
+		if throttle.Failures < threshold {
+			continue
+		}
+
+		until := now.Add(lockoutDuration(throttle.Failures - threshold))
+		started, err := s.authThrottleRepository.Lock(ctx, key, now, until)
+		if err != nil {
+			return &errorhandler.InternalServerError{Message: err.Error()}
+		}
+		if started {
+			metrics.Lockout(scope)
+		}
+	}
+
+	return nil
+}
+
+func (s *lockoutService) Reset(ctx context.Context, keys ...string) (err error) {
+	ctx, span := tracing.Start(ctx, "LockoutService.Reset")
+	defer tracing.End(span, &err)
+
+	if err := s.authThrottleRepository.DeleteByKeys(ctx, keys); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return nil
+}
+
+// UnlockUser clears the account lockout of a user and the attempt counters of
+// their pending one-time codes.
+func (s *lockoutService) UnlockUser(ctx context.Context, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "LockoutService.UnlockUser", attribute.Int("user.id", userId))
+	defer tracing.End(span, &err)
+
+	user, err := s.userRepository.GetUserByID(ctx, userId)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if user == nil {
+		return &errorhandler.NotFoundError{Message: "user not found"}
+	}
+
+	if err := s.Reset(ctx, AccountLockoutKey(user.Email)); err != nil {
+		return err
+	}
+
+	user.OTPAttempts = 0
+	user.EmailVerifyAttempts = 0
+
+	if err := s.userRepository.UpdateUser(ctx, user); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return nil
+}
+
+// lockoutDuration returns LOCKOUT_BASE_DURATION doubled once per failure past
+// the threshold, capped at LOCKOUT_MAX_DURATION.
+func lockoutDuration(extraFailures int) time.Duration {
+	cfg := config.Current()
+	duration := cfg.LOCKOUT_BASE_DURATION
+	for i := 0; i < extraFailures && duration < cfg.LOCKOUT_MAX_DURATION; i++ {
+		duration *= 2
+	}
+
+	if duration > cfg.LOCKOUT_MAX_DURATION {
+		duration = cfg.LOCKOUT_MAX_DURATION
+	}
+
+	return duration
+}
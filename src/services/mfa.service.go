@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
+	"restApi-GoGin/src/utils"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const recoveryCodeCount = 10
+
+type MFAService interface {
+	Enroll(ctx context.Context, user *models.User) (*dto.MFAEnrollResponse, error)
+	Confirm(ctx context.Context, user *models.User, req *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error)
+	Disable(ctx context.Context, user *models.User, req *dto.MFACodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, user *models.User, req *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error)
+}
+
+type mfaService struct {
+	userRepository         repository.UserRepository
+	recoveryCodeRepository repository.RecoveryCodeRepository
+}
+
+func NewMFAService(userRepository repository.UserRepository, recoveryCodeRepository repository.RecoveryCodeRepository) *mfaService {
+	return &mfaService{
+		userRepository:         userRepository,
+		recoveryCodeRepository: recoveryCodeRepository,
+	}
+}
+
+// Enroll creates a new pending secret. MFA stays disabled until the user
+// proves the authenticator works by calling Confirm.
+func (s *mfaService) Enroll(ctx context.Context, user *models.User) (_ *dto.MFAEnrollResponse, err error) {
+	ctx, span := tracing.Start(ctx, "MFAService.Enroll", attribute.Int("user.id", user.Id))
+	defer tracing.End(span, &err)
+
+	if user.MFAEnabled {
+		return nil, &errorhandler.BadRequestError{Message: "MFA is already enabled"}
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	user.MFASecret = &secret
+	user.MFALastStep = nil
+
+	if err := s.userRepository.UpdateUser(ctx, user); err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return &dto.MFAEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(config.Current().MFA_ISSUER, user.Email, secret),
+	}, nil
+}
+
+func (s *mfaService) Confirm(ctx context.Context, user *models.User, req *dto.MFACodeRequest) (_ *dto.MFARecoveryCodesResponse, err error) {
+	ctx, span := tracing.Start(ctx, "MFAService.Confirm", attribute.Int("user.id", user.Id))
+	defer tracing.End(span, &err)
+
+	if user.MFAEnabled {
+		return nil, &errorhandler.BadRequestError{Message: "MFA is already enabled"}
+	}
+
+	if user.MFASecret == nil {
+		return nil, &errorhandler.BadRequestError{Message: "no MFA enrollment found"}
+	}
+
+	step, ok := utils.ValidateTOTP(*user.MFASecret, strings.TrimSpace(req.Code), time.Now())
+	if !ok {
+		return nil, &errorhandler.BadRequestError{Message: "invalid MFA code"}
+	}
+
+	user.MFAEnabled = true
+	user.MFALastStep = &step
+
+	if err := s.userRepository.UpdateUser(ctx, user); err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return s.issueRecoveryCodes(ctx, user)
+}
+
+func (s *mfaService) Disable(ctx context.Context, user *models.User, req *dto.MFACodeRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "MFAService.Disable", attribute.Int("user.id", user.Id))
+	defer tracing.End(span, &err)
+
+	if !user.MFAEnabled {
+		return &errorhandler.BadRequestError{Message: "MFA is not enabled"}
+	}
+
+	// The user from the request context carries no roles, so load them.
+	withRoles, err := s.userRepository.GetUserByID(ctx, user.Id)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if withRoles != nil && config.MFARequiredForRoles(withRoles.RoleNames()) {
+		return &errorhandler.ForbiddenError{Message: "MFA is required for your role"}
+	}
+
+	if err := verifySecondFactor(ctx, user, req.Code, s.userRepository, s.recoveryCodeRepository); err != nil {
+		return err
+	}
+
+	user.MFAEnabled = false
+	user.MFASecret = nil
+	user.MFALastStep = nil
+
+	if err := s.userRepository.UpdateUser(ctx, user); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if err := s.recoveryCodeRepository.DeleteByUser(ctx, user.Id); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, user *models.User, req *dto.MFACodeRequest) (_ *dto.MFARecoveryCodesResponse, err error) {
+	ctx, span := tracing.Start(ctx, "MFAService.RegenerateRecoveryCodes", attribute.Int("user.id", user.Id))
+	defer tracing.End(span, &err)
+
+	if !user.MFAEnabled {
+		return nil, &errorhandler.BadRequestError{Message: "MFA is not enabled"}
+	}
+
+	if err := verifySecondFactor(ctx, user, req.Code, s.userRepository, s.recoveryCodeRepository); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(ctx, user)
+}
+
+func (s *mfaService) issueRecoveryCodes(ctx context.Context, user *models.User) (*dto.MFARecoveryCodesResponse, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+
+	if err := s.recoveryCodeRepository.Replace(ctx, user.Id, hashes); err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return &dto.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery
+// code. A TOTP code is rejected if its time step was already used.
+func verifySecondFactor(ctx context.Context, user *models.User, code string, userRepository repository.UserRepository, recoveryCodeRepository repository.RecoveryCodeRepository) error {
+	code = strings.TrimSpace(code)
+
+	if user.MFASecret != nil {
+		if step, ok := utils.ValidateTOTP(*user.MFASecret, code, time.Now()); ok {
+			used, err := userRepository.UseTOTPStep(ctx, user.Id, step)
+			if err != nil {
+				return &errorhandler.InternalServerError{Message: err.Error()}
+			}
+			if !used {
+				return &errorhandler.UnauthorizedError{Message: "MFA code already used"}
+			}
+
+			user.MFALastStep = &step
+			return nil
+		}
+	}
+
+	used, err := recoveryCodeRepository.Consume(ctx, user.Id, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if !used {
+		return &errorhandler.UnauthorizedError{Message: "invalid MFA code"}
+	}
+
+	return nil
+}
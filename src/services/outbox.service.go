@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"time"
+)
+
+const (
+	defaultOutboxPerPage = 20
+	maxOutboxPerPage     = 100
+)
+
+type OutboxService interface {
+	ListMessages(ctx context.Context, query *dto.OutboxListQuery) ([]models.OutboxMessage, *dto.Paginate, error)
+	GetMessage(ctx context.Context, id int64) (*models.OutboxMessage, error)
+	Requeue(ctx context.Context, id int64) error
+	RequeueDead(ctx context.Context) (int64, error)
+}
+
+type outboxService struct {
+	outboxRepository repository.OutboxRepository
+}
+
+func NewOutboxService(outboxRepository repository.OutboxRepository) *outboxService {
+	return &outboxService{outboxRepository: outboxRepository}
+}
+
+func (s *outboxService) ListMessages(ctx context.Context, query *dto.OutboxListQuery) ([]models.OutboxMessage, *dto.Paginate, error) {
+	page := max(query.Page, 1)
+	perPage := query.PerPage
+	if perPage <= 0 {
+		perPage = defaultOutboxPerPage
+	}
+	perPage = min(perPage, maxOutboxPerPage)
+
+	messages, total, err := s.outboxRepository.List(ctx, repository.OutboxFilter{
+		Status: query.Status,
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		return nil, nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	paginate := &dto.Paginate{
+		Page:      page,
+		PerPage:   perPage,
+		Total:     int(total),
+		TotalPage: int((total + int64(perPage) - 1) / int64(perPage)),
+	}
+	return messages, paginate, nil
+}
+
+func (s *outboxService) GetMessage(ctx context.Context, id int64) (*models.OutboxMessage, error) {
+	message, err := s.outboxRepository.GetById(ctx, id)
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+	if message == nil {
+		return nil, &errorhandler.NotFoundError{Message: "message not found"}
+	}
+	return message, nil
+}
+
+// Requeue gives a dead message a fresh set of attempts, starting now.
+func (s *outboxService) Requeue(ctx context.Context, id int64) error {
+	if _, err := s.GetMessage(ctx, id); err != nil {
+		return err
+	}
+
+	requeued, err := s.outboxRepository.Requeue(ctx, id, time.Now())
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+	if !requeued {
+		return &errorhandler.BadRequestError{Message: "only dead messages can be requeued"}
+	}
+	return nil
+}
+
+func (s *outboxService) RequeueDead(ctx context.Context) (int64, error) {
+	count, err := s.outboxRepository.RequeueDead(ctx, time.Now())
+	if err != nil {
+		return 0, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+	return count, nil
+}
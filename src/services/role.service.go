@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"strings"
+)
+
+type RoleService interface {
+	ListRoles() ([]models.Role, error)
+	GetRole(id int) (*models.Role, error)
+	CreateRole(ctx context.Context, req *dto.CreateRoleRequest) (*models.Role, error)
+	UpdateRole(ctx context.Context, id int, req *dto.UpdateRoleRequest) (*models.Role, error)
+	DeleteRole(ctx context.Context, id int) error
+	ListPermissions() ([]models.Permission, error)
+	CreatePermission(ctx context.Context, req *dto.CreatePermissionRequest) (*models.Permission, error)
+	DeletePermission(ctx context.Context, id int) error
+	AssignRoles(ctx context.Context, userId int, req *dto.AssignRolesRequest) error
+}
+
+type roleService struct {
+	roleRepository       repository.RoleRepository
+	permissionRepository repository.PermissionRepository
+	userRepository       repository.UserRepository
+	auditor              audit.Recorder
+}
+
+func NewRoleService(roleRepository repository.RoleRepository, permissionRepository repository.PermissionRepository, userRepository repository.UserRepository, auditor audit.Recorder) *roleService {
+	return &roleService{
+		roleRepository:       roleRepository,
+		permissionRepository: permissionRepository,
+		userRepository:       userRepository,
+		auditor:              auditor,
+	}
+}
+
+func (s *roleService) ListRoles() ([]models.Role, error) {
+	roles, err := s.roleRepository.GetAll()
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return roles, nil
+}
+
+func (s *roleService) GetRole(id int) (*models.Role, error) {
+	role, err := s.roleRepository.GetById(id)
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if role == nil {
+		return nil, &errorhandler.NotFoundError{Message: "role not found"}
+	}
+
+	return role, nil
+}
+
+func (s *roleService) CreateRole(ctx context.Context, req *dto.CreateRoleRequest) (*models.Role, error) {
+	name := strings.TrimSpace(req.Name)
+
+	existing, err := s.roleRepository.GetByNames([]string{name})
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if len(existing) > 0 {
+		return nil, &errorhandler.BadRequestError{Message: "role already exists"}
+	}
+
+	permissions, err := s.findPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := models.Role{
+		Name:        name,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+
+	if err := s.roleRepository.Create(&role); err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionRoleCreate,
+		TargetType: audit.TargetRole,
+		TargetId:   role.Id,
+		Changes:    audit.Diff(nil, roleSnapshot(&role)),
+	})
+
+	return &role, nil
+}
+
+func (s *roleService) UpdateRole(ctx context.Context, id int, req *dto.UpdateRoleRequest) (*models.Role, error) {
+	role, err := s.GetRole(id)
+	if err != nil {
+		return nil, err
+	}
+	before := roleSnapshot(role)
+
+	permissions, err := s.findPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Description = req.Description
+	role.Permissions = permissions
+
+	if err := s.roleRepository.Update(role); err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if changes := audit.Diff(before, roleSnapshot(role)); len(changes) > 0 {
+		s.auditor.Record(ctx, audit.Event{
+			Action:     audit.ActionRoleUpdate,
+			TargetType: audit.TargetRole,
+			TargetId:   role.Id,
+			Changes:    changes,
+		})
+	}
+
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(ctx context.Context, id int) error {
+	role, err := s.GetRole(id)
+	if err != nil {
+		return err
+	}
+
+	// The default roles are assumed to exist by registration and seeding.
+	if role.Name == models.RoleAdmin || role.Name == models.RoleUser {
+		return &errorhandler.BadRequestError{Message: "default roles cannot be deleted"}
+	}
+
+	if err := s.roleRepository.Delete(id); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionRoleDelete,
+		TargetType: audit.TargetRole,
+		TargetId:   id,
+		Changes:    audit.Diff(roleSnapshot(role), nil),
+	})
+
+	return nil
+}
+
+func (s *roleService) ListPermissions() ([]models.Permission, error) {
+	permissions, err := s.permissionRepository.GetAll()
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return permissions, nil
+}
+
+func (s *roleService) CreatePermission(ctx context.Context, req *dto.CreatePermissionRequest) (*models.Permission, error) {
+	name := strings.TrimSpace(req.Name)
+
+	existing, err := s.permissionRepository.GetByNames([]string{name})
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if len(existing) > 0 {
+		return nil, &errorhandler.BadRequestError{Message: "permission already exists"}
+	}
+
+	permission := models.Permission{
+		Name:        name,
+		Description: req.Description,
+	}
+
+	if err := s.permissionRepository.Create(&permission); err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionPermissionCreate,
+		TargetType: audit.TargetPermission,
+		TargetId:   permission.Id,
+		Changes:    audit.Diff(nil, permissionSnapshot(&permission)),
+	})
+
+	return &permission, nil
+}
+
+func (s *roleService) DeletePermission(ctx context.Context, id int) error {
+	permission, err := s.permissionRepository.GetById(id)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if permission == nil {
+		return &errorhandler.NotFoundError{Message: "permission not found"}
+	}
+
+	for _, builtin := range models.DefaultPermissions {
+		if builtin.Name == permission.Name {
+			return &errorhandler.BadRequestError{Message: "built-in permissions cannot be deleted"}
+		}
+	}
+
+	if err := s.permissionRepository.Delete(id); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionPermissionDelete,
+		TargetType: audit.TargetPermission,
+		TargetId:   id,
+		Changes:    audit.Diff(permissionSnapshot(permission), nil),
+	})
+
+	return nil
+}
+
+// AssignRoles replaces the roles of a user. The new roles reach the user's
+// access token the next time it is refreshed.
+func (s *roleService) AssignRoles(ctx context.Context, userId int, req *dto.AssignRolesRequest) error {
+	user, err := s.userRepository.GetUserByID(ctx, userId)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if user == nil {
+		return &errorhandler.NotFoundError{Message: "user not found"}
+	}
+
+	roles := []models.Role{}
+	if len(req.Roles) > 0 {
+		roles, err = s.roleRepository.GetByNames(req.Roles)
+		if err != nil {
+			return &errorhandler.InternalServerError{Message: err.Error()}
+		}
+
+		if len(roles) != len(uniqueNames(req.Roles)) {
+			return &errorhandler.BadRequestError{Message: "unknown role"}
+		}
+	}
+
+	before := userSnapshot(user)
+	if err := s.userRepository.ReplaceRoles(ctx, user, roles); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+	user.Roles = roles
+
+	if change, ok := audit.Diff(before, userSnapshot(user))["roles"]; ok {
+		s.auditor.Record(ctx, audit.Event{
+			Action:     audit.ActionUserRoles,
+			TargetType: audit.TargetUser,
+			TargetId:   user.Id,
+			Changes:    audit.Changes{"roles": change},
+		})
+	}
+
+	return nil
+}
+
+func (s *roleService) findPermissions(names []string) ([]models.Permission, error) {
+	if len(names) == 0 {
+		return []models.Permission{}, nil
+	}
+
+	permissions, err := s.permissionRepository.GetByNames(names)
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if len(permissions) != len(uniqueNames(names)) {
+		return nil, &errorhandler.BadRequestError{Message: "unknown permission"}
+	}
+
+	return permissions, nil
+}
+
+func uniqueNames(names []string) map[string]bool {
+	unique := make(map[string]bool, len(names))
+	for _, name := range names {
+		unique[name] = true
+	}
+
+	return unique
+}
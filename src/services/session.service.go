@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
+	"restApi-GoGin/src/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type SessionService interface {
+	ListSessions(ctx context.Context, userId int, currentSessionId string) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, userId int, sessionId string) error
+	RevokeOtherSessions(ctx context.Context, userId int, currentSessionId string) error
+	RevokeAllSessions(ctx context.Context, userId int) error
+}
+
+type sessionService struct {
+	sessionRepository repository.SessionRepository
+	userRepository    repository.UserRepository
+}
+
+func NewSessionService(sessionRepository repository.SessionRepository, userRepository repository.UserRepository) *sessionService {
+	return &sessionService{
+		sessionRepository: sessionRepository,
+		userRepository:    userRepository,
+	}
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, userId int, currentSessionId string) (_ []dto.SessionResponse, err error) {
+	ctx, span := tracing.Start(ctx, "SessionService.ListSessions", attribute.Int("user.id", userId))
+	defer tracing.End(span, &err)
+
+	sessions, err := s.sessionRepository.GetActiveByUser(ctx, userId)
+	if err != nil {
+		return nil, &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	data := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		data = append(data, dto.SessionResponse{
+			ID:         session.Id,
+			Device:     utils.DeviceName(session.UserAgent),
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			LastSeenAt: session.LastSeenAt,
+			CreatedAt:  session.CreatedAt,
+			Current:    session.Id == currentSessionId,
+		})
+	}
+
+	return data, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userId int, sessionId string) (err error) {
+	ctx, span := tracing.Start(ctx, "SessionService.RevokeSession", attribute.Int("user.id", userId))
+	defer tracing.End(span, &err)
+
+	session, err := s.sessionRepository.GetById(ctx, sessionId)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	// Sessions of other users are reported as missing rather than forbidden
+	// so their ids cannot be probed.
+	if session == nil || session.UserId != userId || session.RevokedAt != nil {
+		return &errorhandler.NotFoundError{Message: "session not found"}
+	}
+
+	if err := s.sessionRepository.Revoke(ctx, session.Id); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, userId int, currentSessionId string) (err error) {
+	ctx, span := tracing.Start(ctx, "SessionService.RevokeOtherSessions", attribute.Int("user.id", userId))
+	defer tracing.End(span, &err)
+
+	if err := s.sessionRepository.RevokeAllByUser(ctx, userId, currentSessionId); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (s *sessionService) RevokeAllSessions(ctx context.Context, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "SessionService.RevokeAllSessions", attribute.Int("user.id", userId))
+	defer tracing.End(span, &err)
+
+	user, err := s.userRepository.GetUserByID(ctx, userId)
+	if err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	if user == nil {
+		return &errorhandler.NotFoundError{Message: "user not found"}
+	}
+
+	if err := s.sessionRepository.RevokeAllByUser(ctx, userId, ""); err != nil {
+		return &errorhandler.InternalServerError{Message: err.Error()}
+	}
+
+	return nil
+}
@@ -1,81 +1,245 @@
 package services
 
 import (
+	"context"
+	"strings"
+	"time"
+
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
 	"restApi-GoGin/src/models"
 	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 )
 
 // UserService interface
 type UserService interface {
-	GetAllUsers() ([]models.User, error)
-	GetUserByEmail(email string) (*models.User, error)
-	GetUserByID(id int) (*models.User, error)
-	CreateUser(name, email, password, role string) error
-	UpdateUser(id int, name, email, password, role *string) error
-	DeleteUser(id int) error
+	GetAllUsers(ctx context.Context, query *dto.UserListQuery) ([]models.User, *dto.Paginate, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	CreateUser(ctx context.Context, name, email, password, role string) error
+	UpdateUser(ctx context.Context, id int, name, email, password, role *string) error
+	DeleteUser(ctx context.Context, id int) error
+	GetDeletedUsers(ctx context.Context, query *dto.UserListQuery) ([]models.User, *dto.Paginate, error)
+	RestoreUser(ctx context.Context, id int) error
+	PurgeUser(ctx context.Context, id int) error
 }
 
 // userService struct
 type userService struct {
-	repo repository.UserRepository
+	repo       repository.UserRepository
+	roleRepo   repository.RoleRepository
+	transactor repository.Transactor
+	auditor    audit.Recorder
 }
 
 // NewUserService constructor
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+func NewUserService(repo repository.UserRepository, roleRepo repository.RoleRepository, transactor repository.Transactor, auditor audit.Recorder) UserService {
+	return &userService{repo: repo, roleRepo: roleRepo, transactor: transactor, auditor: auditor}
+}
+
+const (
+	defaultUsersPerPage = 10
+	maxUsersPerPage     = 100
+)
+
+// userSortColumns maps the fields accepted by the sort parameter to columns.
+var userSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"deleted_at": "deleted_at",
 }
 
 // GetAllUsers implementation
-func (s *userService) GetAllUsers() ([]models.User, error) {
-	return s.repo.GetAllUsers()
+func (s *userService) GetAllUsers(ctx context.Context, query *dto.UserListQuery) (_ []models.User, _ *dto.Paginate, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer tracing.End(span, &err)
+
+	page := max(query.Page, 1)
+	perPage := query.PerPage
+	if perPage <= 0 {
+		perPage = defaultUsersPerPage
+	}
+	perPage = min(perPage, maxUsersPerPage)
+
+	filter := repository.UserFilter{
+		Role:   strings.TrimSpace(query.Role),
+		Name:   strings.TrimSpace(query.Name),
+		Email:  strings.TrimSpace(query.Email),
+		Status: query.Status,
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	}
+
+	if query.CreatedFrom != "" {
+		from, err := time.ParseInLocation(time.DateOnly, query.CreatedFrom, time.Local)
+		if err != nil {
+			return nil, nil, &errorhandler.BadRequestError{Message: "invalid created_from"}
+		}
+		filter.CreatedFrom = &from
+	}
+	if query.CreatedUntil != "" {
+		until, err := time.ParseInLocation(time.DateOnly, query.CreatedUntil, time.Local)
+		if err != nil {
+			return nil, nil, &errorhandler.BadRequestError{Message: "invalid created_until"}
+		}
+		// created_until is inclusive, so stop at the start of the next day.
+		until = until.AddDate(0, 0, 1)
+		filter.CreatedUntil = &until
+	}
+
+	sort, err := parseUserSort(query.Sort)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter.Sort = sort
+
+	users, total, err := s.repo.GetAllUsers(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paginate := &dto.Paginate{
+		Page:      page,
+		PerPage:   perPage,
+		Total:     int(total),
+		TotalPage: int((total + int64(perPage) - 1) / int64(perPage)),
+	}
+
+	return users, paginate, nil
 }
 
-func (s *userService) GetUserByEmail(email string) (*models.User, error) {
-	return s.repo.GetUserByEmail(email)
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByEmail")
+	defer tracing.End(span, &err)
+
+	return s.repo.GetUserByEmail(ctx, email)
 }
 
-func (s *userService) GetUserByID(id int) (*models.User, error) {
-	return s.repo.GetUserByID(id)
+func (s *userService) GetUserByID(ctx context.Context, id int) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID", attribute.Int("user.id", id))
+	defer tracing.End(span, &err)
+
+	return s.repo.GetUserByID(ctx, id)
 }
 
-func (s *userService) CreateUser(name, email, password, role string) error {
+func (s *userService) CreateUser(ctx context.Context, name, email, password, role string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer tracing.End(span, &err)
+
+	roles, err := s.findRole(role)
+	if err != nil {
+		return err
+	}
+
 	user := &models.User{
 		Name:     name,
 		Email:    email,
 		Password: password,
-		Role:     role,
+		Roles:    roles,
 	}
-	return s.repo.CreateUser(user)
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionUserCreate,
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+		Changes:    audit.Diff(nil, userSnapshot(user)),
+	})
+	return nil
 }
 
-func (s *userService) UpdateUser(id int, name, email, password, role *string) error {
-	user, err := s.repo.GetUserByID(id)
+func (s *userService) UpdateUser(ctx context.Context, id int, name, email, password, role *string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser", attribute.Int("user.id", id))
+	defer tracing.End(span, &err)
+
+	user, err := s.repo.GetUserByID(ctx, id)
 	if err != nil {
 		return err
 	}
 	if user == nil {
 		return gorm.ErrRecordNotFound
 	}
+	before := userSnapshot(user)
 	if name != nil {
 		user.Name = *name
 	}
-	if email != nil {
+	if email != nil && *email != user.Email {
+		// A new address has to be verified again.
 		user.Email = *email
+		user.EmailVerifiedAt = nil
 	}
 	if password != nil {
 		user.Password = *password
 	}
+	// Setting a role replaces every role the user had.
+	var roles []models.Role
+	if role != nil {
+		roles, err = s.findRole(*role)
+		if err != nil {
+			return err
+		}
+	}
+	// The fields and the roles are written together, so a failed role
+	// change leaves the user as it was.
+	err = s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateUser(ctx, user); err != nil {
+			return err
+		}
+		if role != nil {
+			return s.repo.ReplaceRoles(ctx, user, roles)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 	if role != nil {
-		user.Role = *role
+		user.Roles = roles
+	}
+
+	s.recordUpdate(ctx, user.Id, before, userSnapshot(user))
+	return nil
+}
+
+// recordUpdate audits the changes to a user. Role changes are recorded as
+// their own action, so they can be found however they were made.
+func (s *userService) recordUpdate(ctx context.Context, id int, before, after audit.Snapshot) {
+	changes := audit.Diff(before, after)
+	if change, ok := changes["roles"]; ok {
+		delete(changes, "roles")
+		s.auditor.Record(ctx, audit.Event{
+			Action:     audit.ActionUserRoles,
+			TargetType: audit.TargetUser,
+			TargetId:   id,
+			Changes:    audit.Changes{"roles": change},
+		})
+	}
+	if len(changes) > 0 {
+		s.auditor.Record(ctx, audit.Event{
+			Action:     audit.ActionUserUpdate,
+			TargetType: audit.TargetUser,
+			TargetId:   id,
+			Changes:    changes,
+		})
 	}
-	return s.repo.UpdateUser(user)
 }
 
-func (s *userService) DeleteUser(id int) error {
-	// Check if user exists before soft delete
-	user, err := s.repo.GetUserByID(id)
+func (s *userService) DeleteUser(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser", attribute.Int("user.id", id))
+	defer tracing.End(span, &err)
+
+	// GetUserByID does not find users that are already deleted.
+	user, err := s.repo.GetUserByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -83,10 +247,118 @@ func (s *userService) DeleteUser(id int) error {
 		return gorm.ErrRecordNotFound
 	}
 
-	// Check if user is already deleted
-	if user.DeletedAt != nil {
-		return gorm.ErrRecordNotFound
+	if err := s.repo.DeleteUser(ctx, id); err != nil {
+		return err
 	}
 
-	return s.repo.DeleteUser(id)
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionUserDelete,
+		TargetType: audit.TargetUser,
+		TargetId:   id,
+		Changes:    audit.Diff(userSnapshot(user), nil),
+	})
+	return nil
+}
+
+// GetDeletedUsers lists the soft-deleted users like GetAllUsers, most
+// recently deleted first unless query sorts otherwise.
+func (s *userService) GetDeletedUsers(ctx context.Context, query *dto.UserListQuery) ([]models.User, *dto.Paginate, error) {
+	deleted := *query
+	deleted.Status = repository.UserStatusDeleted
+	if strings.TrimSpace(deleted.Sort) == "" {
+		deleted.Sort = "-deleted_at"
+	}
+	return s.GetAllUsers(ctx, &deleted)
+}
+
+func (s *userService) RestoreUser(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RestoreUser", attribute.Int("user.id", id))
+	defer tracing.End(span, &err)
+
+	user, err := s.repo.GetDeletedUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return &errorhandler.NotFoundError{Message: "deleted user not found"}
+	}
+
+	if err := s.repo.RestoreUser(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionUserRestore,
+		TargetType: audit.TargetUser,
+		TargetId:   id,
+		Changes:    audit.Diff(nil, userSnapshot(user)),
+	})
+	return nil
+}
+
+// PurgeUser permanently deletes a user together with their role assignments,
+// sessions, refresh tokens and recovery codes. Only users that are already
+// soft deleted can be purged, so a purge always follows a delete that could
+// still be undone.
+func (s *userService) PurgeUser(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.PurgeUser", attribute.Int("user.id", id))
+	defer tracing.End(span, &err)
+
+	user, err := s.repo.GetDeletedUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return &errorhandler.NotFoundError{Message: "deleted user not found"}
+	}
+
+	if err := s.repo.PurgeUser(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionUserPurge,
+		TargetType: audit.TargetUser,
+		TargetId:   id,
+		Changes:    audit.Diff(userSnapshot(user), nil),
+	})
+	return nil
+}
+
+func (s *userService) findRole(name string) ([]models.Role, error) {
+	roles, err := s.roleRepo.GetByNames([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, &errorhandler.BadRequestError{Message: "role " + name + " does not exist"}
+	}
+	return roles, nil
+}
+
+// parseUserSort parses a comma-separated list of sort fields, each optionally
+// prefixed with "-" for descending order, e.g. "-created_at,name".
+func parseUserSort(sort string) ([]repository.UserSort, error) {
+	var result []repository.UserSort
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(field, "-")
+		name := strings.TrimPrefix(field, "-")
+		column, ok := userSortColumns[name]
+		if !ok {
+			return nil, &errorhandler.BadRequestError{Message: "cannot sort by " + name}
+		}
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+
+		result = append(result, repository.UserSort{Column: column, Desc: desc})
+	}
+	return result, nil
 }
@@ -0,0 +1,13 @@
+package utils
+
+import "time"
+
+// Backoff returns the delay before retrying work that has failed attempts
+// times: base, doubling after each further failure, capped at limit.
+func Backoff(attempts int, base, limit time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
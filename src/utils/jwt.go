@@ -1,58 +1,84 @@
 package utils
 
 import (
-	"os"
+	"restApi-GoGin/src/config"
 	"restApi-GoGin/src/models"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var accessSecret = []byte(os.Getenv("ACCESS_SECRET"))
-var refreshSecret = []byte(os.Getenv("REFRESH_SECRET"))
+const mfaTokenPurpose = "mfa"
 
+// Token types written to the typ header. Services verifying access tokens
+// through the JWKS endpoint must only accept AccessTokenType.
+const (
+	AccessTokenType  = "at+jwt"
+	RefreshTokenType = "refresh+jwt"
+	MFATokenType     = "mfa+jwt"
+)
+
+// JWTAccessClaims carries the user's roles and permissions so authorization
+// does not need a database round trip. Changes to a user's roles take effect
+// when the access token is next refreshed.
 type JWTAccessClaims struct {
-	UserId int `json:"user_id"`
+	UserId      int      `json:"user_id"`
+	SessionId   string   `json:"sid"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"perms,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type JWTRefreshClaims struct {
-	UserId int `json:"user_id"`
+	UserId   int    `json:"user_id"`
+	FamilyId string `json:"fid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(user *models.User) (string, error) {
+// JWTMFAClaims identifies a user who passed the password check and still has
+// to present a second factor.
+type JWTMFAClaims struct {
+	UserId  int    `json:"user_id"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+func GenerateAccessToken(user *models.User, sessionId string) (string, error) {
 	claims := JWTAccessClaims{
 		user.Id,
+		sessionId,
+		user.RoleNames(),
+		user.PermissionNames(),
 		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 15)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.Current().ACCESS_TOKEN_TTL)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	ss, err := token.SignedString(accessSecret)
-
-	return ss, err
+	return currentKeyring().access.sign(claims, AccessTokenType)
 }
 
-func GenerateRefreshToken(user *models.User) (string, error) {
+// GenerateRefreshToken signs a refresh token belonging to the given token
+// family. Every token gets a random jti so it can be tracked and revoked.
+func GenerateRefreshToken(user *models.User, familyId string) (string, *JWTRefreshClaims, error) {
+	now := time.Now()
 	claims := JWTRefreshClaims{
 		user.Id,
+		familyId,
 		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)),
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(config.Current().REFRESH_TOKEN_TTL)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	ss, err := currentKeyring().refresh.sign(claims, RefreshTokenType)
 
-	ss, err := token.SignedString(refreshSecret)
-
-	return ss, err
+	return ss, &claims, err
 }
 
 func VerifyRefreshToken(tokenStr string) (*JWTRefreshClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &JWTRefreshClaims{}, func(t *jwt.Token) (interface{}, error) { return refreshSecret, nil })
+	token, err := currentKeyring().refresh.parse(tokenStr, &JWTRefreshClaims{}, RefreshTokenType)
 
 	if err != nil || !token.Valid {
 		return nil, err
@@ -69,7 +95,7 @@ func VerifyRefreshToken(tokenStr string) (*JWTRefreshClaims, error) {
 }
 
 func VerifyAccessToken(tokenStr string) (*JWTAccessClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &JWTAccessClaims{}, func(t *jwt.Token) (interface{}, error) { return accessSecret, nil })
+	token, err := currentKeyring().access.parse(tokenStr, &JWTAccessClaims{}, AccessTokenType)
 
 	if err != nil || !token.Valid {
 		return nil, err
@@ -83,3 +109,33 @@ func VerifyAccessToken(tokenStr string) (*JWTAccessClaims, error) {
 
 	return claims, nil
 }
+
+// GenerateMFAToken signs the short-lived challenge returned by login when the
+// user has two-factor authentication enabled.
+func GenerateMFAToken(user *models.User) (string, error) {
+	claims := JWTMFAClaims{
+		user.Id,
+		mfaTokenPurpose,
+		jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.Current().MFA_TOKEN_TTL)),
+		},
+	}
+
+	return currentKeyring().access.sign(claims, MFATokenType)
+}
+
+func VerifyMFAToken(tokenStr string) (*JWTMFAClaims, error) {
+	token, err := currentKeyring().access.parse(tokenStr, &JWTMFAClaims{}, MFATokenType)
+
+	if err != nil || !token.Valid {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*JWTMFAClaims)
+
+	if !ok || claims.Purpose != mfaTokenPurpose {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
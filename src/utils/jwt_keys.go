@@ -0,0 +1,324 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"restApi-GoGin/src/dto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing keys.
+const minRSAKeyBits = 2048
+
+// JWTKeyOptions describes where the token signing keys come from.
+//
+// When SigningKeyFile is empty tokens are signed with HS256 using AccessSecret
+// and RefreshSecret. Otherwise SigningKeyFile must hold an RSA or Ed25519
+// private key in PEM format and every token is signed with it, using RS256 or
+// EdDSA respectively and a kid header of SigningKeyId (the RFC 7638 thumbprint
+// of the key when empty).
+//
+// VerificationKeys lists retired keys that are still accepted, as
+// comma-separated "kid=path" entries. An entry may end in "@<RFC 3339 time>"
+// to stop accepting the key after that moment, which is how the overlap window
+// of a rotation is expressed.
+type JWTKeyOptions struct {
+	AccessSecret     string
+	RefreshSecret    string
+	SigningKeyFile   string
+	SigningKeyId     string
+	VerificationKeys string
+}
+
+// jwtKey is a single key able to verify, and possibly sign, tokens.
+type jwtKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	notAfter  time.Time
+}
+
+// jwtKeySet holds the key new tokens are signed with and every key tokens are
+// verified against. Keys of asymmetric sets are addressed by kid.
+type jwtKeySet struct {
+	signer    *jwtKey
+	verifiers map[string]*jwtKey
+}
+
+type jwtKeyring struct {
+	access  *jwtKeySet
+	refresh *jwtKeySet
+}
+
+var (
+	keyringMu sync.RWMutex
+	// keyring has empty HMAC secrets until LoadJWTKeys runs.
+	keyring = newHMACKeyring("", "")
+)
+
+// LoadJWTKeys replaces the keys used to sign and verify tokens.
+func LoadJWTKeys(opts JWTKeyOptions) error {
+	var next *jwtKeyring
+
+	if opts.SigningKeyFile == "" {
+		if opts.VerificationKeys != "" {
+			return fmt.Errorf("jwt: verification keys require a signing key file")
+		}
+		next = newHMACKeyring(opts.AccessSecret, opts.RefreshSecret)
+	} else {
+		set, err := loadAsymmetricKeySet(opts)
+		if err != nil {
+			return err
+		}
+		next = &jwtKeyring{access: set, refresh: set}
+	}
+
+	keyringMu.Lock()
+	keyring = next
+	keyringMu.Unlock()
+
+	return nil
+}
+
+// PublicJWKs returns the public keys that currently verify access tokens, for
+// publishing as a JWKS document. It is empty when tokens are signed with a
+// shared secret.
+func PublicJWKs() []dto.JWK {
+	set := currentKeyring().access
+	keys := []dto.JWK{}
+
+	for _, key := range set.verifiers {
+		if key.id == "" || key.expired(time.Now()) {
+			continue
+		}
+
+		jwk, err := publicJWK(key)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+
+	return keys
+}
+
+func currentKeyring() *jwtKeyring {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+
+	return keyring
+}
+
+func newHMACKeyring(accessSecret string, refreshSecret string) *jwtKeyring {
+	return &jwtKeyring{
+		access:  newHMACKeySet(accessSecret),
+		refresh: newHMACKeySet(refreshSecret),
+	}
+}
+
+func newHMACKeySet(secret string) *jwtKeySet {
+	key := &jwtKey{
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+
+	return &jwtKeySet{
+		signer:    key,
+		verifiers: map[string]*jwtKey{"": key},
+	}
+}
+
+func loadAsymmetricKeySet(opts JWTKeyOptions) (*jwtKeySet, error) {
+	signer, err := loadJWTKey(opts.SigningKeyFile, opts.SigningKeyId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &jwtKeySet{
+		signer:    signer,
+		verifiers: map[string]*jwtKey{signer.id: signer},
+	}
+
+	for _, entry := range strings.Split(opts.VerificationKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, rest, found := strings.Cut(entry, "=")
+		if !found || kid == "" || rest == "" {
+			return nil, fmt.Errorf("jwt: invalid verification key entry %q", entry)
+		}
+
+		path, until, _ := strings.Cut(rest, "@")
+		key, err := loadJWTKey(path, kid, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if until != "" {
+			key.notAfter, err = time.Parse(time.RFC3339, until)
+			if err != nil {
+				return nil, fmt.Errorf("jwt: invalid expiry for verification key %q: %w", kid, err)
+			}
+		}
+
+		if _, exists := set.verifiers[kid]; exists {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", kid)
+		}
+		set.verifiers[kid] = key
+	}
+
+	return set, nil
+}
+
+// loadJWTKey reads an RSA or Ed25519 key from a PEM file. Signing keys must be
+// private keys; verification keys may be either.
+func loadJWTKey(path string, kid string, signing bool) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: read key file: %w", err)
+	}
+
+	key := &jwtKey{id: kid}
+
+	if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		key.method, key.signKey, key.verifyKey = jwt.SigningMethodRS256, private, &private.PublicKey
+	} else if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		key.method, key.signKey, key.verifyKey = jwt.SigningMethodEdDSA, private, private.(ed25519.PrivateKey).Public()
+	} else if signing {
+		return nil, fmt.Errorf("jwt: %s is not an RSA or Ed25519 private key", path)
+	} else if public, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		key.method, key.verifyKey = jwt.SigningMethodRS256, public
+	} else if public, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		key.method, key.verifyKey = jwt.SigningMethodEdDSA, public
+	} else {
+		return nil, fmt.Errorf("jwt: %s is not an RSA or Ed25519 key", path)
+	}
+
+	if public, ok := key.verifyKey.(*rsa.PublicKey); ok && public.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("jwt: RSA key %s is shorter than %d bits", path, minRSAKeyBits)
+	}
+
+	if key.id == "" {
+		key.id, err = jwkThumbprint(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+func (k *jwtKey) expired(now time.Time) bool {
+	return !k.notAfter.IsZero() && now.After(k.notAfter)
+}
+
+// sign signs claims with the signer of set, tagging the token with its type
+// and, for asymmetric keys, the kid.
+func (set *jwtKeySet) sign(claims jwt.Claims, typ string) (string, error) {
+	token := jwt.NewWithClaims(set.signer.method, claims)
+	token.Header["typ"] = typ
+	if set.signer.id != "" {
+		token.Header["kid"] = set.signer.id
+	}
+
+	return token.SignedString(set.signer.signKey)
+}
+
+// parse verifies tokenStr against set. The key is picked by kid, the token's
+// algorithm must be the one of that key and its typ header must match typ.
+// Tokens signed with a shared secret before tokens were typed carry the
+// generic "JWT" type and are still accepted.
+func (set *jwtKeySet) parse(tokenStr string, claims jwt.Claims, typ string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := set.verifiers[kid]
+		if !ok || key.expired(time.Now()) {
+			return nil, fmt.Errorf("jwt: unknown signing key %q", kid)
+		}
+
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+
+		tokenTyp, _ := t.Header["typ"].(string)
+		if tokenTyp != typ && (key.id != "" || tokenTyp != "JWT") {
+			return nil, jwt.ErrTokenInvalidClaims
+		}
+
+		return key.verifyKey, nil
+	})
+}
+
+func publicJWK(key *jwtKey) (dto.JWK, error) {
+	jwk := dto.JWK{
+		Kid: key.id,
+		Use: "sig",
+		Alg: key.method.Alg(),
+	}
+
+	switch public := key.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return dto.JWK{}, fmt.Errorf("jwt: unsupported public key type %T", public)
+	}
+
+	return jwk, nil
+}
+
+// jwkThumbprint computes the RFC 7638 thumbprint of the public part of key.
+func jwkThumbprint(key *jwtKey) (string, error) {
+	jwk, err := publicJWK(key)
+	if err != nil {
+		return "", err
+	}
+
+	// The members must be serialised in lexicographic order, which the
+	// ordering of these structs guarantees.
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+)
+
+// Validator validates request DTOs. Its errors name fields by their json tag,
+// or form tag for query parameters, and can be translated with the
+// translators returned by ValidationTranslator.
+var Validator, validationTranslators = newValidator()
+
+func newValidator() (*validator.Validate, *ut.UniversalTranslator) {
+	english := en.New()
+	translators := ut.New(english, english, id.New())
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	enTranslator, _ := translators.GetTranslator("en")
+	if err := enTranslations.RegisterDefaultTranslations(v, enTranslator); err != nil {
+		panic(err)
+	}
+
+	idTranslator, _ := translators.GetTranslator("id")
+	if err := idTranslations.RegisterDefaultTranslations(v, idTranslator); err != nil {
+		panic(err)
+	}
+
+	return v, translators
+}
+
+// ValidationTranslator picks the translator for validation messages from an
+// Accept-Language header, falling back to English.
+func ValidationTranslator(acceptLanguage string) ut.Translator {
+	translator, _ := validationTranslators.FindTranslator(acceptedLocales(acceptLanguage)...)
+	return translator
+}
+
+// acceptedLocales lists the locales of an Accept-Language header by
+// preference, adding the base language after each regional one so "id-ID"
+// also matches "id".
+func acceptedLocales(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+
+		languages = append(languages, language{tag: strings.ToLower(tag), quality: quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	locales := make([]string, 0, len(languages)*2)
+	for _, l := range languages {
+		locales = append(locales, strings.ReplaceAll(l.tag, "-", "_"))
+		if base, _, regional := strings.Cut(l.tag, "-"); regional {
+			locales = append(locales, base)
+		}
+	}
+
+	return locales
+}
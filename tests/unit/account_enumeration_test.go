@@ -0,0 +1,169 @@
+package unit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/mail"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/utils"
+
+	"gorm.io/gorm"
+)
+
+// createPendingUser creates a user with an unverified email, a verification
+// code and a password reset OTP, both "123456".
+func createPendingUser(t *testing.T, db *gorm.DB, email string) *models.User {
+	t.Helper()
+	hash, _ := utils.HashBcrypt("123456")
+	exp := time.Now().Add(time.Hour)
+	user := &models.User{
+		Name:               "John",
+		Email:              email,
+		Password:           "hash",
+		EmailVerifyCode:    &hash,
+		EmailVerifyCodeExp: &exp,
+		OTPCode:            &hash,
+		OTPCodeExp:         &exp,
+	}
+	if err := repository.NewUserRepository(db).CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return user
+}
+
+func TestEnumeration_ForgotPasswordAnswersUnknownEmails(t *testing.T) {
+	db := openSQLite(t)
+	configureLockout()
+	config.ENV.OTP_TTL = 10 * time.Minute
+	createPendingUser(t, db, "john@example.com")
+	mailer := mail.NewMemory()
+	service := newOutboxAuthService(db, mail.NewSender(mailer, loadTemplates(t)))
+
+	if err := service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: "nobody@example.com"}); err != nil {
+		t.Errorf("Expected an unknown email to succeed, got %v", err)
+	}
+	if len(mailer.Messages()) != 0 {
+		t.Error("Expected no email for an unknown address")
+	}
+
+	if err := service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: "john@example.com"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(mailer.Messages()) != 1 {
+		t.Errorf("Expected one email for a known address, got %d", len(mailer.Messages()))
+	}
+}
+
+func TestEnumeration_ResendVerificationAnswersEveryEmail(t *testing.T) {
+	db := openSQLite(t)
+	configureLockout()
+	config.ENV.OTP_TTL = 10 * time.Minute
+	createPendingUser(t, db, "john@example.com")
+	verified := createPendingUser(t, db, "jane@example.com")
+	db.Model(verified).Update("email_verified_at", time.Now())
+	mailer := mail.NewMemory()
+	service := newOutboxAuthService(db, mail.NewSender(mailer, loadTemplates(t)))
+
+	for _, email := range []string{"nobody@example.com", "jane@example.com", "john@example.com", "john@example.com"} {
+		if err := service.ResendVerification(context.Background(), &dto.ResendVerificationRequest{Email: email}); err != nil {
+			t.Errorf("Expected %s to succeed, got %v", email, err)
+		}
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 || messages[0].To[0] != "john@example.com" {
+		t.Errorf("Expected one email to the unverified user only, got %+v", messages)
+	}
+}
+
+func TestEnumeration_VerifyEmailSameErrorForUnknownEmail(t *testing.T) {
+	db := openSQLite(t)
+	configureLockout()
+	createPendingUser(t, db, "john@example.com")
+	verified := createPendingUser(t, db, "jane@example.com")
+	db.Model(verified).Update("email_verified_at", time.Now())
+	service := newOutboxAuthService(db, mail.NewSender(mail.NewMemory(), loadTemplates(t)))
+
+	wrongCode := service.VerifyEmail(context.Background(), &dto.VerifyEmailRequest{Email: "john@example.com", OTP: "000000"})
+	if wrongCode == nil {
+		t.Fatal("Expected a wrong code to fail")
+	}
+	for _, email := range []string{"nobody@example.com", "jane@example.com"} {
+		err := service.VerifyEmail(context.Background(), &dto.VerifyEmailRequest{Email: email, OTP: "000000"})
+		if !reflect.DeepEqual(err, wrongCode) {
+			t.Errorf("Expected %s to fail like a wrong code (%v), got %v", email, wrongCode, err)
+		}
+	}
+}
+
+func TestEnumeration_VerifyOTPSameErrorForUnknownEmail(t *testing.T) {
+	db := openSQLite(t)
+	configureLockout()
+	createPendingUser(t, db, "john@example.com")
+	service := newOutboxAuthService(db, mail.NewSender(mail.NewMemory(), loadTemplates(t)))
+
+	_, wrongCode := service.VerifyOTP(context.Background(), &dto.VerifyOTPRequest{Email: "john@example.com", OTP: "000000"}, nil)
+	if wrongCode == nil {
+		t.Fatal("Expected a wrong OTP to fail")
+	}
+	_, unknown := service.VerifyOTP(context.Background(), &dto.VerifyOTPRequest{Email: "nobody@example.com", OTP: "000000"}, nil)
+	if !reflect.DeepEqual(unknown, wrongCode) {
+		t.Errorf("Expected an unknown email to fail like a wrong OTP (%v), got %v", wrongCode, unknown)
+	}
+}
+
+func TestEnumeration_ResetPasswordSameErrorForUnknownEmail(t *testing.T) {
+	db := openSQLite(t)
+	configureLockout()
+	token, _ := utils.HashBcrypt("reset-token")
+	exp := time.Now().Add(time.Hour)
+	expired := time.Now().Add(-time.Minute)
+	createPendingUser(t, db, "john@example.com")
+	db.Model(&models.User{}).Where("email = ?", "john@example.com").Updates(map[string]interface{}{"reset_token": token, "reset_token_exp": exp})
+	createPendingUser(t, db, "jane@example.com")
+	db.Model(&models.User{}).Where("email = ?", "jane@example.com").Updates(map[string]interface{}{"reset_token": token, "reset_token_exp": expired})
+	createPendingUser(t, db, "jim@example.com")
+	service := newOutboxAuthService(db, mail.NewSender(mail.NewMemory(), loadTemplates(t)))
+
+	reset := func(email, token string) error {
+		return service.ResetPassword(context.Background(), &dto.ResetPasswordRequest{
+			Email: email, ResetToken: token, Password: "newpassword", PasswordConfirm: "newpassword",
+		}, nil)
+	}
+
+	wrongToken := reset("john@example.com", "wrong")
+	if wrongToken == nil {
+		t.Fatal("Expected a wrong reset token to fail")
+	}
+	// Unknown, expired and never requested all fail like a wrong token.
+	for _, email := range []string{"nobody@example.com", "jane@example.com", "jim@example.com"} {
+		if err := reset(email, "reset-token"); !reflect.DeepEqual(err, wrongToken) {
+			t.Errorf("Expected %s to fail like a wrong token (%v), got %v", email, wrongToken, err)
+		}
+	}
+}
+
+func TestVerifyOTP_ResetTokenUsesConfiguredTTL(t *testing.T) {
+	db := openSQLite(t)
+	configureLockout()
+	config.ENV.RESET_TOKEN_TTL = 30 * time.Minute
+	user := createPendingUser(t, db, "john@example.com")
+	service := newOutboxAuthService(db, mail.NewSender(mail.NewMemory(), loadTemplates(t)))
+
+	before := time.Now()
+	if _, err := service.VerifyOTP(context.Background(), &dto.VerifyOTPRequest{Email: user.Email, OTP: "123456"}, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var stored models.User
+	db.First(&stored, user.Id)
+	if stored.ResetTokenExp == nil || stored.ResetTokenExp.Before(before.Add(30*time.Minute)) || stored.ResetTokenExp.After(time.Now().Add(30*time.Minute)) {
+		t.Errorf("Expected the reset token to expire after RESET_TOKEN_TTL, got %v", stored.ResetTokenExp)
+	}
+}
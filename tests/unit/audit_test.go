@@ -0,0 +1,395 @@
+package unit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/mail"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditEntries returns the whole audit log, oldest first.
+func auditEntries(t *testing.T, db *gorm.DB) []models.AuditLog {
+	t.Helper()
+	var entries []models.AuditLog
+	if err := db.Order("id").Find(&entries).Error; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return entries
+}
+
+func recordEvents(t *testing.T, db *gorm.DB, n int) {
+	t.Helper()
+	recorder := audit.NewRecorder(repository.NewAuditRepository(db))
+	for i := range n {
+		recorder.Record(context.Background(), audit.Event{
+			Action:     audit.ActionUserUpdate,
+			TargetType: audit.TargetUser,
+			TargetId:   i + 1,
+			Changes:    audit.Diff(audit.Snapshot{"name": "old"}, audit.Snapshot{"name": "new"}),
+		})
+	}
+}
+
+func TestAudit_DiffRedactsSecrets(t *testing.T) {
+	changes := audit.Diff(
+		audit.Snapshot{"name": "John", "password": "hash1", "roles": []string{"user"}, "mfa_secret": nil},
+		audit.Snapshot{"name": "John", "password": "hash2", "roles": []string{"admin"}, "mfa_secret": "ABC"},
+	)
+
+	if _, ok := changes["name"]; ok {
+		t.Error("Expected unchanged fields to be left out")
+	}
+	if changes["password"] != (audit.Change{From: audit.Redacted, To: audit.Redacted}) {
+		t.Errorf("Expected the password change to be redacted, got %+v", changes["password"])
+	}
+	if changes["mfa_secret"] != (audit.Change{To: audit.Redacted}) {
+		t.Errorf("Expected a new secret to show as redacted, got %+v", changes["mfa_secret"])
+	}
+	roles := changes["roles"]
+	if roles.From.([]string)[0] != "user" || roles.To.([]string)[0] != "admin" {
+		t.Errorf("Expected the role change in the clear, got %+v", roles)
+	}
+}
+
+func TestAudit_AppendChainsEntries(t *testing.T) {
+	db := openSQLite(t)
+	recordEvents(t, db, 3)
+
+	entries := auditEntries(t, db)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Id != int64(i+1) {
+			t.Errorf("Expected entry %d to have id %d, got %d", i, i+1, entry.Id)
+		}
+		if entry.Hash != entry.ChainHash() {
+			t.Errorf("Expected the stored hash of entry %d to match its contents", entry.Id)
+		}
+		if i > 0 && entry.PrevHash != entries[i-1].Hash {
+			t.Errorf("Expected entry %d to link to the hash of entry %d", entry.Id, entries[i-1].Id)
+		}
+	}
+	if entries[0].PrevHash != "" {
+		t.Errorf("Expected the first entry to have no previous hash, got %q", entries[0].PrevHash)
+	}
+	if entries[0].Changes != `{"name":{"from":"old","to":"new"}}` {
+		t.Errorf("Unexpected changes %s", entries[0].Changes)
+	}
+
+	result, err := audit.Verify(context.Background(), repository.NewAuditRepository(db))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.Valid() || result.Entries != 3 || result.HeadId != 3 || result.HeadHash != entries[2].Hash {
+		t.Errorf("Expected an intact chain of 3 entries, got %+v", result)
+	}
+}
+
+func TestAudit_ConcurrentAppendsKeepOneChain(t *testing.T) {
+	db := openSQLite(t)
+	recorder := audit.NewRecorder(repository.NewAuditRepository(db))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorder.Record(context.Background(), audit.Event{Action: audit.ActionUserDelete, TargetType: audit.TargetUser, TargetId: i + 1})
+		}()
+	}
+	wg.Wait()
+
+	result, err := audit.Verify(context.Background(), repository.NewAuditRepository(db))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.Valid() || result.Entries != 8 {
+		t.Errorf("Expected one intact chain of 8 entries, got %+v", result)
+	}
+}
+
+func TestAudit_VerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(db *gorm.DB) error
+		broken int64
+	}{
+		{"edited", func(db *gorm.DB) error {
+			return db.Model(&models.AuditLog{}).Where("id = ?", 2).Update("actor", "someone else").Error
+		}, 2},
+		{"rehashed", func(db *gorm.DB) error {
+			var entry models.AuditLog
+			db.First(&entry, 2)
+			entry.Actor = "someone else"
+			return db.Model(&entry).Updates(map[string]any{"actor": entry.Actor, "hash": entry.ChainHash()}).Error
+		}, 3},
+		{"removed", func(db *gorm.DB) error {
+			return db.Delete(&models.AuditLog{}, 2).Error
+		}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openSQLite(t)
+			recordEvents(t, db, 4)
+			if err := tt.tamper(db); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			result, err := audit.Verify(context.Background(), repository.NewAuditRepository(db))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.Valid() || result.BrokenAt != tt.broken || result.Problem == "" {
+				t.Errorf("Expected the chain to break at entry %d, got %+v", tt.broken, result)
+			}
+		})
+	}
+}
+
+func TestAudit_RecordTakesActorAndClientFromRequest(t *testing.T) {
+	db := openSQLite(t)
+	recorder := audit.NewRecorder(repository.NewAuditRepository(db))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID(discardLog), middleware.Client())
+	router.DELETE("/user/:id", func(c *gin.Context) {
+		ctx := audit.WithActor(c.Request.Context(), &audit.Actor{Id: 1, Name: "admin@example.com"})
+		recorder.Record(ctx, audit.Event{Action: audit.ActionUserDelete, TargetType: audit.TargetUser, TargetId: 7})
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/user/7", nil)
+	req.RemoteAddr = "203.0.113.9:1234"
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set(logger.RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := auditEntries(t, db)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.ActorId == nil || *entry.ActorId != 1 || entry.Actor != "admin@example.com" {
+		t.Errorf("Expected the actor of the context, got %v %q", entry.ActorId, entry.Actor)
+	}
+	if entry.IP != "203.0.113.9" || entry.UserAgent != "curl/8.0" || entry.RequestId != "req-1" {
+		t.Errorf("Expected the client and request ID, got %q %q %q", entry.IP, entry.UserAgent, entry.RequestId)
+	}
+	if entry.TargetType != audit.TargetUser || entry.TargetId != "7" {
+		t.Errorf("Expected user 7 as the target, got %s %s", entry.TargetType, entry.TargetId)
+	}
+}
+
+func TestAudit_UserUpdateRecordsRoleChangeSeparately(t *testing.T) {
+	db := openSQLite(t)
+	users := repository.NewUserRepository(db)
+	roles := repository.NewRoleRepository(db)
+	userRoles, _ := roles.GetByNames([]string{models.RoleUser})
+	user := &models.User{Name: "John", Email: "john@example.com", Password: "hash", Roles: userRoles}
+	if err := users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	service := services.NewUserService(users, roles, repository.NewTransactor(db), audit.NewRecorder(repository.NewAuditRepository(db)))
+	ctx := audit.WithActor(context.Background(), &audit.Actor{Id: 99, Name: "admin@example.com"})
+	role, password := models.RoleAdmin, "new-hash"
+	if err := service.UpdateUser(ctx, user.Id, nil, nil, &password, &role); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	entries := auditEntries(t, db)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Action != audit.ActionUserRoles || entries[0].Changes != `{"roles":{"from":["user"],"to":["admin"]}}` {
+		t.Errorf("Expected the role change first, got %s %s", entries[0].Action, entries[0].Changes)
+	}
+	if entries[1].Action != audit.ActionUserUpdate || entries[1].Changes != `{"password":{"from":"[redacted]","to":"[redacted]"}}` {
+		t.Errorf("Expected the redacted password change, got %s %s", entries[1].Action, entries[1].Changes)
+	}
+	if strings.Contains(entries[1].Changes, "new-hash") {
+		t.Error("Expected the password hash to stay out of the log")
+	}
+	for _, entry := range entries {
+		if entry.ActorId == nil || *entry.ActorId != 99 {
+			t.Errorf("Expected admin 99 as the actor of %s, got %v", entry.Action, entry.ActorId)
+		}
+	}
+}
+
+func TestAudit_LoginAttempts(t *testing.T) {
+	db := openSQLite(t)
+	config.ENV.LOCKOUT_THRESHOLD = 5
+	config.ENV.LOCKOUT_IP_THRESHOLD = 20
+	config.ENV.LOCKOUT_WINDOW = time.Minute
+	config.ENV.REFRESH_TOKEN_TTL = time.Hour
+	loadJWTKeys(t, utils.JWTKeyOptions{AccessSecret: "access", RefreshSecret: "refresh"})
+
+	passwordHash, _ := utils.HashBcrypt("password123")
+	now := time.Now()
+	user := &models.User{Name: "John", Email: "john@example.com", Password: passwordHash, EmailVerifiedAt: &now}
+	if err := repository.NewUserRepository(db).CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	service := newOutboxAuthService(db, mail.NewSender(mail.NewMemory(), loadTemplates(t)))
+	client := &dto.ClientInfo{IP: "203.0.113.9"}
+	attempts := []dto.LoginRequest{
+		{Email: "nobody@example.com", Password: "password123"},
+		{Email: "john@example.com", Password: "wrong"},
+		{Email: "john@example.com", Password: "password123"},
+	}
+	for _, attempt := range attempts {
+		service.Login(context.Background(), &attempt, client)
+	}
+
+	entries := auditEntries(t, db)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	want := []struct {
+		action, actor, reason, target string
+	}{
+		{audit.ActionLoginFailed, "nobody@example.com", "unknown email", ""},
+		{audit.ActionLoginFailed, "john@example.com", "wrong password", "1"},
+		{audit.ActionLogin, "john@example.com", "", "1"},
+	}
+	for i, w := range want {
+		entry := entries[i]
+		if entry.Action != w.action || entry.Actor != w.actor || entry.Reason != w.reason || entry.TargetId != w.target {
+			t.Errorf("Expected entry %d to be %+v, got %s %s %q %q", i+1, w, entry.Action, entry.Actor, entry.Reason, entry.TargetId)
+		}
+	}
+	if entries[0].ActorId != nil || entries[2].ActorId == nil || *entries[2].ActorId != user.Id {
+		t.Error("Expected only the successful login to name the user as the actor")
+	}
+}
+
+func setupAuditRouter(db *gorm.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := controllers.NewAuditController(services.NewAuditService(repository.NewAuditRepository(db)))
+	router.GET("/audit", controller.ListEntries)
+	router.GET("/audit/export", controller.ExportEntries)
+	router.GET("/audit/verify", controller.Verify)
+	return router
+}
+
+func TestAudit_ListFilters(t *testing.T) {
+	db := openSQLite(t)
+	recorder := audit.NewRecorder(repository.NewAuditRepository(db))
+	admin := &audit.Actor{Id: 1, Name: "admin@example.com"}
+	recorder.Record(context.Background(), audit.Event{Action: audit.ActionUserRoles, Actor: admin, TargetType: audit.TargetUser, TargetId: 2})
+	recorder.Record(context.Background(), audit.Event{Action: audit.ActionUserRoles, Actor: admin, TargetType: audit.TargetUser, TargetId: 3})
+	recorder.Record(context.Background(), audit.Event{Action: audit.ActionLoginFailed, Actor: &audit.Actor{Name: "x@example.com"}})
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 3},
+		{"?action=user.roles", 2},
+		{"?actor_id=1", 2},
+		{"?target_type=user&target_id=3", 1},
+		{"?until=2000-01-01", 0},
+		{"?per_page=1", 3},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		setupAuditRouter(db).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %q, got %d", http.StatusOK, tt.query, w.Code)
+		}
+
+		var body struct {
+			Data     []models.AuditLog `json:"data"`
+			Paginate dto.Paginate      `json:"paginate"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if body.Paginate.Total != tt.want {
+			t.Errorf("Expected %d entries for %q, got %d", tt.want, tt.query, body.Paginate.Total)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	setupAuditRouter(db).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit?from=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid date, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestAudit_ExportCSV(t *testing.T) {
+	db := openSQLite(t)
+	recorder := audit.NewRecorder(repository.NewAuditRepository(db))
+	ctx := audit.WithClient(context.Background(), "203.0.113.9", "=HYPERLINK(\"http://evil\")")
+	recorder.Record(ctx, audit.Event{Action: audit.ActionLoginFailed, Actor: &audit.Actor{Name: "x@example.com"}, Reason: "unknown email"})
+	recorder.Record(ctx, audit.Event{Action: audit.ActionUserDelete, TargetType: audit.TargetUser, TargetId: 2})
+
+	w := httptest.NewRecorder()
+	setupAuditRouter(db).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit/export?action=auth.login_failed", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Expected CSV, got %s", w.Header().Get("Content-Type"))
+	}
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) != 2 || rows[0][0] != "id" {
+		t.Fatalf("Expected a header and one row, got %v", rows)
+	}
+	if rows[1][2] != audit.ActionLoginFailed || rows[1][4] != "x@example.com" || rows[1][8] != "unknown email" {
+		t.Errorf("Unexpected row %v", rows[1])
+	}
+	if rows[1][10] != "'=HYPERLINK(\"http://evil\")" {
+		t.Errorf("Expected the formula to be escaped, got %s", rows[1][10])
+	}
+}
+
+func TestAudit_VerifyEndpoint(t *testing.T) {
+	db := openSQLite(t)
+	recordEvents(t, db, 2)
+	db.Model(&models.AuditLog{}).Where("id = ?", 1).Update("reason", "edited")
+
+	w := httptest.NewRecorder()
+	setupAuditRouter(db).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit/verify", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var body struct {
+		Data dto.AuditVerifyResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Data.Valid || body.Data.BrokenAt != 1 {
+		t.Errorf("Expected the edit of entry 1 to be reported, got %+v", body.Data)
+	}
+}
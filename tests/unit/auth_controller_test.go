@@ -2,11 +2,13 @@ package unit
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"restApi-GoGin/src/controllers"
 	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -14,52 +16,84 @@ import (
 
 // Mock untuk AuthService
 type MockAuthService struct {
-	registerFunc       func(*dto.RegisterRequest) error
-	loginFunc          func(*dto.LoginRequest) (*dto.LoginResponse, string, string, error)
-	refreshTokenFunc   func(string) (string, error)
-	forgotPasswordFunc func(*dto.ForgotPasswordRequest) error
-	verifyOTPFunc      func(*dto.VerifyOTPRequest) (*dto.VerifyOTPResponse, error)
-	resetPasswordFunc  func(*dto.ResetPasswordRequest) error
+	registerFunc           func(*dto.RegisterRequest) error
+	verifyEmailFunc        func(*dto.VerifyEmailRequest) error
+	resendVerificationFunc func(*dto.ResendVerificationRequest) error
+	loginFunc              func(*dto.LoginRequest, *dto.ClientInfo) (*dto.LoginResponse, string, string, error)
+	loginMFAFunc           func(*dto.LoginMFARequest, *dto.ClientInfo) (*dto.LoginResponse, string, string, error)
+	refreshTokenFunc       func(string, *dto.ClientInfo) (string, string, error)
+	logoutFunc             func(string) error
+	forgotPasswordFunc     func(*dto.ForgotPasswordRequest) error
+	verifyOTPFunc          func(*dto.VerifyOTPRequest, *dto.ClientInfo) (*dto.VerifyOTPResponse, error)
+	resetPasswordFunc      func(*dto.ResetPasswordRequest, *dto.ClientInfo) error
 }
 
-func (m *MockAuthService) Register(register *dto.RegisterRequest) error {
+func (m *MockAuthService) Register(ctx context.Context, register *dto.RegisterRequest) error {
 	if m.registerFunc != nil {
 		return m.registerFunc(register)
 	}
 	return nil
 }
 
-func (m *MockAuthService) Login(login *dto.LoginRequest) (*dto.LoginResponse, string, string, error) {
+func (m *MockAuthService) VerifyEmail(ctx context.Context, verifyEmail *dto.VerifyEmailRequest) error {
+	if m.verifyEmailFunc != nil {
+		return m.verifyEmailFunc(verifyEmail)
+	}
+	return nil
+}
+
+func (m *MockAuthService) ResendVerification(ctx context.Context, resend *dto.ResendVerificationRequest) error {
+	if m.resendVerificationFunc != nil {
+		return m.resendVerificationFunc(resend)
+	}
+	return nil
+}
+
+func (m *MockAuthService) Login(ctx context.Context, login *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
 	if m.loginFunc != nil {
-		return m.loginFunc(login)
+		return m.loginFunc(login, client)
+	}
+	return nil, "", "", nil
+}
+
+func (m *MockAuthService) LoginMFA(ctx context.Context, login *dto.LoginMFARequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
+	if m.loginMFAFunc != nil {
+		return m.loginMFAFunc(login, client)
 	}
 	return nil, "", "", nil
 }
 
-func (m *MockAuthService) RefreshToken(refreshToken string) (string, error) {
+func (m *MockAuthService) RefreshToken(ctx context.Context, refreshToken string, client *dto.ClientInfo) (string, string, error) {
 	if m.refreshTokenFunc != nil {
-		return m.refreshTokenFunc(refreshToken)
+		return m.refreshTokenFunc(refreshToken, client)
 	}
-	return "", nil
+	return "", "", nil
 }
 
-func (m *MockAuthService) ForgotPassword(forgotPassword *dto.ForgotPasswordRequest) error {
+func (m *MockAuthService) Logout(ctx context.Context, refreshToken string) error {
+	if m.logoutFunc != nil {
+		return m.logoutFunc(refreshToken)
+	}
+	return nil
+}
+
+func (m *MockAuthService) ForgotPassword(ctx context.Context, forgotPassword *dto.ForgotPasswordRequest) error {
 	if m.forgotPasswordFunc != nil {
 		return m.forgotPasswordFunc(forgotPassword)
 	}
 	return nil
 }
 
-func (m *MockAuthService) VerifyOTP(verifyOTP *dto.VerifyOTPRequest) (*dto.VerifyOTPResponse, error) {
+func (m *MockAuthService) VerifyOTP(ctx context.Context, verifyOTP *dto.VerifyOTPRequest, client *dto.ClientInfo) (*dto.VerifyOTPResponse, error) {
 	if m.verifyOTPFunc != nil {
-		return m.verifyOTPFunc(verifyOTP)
+		return m.verifyOTPFunc(verifyOTP, client)
 	}
 	return nil, nil
 }
 
-func (m *MockAuthService) ResetPassword(resetPassword *dto.ResetPasswordRequest) error {
+func (m *MockAuthService) ResetPassword(ctx context.Context, resetPassword *dto.ResetPasswordRequest, client *dto.ClientInfo) error {
 	if m.resetPasswordFunc != nil {
-		return m.resetPasswordFunc(resetPassword)
+		return m.resetPasswordFunc(resetPassword, client)
 	}
 	return nil
 }
@@ -139,15 +173,205 @@ func TestRegister_InvalidRequest(t *testing.T) {
 	}
 }
 
+func TestRegister_FieldValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{}
+	controller := controllers.NewAuthController(mockService)
+
+	registerData := map[string]interface{}{
+		"name":             "Test User",
+		"email":            "invalid-email",
+		"password":         "password123",
+		"password_confirm": "password123",
+	}
+
+	jsonData, _ := json.Marshal(registerData)
+	req, _ := http.NewRequest("POST", "/register", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.Register(c)
+
+	var response errorhandler.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if response.Error != errorhandler.CodeValidationFailed || len(response.Errors) != 1 {
+		t.Fatalf("Expected one validation error, got %+v", response)
+	}
+
+	fieldError := response.Errors[0]
+	if fieldError.Field != "email" || fieldError.Rule != "email" {
+		t.Errorf("Expected email rule on field email, got %+v", fieldError)
+	}
+
+	if fieldError.Message != "email must be a valid email address" {
+		t.Errorf("Expected English message, got '%s'", fieldError.Message)
+	}
+}
+
+func TestRegister_FieldValidationErrors_Indonesian(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{}
+	controller := controllers.NewAuthController(mockService)
+
+	req, _ := http.NewRequest("POST", "/register", bytes.NewBufferString(`{"email":"user@example.com","password":"password123","password_confirm":"password123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "id-ID,id;q=0.9,en;q=0.8")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.Register(c)
+
+	var response errorhandler.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(response.Errors) != 1 || response.Errors[0].Field != "name" || response.Errors[0].Rule != "required" {
+		t.Fatalf("Expected required rule on field name, got %+v", response.Errors)
+	}
+
+	if response.Errors[0].Message != "name wajib diisi" {
+		t.Errorf("Expected Indonesian message, got '%s'", response.Errors[0].Message)
+	}
+}
+
+func TestVerifyEmail_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		verifyEmailFunc: func(verifyEmail *dto.VerifyEmailRequest) error {
+			return nil
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	verifyEmailData := dto.VerifyEmailRequest{
+		Email: "test@example.com",
+		OTP:   "123456",
+	}
+
+	jsonData, _ := json.Marshal(verifyEmailData)
+	req, _ := http.NewRequest("POST", "/verify-email", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.VerifyEmail(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestVerifyEmail_InvalidCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		verifyEmailFunc: func(verifyEmail *dto.VerifyEmailRequest) error {
+			return &errorhandler.BadRequestError{Message: "invalid verification code"}
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	verifyEmailData := dto.VerifyEmailRequest{
+		Email: "test@example.com",
+		OTP:   "000000",
+	}
+
+	jsonData, _ := json.Marshal(verifyEmailData)
+	req, _ := http.NewRequest("POST", "/verify-email", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.VerifyEmail(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestLogin_EmailNotVerified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		loginFunc: func(login *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
+			return nil, "", "", &errorhandler.ForbiddenError{Message: "email not verified"}
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	loginData := dto.LoginRequest{
+		Email:    "test@example.com",
+		Password: "password123",
+	}
+
+	jsonData, _ := json.Marshal(loginData)
+	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.Login(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestLogin_Locked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		loginFunc: func(login *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
+			return nil, "", "", &errorhandler.LockedError{Message: "too many failed attempts, please try again later", RetryAfter: 60}
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	loginData := dto.LoginRequest{
+		Email:    "test@example.com",
+		Password: "wrongpassword",
+	}
+
+	jsonData, _ := json.Marshal(loginData)
+	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.Login(c)
+
+	if w.Code != http.StatusLocked {
+		t.Errorf("Expected status code %d, got %d", http.StatusLocked, w.Code)
+	}
+
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected Retry-After header '60', got '%s'", w.Header().Get("Retry-After"))
+	}
+}
+
 func TestLogin_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockAuthService{
-		loginFunc: func(login *dto.LoginRequest) (*dto.LoginResponse, string, string, error) {
+		loginFunc: func(login *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
 			return &dto.LoginResponse{
 				ID:    1,
 				Name:  "Test User",
 				Email: "test@example.com",
-				Role:  "user",
+				Roles: []string{"user"},
 			}, "access_token", "refresh_token", nil
 		},
 	}
@@ -187,6 +411,175 @@ func TestLogin_Success(t *testing.T) {
 	}
 }
 
+func TestLogin_BodyTokenDelivery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		loginFunc: func(login *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
+			return &dto.LoginResponse{ID: 1, Email: "test@example.com"}, "access_token", "refresh_token", nil
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	loginData := dto.LoginRequest{
+		Email:    "test@example.com",
+		Password: "password123",
+	}
+
+	jsonData, _ := json.Marshal(loginData)
+	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Token-Delivery", "body")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.Login(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if len(w.Result().Cookies()) != 0 {
+		t.Errorf("Expected no cookies, got %d", len(w.Result().Cookies()))
+	}
+
+	var response struct {
+		Data dto.LoginResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if response.Data.Tokens == nil {
+		t.Fatal("Expected tokens in response, got nil")
+	}
+
+	if response.Data.Tokens.AccessToken != "access_token" || response.Data.Tokens.RefreshToken != "refresh_token" {
+		t.Errorf("Unexpected tokens in response: %+v", response.Data.Tokens)
+	}
+
+	if response.Data.Tokens.TokenType != "Bearer" {
+		t.Errorf("Expected token type 'Bearer', got '%s'", response.Data.Tokens.TokenType)
+	}
+}
+
+func TestLogin_MFARequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		loginFunc: func(login *dto.LoginRequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
+			return &dto.LoginResponse{MFARequired: true, MFAToken: "mfa_token"}, "", "", nil
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	loginData := dto.LoginRequest{
+		Email:    "admin@example.com",
+		Password: "password123",
+	}
+
+	jsonData, _ := json.Marshal(loginData)
+	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.Login(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("Expected no cookies to be set before MFA verification")
+	}
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Failed to unmarshal response: %v", err)
+	}
+
+	data, _ := response["data"].(map[string]interface{})
+	if data["mfa_token"] != "mfa_token" {
+		t.Errorf("Expected mfa_token in response, got '%v'", data["mfa_token"])
+	}
+}
+
+func TestLoginMFA_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		loginMFAFunc: func(login *dto.LoginMFARequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
+			return &dto.LoginResponse{
+				ID:    1,
+				Name:  "Admin",
+				Email: "admin@example.com",
+				Roles: []string{"admin"},
+			}, "access_token", "refresh_token", nil
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	loginData := dto.LoginMFARequest{
+		MFAToken: "mfa_token",
+		Code:     "123456",
+	}
+
+	jsonData, _ := json.Marshal(loginData)
+	req, _ := http.NewRequest("POST", "/login/mfa", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.LoginMFA(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	cookies := map[string]string{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+
+	if cookies["accessToken"] != "access_token" || cookies["refreshToken"] != "refresh_token" {
+		t.Errorf("Expected session cookies to be set, got %v", cookies)
+	}
+}
+
+func TestLoginMFA_InvalidCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		loginMFAFunc: func(login *dto.LoginMFARequest, client *dto.ClientInfo) (*dto.LoginResponse, string, string, error) {
+			return nil, "", "", &errorhandler.UnauthorizedError{Message: "invalid MFA code"}
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	loginData := dto.LoginMFARequest{
+		MFAToken: "mfa_token",
+		Code:     "000000",
+	}
+
+	jsonData, _ := json.Marshal(loginData)
+	req, _ := http.NewRequest("POST", "/login/mfa", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.LoginMFA(c)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
 func TestLogout_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockAuthService{}
@@ -237,8 +630,8 @@ func TestLogout_Success(t *testing.T) {
 func TestRefreshToken_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockAuthService{
-		refreshTokenFunc: func(refreshToken string) (string, error) {
-			return "new_access_token", nil
+		refreshTokenFunc: func(refreshToken string, client *dto.ClientInfo) (string, string, error) {
+			return "new_access_token", "new_refresh_token", nil
 		},
 	}
 	controller := controllers.NewAuthController(mockService)
@@ -271,6 +664,144 @@ func TestRefreshToken_Success(t *testing.T) {
 	}
 }
 
+func TestRefreshToken_FromBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var received string
+	mockService := &MockAuthService{
+		refreshTokenFunc: func(refreshToken string, client *dto.ClientInfo) (string, string, error) {
+			received = refreshToken
+			return "new_access_token", "new_refresh_token", nil
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	jsonData, _ := json.Marshal(dto.RefreshTokenRequest{RefreshToken: "body_refresh_token"})
+	req, _ := http.NewRequest("POST", "/refresh-token", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Token-Delivery", "body")
+	req.AddCookie(&http.Cookie{
+		Name:  "refreshToken",
+		Value: "cookie_refresh_token",
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.RefreshToken(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if received != "body_refresh_token" {
+		t.Errorf("Expected refresh token from body, got '%s'", received)
+	}
+
+	var response struct {
+		Data dto.TokenResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if response.Data.AccessToken != "new_access_token" || response.Data.RefreshToken != "new_refresh_token" {
+		t.Errorf("Unexpected tokens in response: %+v", response.Data)
+	}
+}
+
+func TestRefreshToken_RotatesRefreshToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		refreshTokenFunc: func(refreshToken string, client *dto.ClientInfo) (string, string, error) {
+			return "new_access_token", "new_refresh_token", nil
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	req, _ := http.NewRequest("POST", "/refresh-token", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "refreshToken",
+		Value: "test_refresh_token",
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.RefreshToken(c)
+
+	cookies := map[string]string{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+
+	if cookies["accessToken"] != "new_access_token" {
+		t.Errorf("Expected accessToken cookie 'new_access_token', got '%v'", cookies["accessToken"])
+	}
+	if cookies["refreshToken"] != "new_refresh_token" {
+		t.Errorf("Expected refreshToken cookie 'new_refresh_token', got '%v'", cookies["refreshToken"])
+	}
+}
+
+func TestRefreshToken_ReuseDetected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockAuthService{
+		refreshTokenFunc: func(refreshToken string, client *dto.ClientInfo) (string, string, error) {
+			return "", "", &errorhandler.UnauthorizedError{Message: "refresh token reuse detected"}
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	req, _ := http.NewRequest("POST", "/refresh-token", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "refreshToken",
+		Value: "rotated_refresh_token",
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.RefreshToken(c)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestLogout_RevokesRefreshToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var revoked string
+	mockService := &MockAuthService{
+		logoutFunc: func(refreshToken string) error {
+			revoked = refreshToken
+			return nil
+		},
+	}
+	controller := controllers.NewAuthController(mockService)
+
+	req, _ := http.NewRequest("POST", "/logout", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "refreshToken",
+		Value: "test_refresh_token",
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	controller.Logout(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if revoked != "test_refresh_token" {
+		t.Errorf("Expected refresh token 'test_refresh_token' to be revoked, got '%v'", revoked)
+	}
+}
+
 func TestRefreshToken_NoToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockAuthService{}
@@ -324,8 +855,8 @@ func TestForgotPassword_Success(t *testing.T) {
 		t.Errorf("Failed to unmarshal response: %v", err)
 	}
 
-	if response["message"] != "OTP has been sent to your email" {
-		t.Errorf("Expected message 'OTP has been sent to your email', got '%v'", response["message"])
+	if response["message"] != "if an account exists for the email, an OTP has been sent to it" {
+		t.Errorf("Expected message 'if an account exists for the email, an OTP has been sent to it', got '%v'", response["message"])
 	}
 }
 
@@ -333,7 +864,7 @@ func TestVerifyOTP_Success(t *testing.T) {
 	// Setup
 	gin.SetMode(gin.TestMode)
 	mockService := &MockAuthService{
-		verifyOTPFunc: func(verifyOTP *dto.VerifyOTPRequest) (*dto.VerifyOTPResponse, error) {
+		verifyOTPFunc: func(verifyOTP *dto.VerifyOTPRequest, client *dto.ClientInfo) (*dto.VerifyOTPResponse, error) {
 			return &dto.VerifyOTPResponse{
 				ResetToken: "test_reset_token",
 			}, nil
@@ -378,7 +909,7 @@ func TestVerifyOTP_Success(t *testing.T) {
 func TestResetPassword_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockAuthService{
-		resetPasswordFunc: func(resetPassword *dto.ResetPasswordRequest) error {
+		resetPasswordFunc: func(resetPassword *dto.ResetPasswordRequest, client *dto.ClientInfo) error {
 			return nil
 		},
 	}
@@ -0,0 +1,46 @@
+package unit
+
+import (
+	"bytes"
+	"restApi-GoGin/src/cli"
+	"strings"
+	"testing"
+)
+
+func TestCLI_Routes(t *testing.T) {
+	var out bytes.Buffer
+	if err := cli.NewApp(strings.NewReader(""), &out).Run([]string{"routes"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, route := range []string{"GET     /api/ping", "POST    /api/login", "PUT     /api/user/:id/roles"} {
+		if !strings.Contains(out.String(), route) {
+			t.Errorf("Expected routes to list '%s', got:\n%s", route, out.String())
+		}
+	}
+}
+
+func TestCLI_UnknownCommand(t *testing.T) {
+	var out bytes.Buffer
+	err := cli.NewApp(strings.NewReader(""), &out).Run([]string{"user", "promote"})
+	if err == nil || !strings.Contains(err.Error(), `unknown command "user promote"`) {
+		t.Errorf("Expected unknown command error, got %v", err)
+	}
+
+	if !strings.Contains(out.String(), "reset-password") {
+		t.Errorf("Expected usage of the user commands, got:\n%s", out.String())
+	}
+}
+
+func TestCLI_Help(t *testing.T) {
+	var out bytes.Buffer
+	if err := cli.NewApp(strings.NewReader(""), &out).Run([]string{"help"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, command := range []string{"serve", "migrate", "seed", "user", "routes"} {
+		if !strings.Contains(out.String(), "  "+command+" ") {
+			t.Errorf("Expected help to list '%s', got:\n%s", command, out.String())
+		}
+	}
+}
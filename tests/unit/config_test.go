@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"restApi-GoGin/src/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfig_Layers(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env"), "APP_ENV=production\nPORT=9000\nDB_DRIVER=sqlite\nDB_DATABASE=from-file.db\nREFRESH_SECRET="+strings.Repeat("r", 32)+"\n")
+	writeFile(t, filepath.Join(dir, ".env.production"), "PORT=9100\nACCESS_TOKEN_TTL=5m\n")
+	writeFile(t, filepath.Join(dir, "access_secret"), testSecret+"\n")
+
+	t.Setenv("DB_DATABASE", "from-env.db")
+	t.Setenv("ACCESS_SECRET", "ignored because the file wins")
+	t.Setenv("ACCESS_SECRET_FILE", filepath.Join(dir, "access_secret"))
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.PORT != "9100" {
+		t.Errorf("Expected the profile file to override .env, got PORT %s", cfg.PORT)
+	}
+	if cfg.DB_DATABASE != "from-env.db" {
+		t.Errorf("Expected the environment to override .env, got DB_DATABASE %s", cfg.DB_DATABASE)
+	}
+	if cfg.ACCESS_SECRET != testSecret {
+		t.Errorf("Expected ACCESS_SECRET from ACCESS_SECRET_FILE, got '%s'", cfg.ACCESS_SECRET)
+	}
+	if !cfg.COOKIE_SECURE {
+		t.Error("Expected the production profile to default COOKIE_SECURE to true")
+	}
+	if cfg.ACCESS_TOKEN_TTL != 5*time.Minute || cfg.LOCKOUT_WINDOW != 15*time.Minute {
+		t.Errorf("Expected ACCESS_TOKEN_TTL 5m and default LOCKOUT_WINDOW 15m, got %v and %v", cfg.ACCESS_TOKEN_TTL, cfg.LOCKOUT_WINDOW)
+	}
+}
+
+func TestConfig_ListsEveryProblem(t *testing.T) {
+	t.Setenv("DB_DRIVER", "oracle")
+	t.Setenv("LOCKOUT_THRESHOLD", "0")
+	t.Setenv("ACCESS_SECRET", "")
+	t.Setenv("REFRESH_SECRET", "")
+	t.Setenv("SCHEDULE_PURGE_USERS", "0 25 * * *")
+
+	_, err := config.Load(t.TempDir())
+
+	var configErr *config.Error
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a config error, got %v", err)
+	}
+
+	for _, want := range []string{"DB_DRIVER must be one of", "LOCKOUT_THRESHOLD must be at least 1", "ACCESS_SECRET is required", "REFRESH_SECRET is required", "SCHEDULE_PURGE_USERS must be a cron expression"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention '%s', got:\n%v", want, err)
+		}
+	}
+}
+
+func TestConfig_RedactsSecrets(t *testing.T) {
+	cfg := &config.Config{DB_USERNAME: "app", DB_PASSWORD: "hunter2", ACCESS_SECRET: testSecret}
+
+	printed := cfg.String()
+	if strings.Contains(printed, "hunter2") || strings.Contains(printed, testSecret) {
+		t.Errorf("Expected secrets to be redacted, got %s", printed)
+	}
+	if !strings.Contains(printed, "DB_USERNAME=app") || !strings.Contains(printed, "DB_PASSWORD=[REDACTED]") {
+		t.Errorf("Expected plain settings and redacted secrets, got %s", printed)
+	}
+}
+
+func TestConfig_RedactsSecretsInLogs(t *testing.T) {
+	cfg := &config.Config{DB_USERNAME: "app", DB_PASSWORD: "hunter2", ACCESS_SECRET: testSecret}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("loaded configuration", "config", cfg)
+
+	logged := buf.String()
+	if strings.Contains(logged, "hunter2") || strings.Contains(logged, testSecret) {
+		t.Errorf("Expected secrets to be redacted, got %s", logged)
+	}
+	if !strings.Contains(logged, `"DB_PASSWORD":"[REDACTED]"`) {
+		t.Errorf("Expected redacted password in the log, got %s", logged)
+	}
+}
+
+func TestConfig_UnloadedUsesDefaults(t *testing.T) {
+	previous := config.ENV
+	config.ENV = nil
+	defer func() { config.ENV = previous }()
+
+	if config.Current().LOCKOUT_THRESHOLD != 5 || config.Current().OTP_MAX_ATTEMPTS != 5 {
+		t.Errorf("Expected the default lockout settings, got %+v", config.Current())
+	}
+	if !config.MFARequiredForRoles([]string{"admin"}) {
+		t.Error("Expected admins to need MFA by default")
+	}
+	if policy := config.EmailVerificationPolicy(); policy != config.EmailVerificationLogin {
+		t.Errorf("Expected the login verification policy by default, got %q", policy)
+	}
+}
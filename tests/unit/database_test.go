@@ -0,0 +1,189 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/migration"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openSQLite migrates a fresh SQLite database selected through DB_DRIVER.
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	previous := config.ENV
+	config.ENV = &config.Config{
+		DB_DRIVER:   config.DriverSQLite,
+		DB_DATABASE: filepath.Join(t.TempDir(), "test.db"),
+	}
+	t.Cleanup(func() { config.ENV = previous })
+
+	dialector, err := config.Dialector()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := config.RunMigration(db); err != nil {
+		t.Fatalf("Expected migrations to run, got %v", err)
+	}
+
+	return db
+}
+
+func TestDatabase_UnsupportedDriver(t *testing.T) {
+	previous := config.ENV
+	config.ENV = &config.Config{DB_DRIVER: "oracle"}
+	defer func() { config.ENV = previous }()
+
+	if _, err := config.Dialector(); err == nil {
+		t.Error("Expected an error for an unsupported driver")
+	}
+}
+
+func TestDatabase_SQLiteMigrationsUpAndDown(t *testing.T) {
+	db := openSQLite(t)
+
+	var admin models.Role
+	if err := db.Preload("Permissions").Where("name = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+		t.Fatalf("Expected the admin role to be seeded, got %v", err)
+	}
+	if len(admin.Permissions) != len(models.DefaultPermissions) {
+		t.Errorf("Expected admin to have %d permissions, got %d", len(models.DefaultPermissions), len(admin.Permissions))
+	}
+
+	migrator, err := migration.NewMigrator(db, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := migrator.To(0); err != nil {
+		t.Fatalf("Expected rollback to succeed, got %v", err)
+	}
+	if db.Migrator().HasTable(&models.User{}) {
+		t.Error("Expected users table to be dropped")
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			t.Errorf("Expected migration %d to be pending", status.Version)
+		}
+	}
+}
+
+func TestDatabase_SQLiteUserFilters(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewUserRepository(db)
+
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	users := []models.User{
+		{Name: "John Doe", Email: "john@example.com", Password: "x"},
+		{Name: "Jane 100% Real", Email: "jane@example.com", Password: "x"},
+		{Name: "Gone", Email: "gone@example.com", Password: "x", DeletedAt: deletedAt},
+	}
+	for i := range users {
+		if err := repo.CreateUser(context.Background(), &users[i]); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	tests := []struct {
+		filter repository.UserFilter
+		want   int64
+	}{
+		{repository.UserFilter{Name: "JOHN"}, 1},
+		{repository.UserFilter{Name: "100%"}, 1},
+		{repository.UserFilter{Name: "%"}, 1},
+		{repository.UserFilter{Email: "example.COM"}, 2},
+		{repository.UserFilter{Status: repository.UserStatusDeleted}, 1},
+		{repository.UserFilter{Status: repository.UserStatusAll}, 3},
+	}
+
+	for _, tt := range tests {
+		tt.filter.Limit = 10
+		_, total, err := repo.GetAllUsers(context.Background(), tt.filter)
+		if err != nil {
+			t.Fatalf("Expected no error for %+v, got %v", tt.filter, err)
+		}
+		if total != tt.want {
+			t.Errorf("Expected %d users for %+v, got %d", tt.want, tt.filter, total)
+		}
+	}
+
+	authRepo := repository.NewAuthRepository(db)
+	if _, err := authRepo.GetUserById(context.Background(), users[2].Id); err == nil {
+		t.Error("Expected deleted user to be hidden from GetUserById")
+	}
+}
+
+func TestDatabase_SQLiteSessionRefreshKeepsRevocation(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewSessionRepository(db)
+	now := time.Now()
+	db.Create(&models.Session{Id: "session", UserId: 1, IP: "10.0.0.1", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)})
+
+	expiresAt := now.Add(2 * time.Hour)
+	refreshed, err := repo.Refresh(context.Background(), "session", expiresAt, "10.0.0.2")
+	if err != nil || !refreshed {
+		t.Fatalf("Expected an active session to be refreshed, got %v, %v", refreshed, err)
+	}
+	session, _ := repo.GetById(context.Background(), "session")
+	if session.IP != "10.0.0.2" || !session.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Expected the IP and expiry to be updated, got %+v", session)
+	}
+
+	if err := repo.Revoke(context.Background(), "session"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	refreshed, err = repo.Refresh(context.Background(), "session", expiresAt.Add(time.Hour), "10.0.0.3")
+	if err != nil || refreshed {
+		t.Fatalf("Expected a revoked session not to be refreshed, got %v, %v", refreshed, err)
+	}
+	session, _ = repo.GetById(context.Background(), "session")
+	if session.RevokedAt == nil || session.IP != "10.0.0.2" {
+		t.Errorf("Expected the session to stay revoked and unchanged, got %+v", session)
+	}
+}
+
+func TestDatabase_SQLiteTOTPStepUsedOnce(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewUserRepository(db)
+	user := &models.User{Name: "John", Email: "john@example.com", Password: "x"}
+	if err := repo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		step int64
+		want bool
+	}{
+		{10, true},
+		{10, false},
+		{9, false},
+		{11, true},
+	}
+	for _, tt := range tests {
+		used, err := repo.UseTOTPStep(context.Background(), user.Id, tt.step)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if used != tt.want {
+			t.Errorf("Expected step %d to be used: %v, got %v", tt.step, tt.want, used)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/middleware"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+func serveWithErrors(handler gin.HandlerFunc) (*httptest.ResponseRecorder, errorhandler.ErrorResponse) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.GET("/test", handler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+
+	var response errorhandler.ErrorResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func TestErrorsMiddleware_RecoversPanic(t *testing.T) {
+	w, response := serveWithErrors(func(c *gin.Context) {
+		panic("boom")
+	})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	if response.Error != errorhandler.CodeInternal || response.Status != "error" {
+		t.Errorf("Expected internal_error envelope, got %+v", response)
+	}
+}
+
+func TestErrorsMiddleware_MapsRecordNotFound(t *testing.T) {
+	w, response := serveWithErrors(func(c *gin.Context) {
+		_ = c.Error(gorm.ErrRecordNotFound)
+	})
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	if response.Error != errorhandler.CodeNotFound {
+		t.Errorf("Expected error code %s, got %s", errorhandler.CodeNotFound, response.Error)
+	}
+}
+
+func TestErrorsMiddleware_MapsValidationErrors(t *testing.T) {
+	w, response := serveWithErrors(func(c *gin.Context) {
+		req := struct {
+			Email string `validate:"required,email"`
+		}{}
+		_ = c.Error(validator.New().Struct(req))
+	})
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if response.Error != errorhandler.CodeValidationFailed {
+		t.Errorf("Expected error code %s, got %s", errorhandler.CodeValidationFailed, response.Error)
+	}
+}
+
+func TestErrorsMiddleware_HidesUnknownErrors(t *testing.T) {
+	w, response := serveWithErrors(func(c *gin.Context) {
+		_ = c.Error(errors.New("dial tcp 10.0.0.5:3306: connection refused"))
+	})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	if response.Message != "internal server error" {
+		t.Errorf("Expected generic message, got '%s'", response.Message)
+	}
+}
+
+func TestErrorHandler_LockedSetsRetryAfter(t *testing.T) {
+	w, response := serveWithErrors(func(c *gin.Context) {
+		errorhandler.ErrorHandler(c, &errorhandler.LockedError{Message: "account locked", RetryAfter: 30})
+	})
+
+	if w.Code != http.StatusLocked {
+		t.Errorf("Expected status code %d, got %d", http.StatusLocked, w.Code)
+	}
+
+	if w.Header().Get("Retry-After") != "30" || response.Error != errorhandler.CodeLocked {
+		t.Errorf("Expected Retry-After 30 and error code locked, got '%s' and %s", w.Header().Get("Retry-After"), response.Error)
+	}
+}
@@ -0,0 +1,213 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/health"
+	"restApi-GoGin/src/migration"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type healthResponse struct {
+	Message string        `json:"message"`
+	Data    health.Report `json:"data"`
+}
+
+func setupHealthRouter(checks *health.Registry) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := controllers.NewHealthController(checks)
+	router.GET("/healthz", controller.Live)
+	router.GET("/readyz", controller.Ready)
+	return router
+}
+
+func getHealth(t *testing.T, router *gin.Engine, path string) (int, healthResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var body healthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return w.Code, body
+}
+
+func passing() health.Checker {
+	return health.CheckerFunc(func(context.Context) error { return nil })
+}
+
+func failing(message string) health.Checker {
+	return health.CheckerFunc(func(context.Context) error { return errors.New(message) })
+}
+
+func TestHealth_LiveIgnoresChecks(t *testing.T) {
+	checks := health.NewRegistry()
+	checks.Register("database", failing("connection refused"), health.Options{})
+
+	code, body := getHealth(t, setupHealthRouter(checks), "/healthz")
+	if code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, code)
+	}
+	if body.Message != "alive" {
+		t.Errorf("Expected message alive, got %q", body.Message)
+	}
+}
+
+func TestHealth_ReadyReportsEachCheck(t *testing.T) {
+	checks := health.NewRegistry()
+	checks.Register("database", passing(), health.Options{})
+	checks.Register("migrations", passing(), health.Options{})
+
+	code, body := getHealth(t, setupHealthRouter(checks), "/readyz")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+	}
+	if body.Data.Status != health.StatusOK {
+		t.Errorf("Expected overall status ok, got %q", body.Data.Status)
+	}
+	for _, name := range []string{"database", "migrations"} {
+		result, ok := body.Data.Checks[name]
+		if !ok {
+			t.Errorf("Expected a result for %s", name)
+			continue
+		}
+		if result.Status != health.StatusOK || result.LatencyMs < 0 {
+			t.Errorf("Expected %s to pass with a latency, got %+v", name, result)
+		}
+	}
+}
+
+func TestHealth_ReadyFailsOnRequiredCheck(t *testing.T) {
+	checks := health.NewRegistry()
+	checks.Register("database", failing("connection refused"), health.Options{})
+	checks.Register("migrations", passing(), health.Options{})
+
+	code, body := getHealth(t, setupHealthRouter(checks), "/readyz")
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, got %d", http.StatusServiceUnavailable, code)
+	}
+	database := body.Data.Checks["database"]
+	if database.Status != health.StatusFail || database.Error != "connection refused" {
+		t.Errorf("Expected database to fail with its error, got %+v", database)
+	}
+	if body.Data.Checks["migrations"].Status != health.StatusOK {
+		t.Errorf("Expected migrations to still be reported as ok")
+	}
+}
+
+func TestHealth_OptionalCheckOnlyWarns(t *testing.T) {
+	checks := health.NewRegistry()
+	checks.Register("database", passing(), health.Options{})
+	checks.Register("mail", failing("no route to host"), health.Options{Optional: true})
+
+	code, body := getHealth(t, setupHealthRouter(checks), "/readyz")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+	}
+	if body.Data.Status != health.StatusWarn {
+		t.Errorf("Expected overall status warn, got %q", body.Data.Status)
+	}
+	if body.Data.Checks["mail"].Status != health.StatusWarn {
+		t.Errorf("Expected mail to warn, got %+v", body.Data.Checks["mail"])
+	}
+}
+
+func TestHealth_CheckTimesOut(t *testing.T) {
+	checks := health.NewRegistry()
+	checks.Register("database", health.CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), health.Options{Timeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	code, body := getHealth(t, setupHealthRouter(checks), "/readyz")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the check to be cut off by its timeout, took %v", elapsed)
+	}
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, code)
+	}
+	if body.Data.Checks["database"].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Expected a deadline error, got %q", body.Data.Checks["database"].Error)
+	}
+}
+
+func TestHealth_DatabaseAndMigrationChecks(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+
+	if err := health.Database(db).Check(ctx); err != nil {
+		t.Errorf("Expected database check to pass, got %v", err)
+	}
+	if err := health.Migrations(db).Check(ctx); err != nil {
+		t.Errorf("Expected migration check to pass on a migrated database, got %v", err)
+	}
+
+	migrator, err := migration.NewMigrator(db, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := migrator.Down(1); err != nil {
+		t.Fatalf("Expected rollback to succeed, got %v", err)
+	}
+	if err := health.Migrations(db).Check(ctx); err == nil {
+		t.Error("Expected migration check to fail with pending migrations")
+	}
+}
+
+func TestHealth_SMTPCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "QUIT") {
+				fmt.Fprint(conn, "221 Bye\r\n")
+				return
+			}
+			fmt.Fprint(conn, "250 localhost\r\n")
+		}
+	}()
+
+	if err := health.SMTP(listener.Addr().String(), false).Check(context.Background()); err != nil {
+		t.Errorf("Expected SMTP check to pass, got %v", err)
+	}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := closed.Addr().String()
+	closed.Close()
+
+	if err := health.SMTP(addr, false).Check(context.Background()); err == nil {
+		t.Error("Expected SMTP check to fail when nothing listens")
+	}
+}
@@ -0,0 +1,338 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"restApi-GoGin/src/cron"
+	"restApi-GoGin/src/jobs"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var testJobOptions = jobs.Options{
+	Workers:      1,
+	PollInterval: 10 * time.Millisecond,
+	MaxAttempts:  3,
+	BaseBackoff:  time.Millisecond,
+	MaxBackoff:   2 * time.Millisecond,
+	Timeout:      time.Second,
+}
+
+// runJobWorker runs a job worker with the handlers of registry until the
+// test ends.
+func runJobWorker(t *testing.T, db *gorm.DB, registry jobs.Registry) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		jobs.NewWorker(repository.NewJobRepository(db), registry, testJobOptions, discardLog).Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitFor polls condition until it holds or the wait times out.
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func countJobs(db *gorm.DB) int64 {
+	var count int64
+	db.Model(&models.Job{}).Count(&count)
+	return count
+}
+
+func TestJobs_CronNext(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 7, 30, 0, time.UTC) // a Wednesday
+	cases := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 15, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, 2, 1, 3, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 7", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"5,50 10 * * *", time.Date(2024, 1, 31, 10, 50, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2024, 1, 31, 10, 9, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		schedule, err := cron.Parse(c.spec)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %v", c.spec, err)
+			continue
+		}
+		if next := schedule.Next(from); !next.Equal(c.next) {
+			t.Errorf("Expected %q to fire next at %v, got %v", c.spec, c.next, next)
+		}
+	}
+}
+
+func TestJobs_CronRejectsInvalidSpecs(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "@every 1ms", "@often"} {
+		if _, err := cron.Parse(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestJobs_WorkerRunsJobWithPayload(t *testing.T) {
+	db := openSQLite(t)
+	received := make(chan string, 1)
+	registry := jobs.NewRegistry()
+	registry.Register("greet", func(ctx context.Context, payload json.RawMessage) error {
+		var data struct{ Name string }
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return err
+		}
+		received <- data.Name
+		return nil
+	})
+
+	queue := jobs.NewQueue(repository.NewJobRepository(db), 3)
+	if _, err := queue.Enqueue(context.Background(), "greet", map[string]string{"Name": "John"}, jobs.EnqueueOptions{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	runJobWorker(t, db, registry)
+
+	select {
+	case name := <-received:
+		if name != "John" {
+			t.Errorf("Expected the payload to reach the handler, got %q", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the job to run")
+	}
+	waitFor(t, "the finished job to be deleted", func() bool { return countJobs(db) == 0 })
+}
+
+func TestJobs_FailuresRetryThenDie(t *testing.T) {
+	db := openSQLite(t)
+	var attempts atomic.Int32
+	registry := jobs.NewRegistry()
+	registry.Register("flaky", func(context.Context, json.RawMessage) error {
+		if attempts.Add(1) == 2 {
+			panic("boom")
+		}
+		return errors.New("remote unavailable")
+	})
+
+	queue := jobs.NewQueue(repository.NewJobRepository(db), 3)
+	if _, err := queue.Enqueue(context.Background(), "flaky", nil, jobs.EnqueueOptions{UniqueKey: "flaky"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	runJobWorker(t, db, registry)
+
+	var job models.Job
+	waitFor(t, "the job to go dead", func() bool {
+		return db.Where("status = ?", models.JobDead).First(&job).Error == nil
+	})
+	if job.Attempts != 3 || job.LastError != "remote unavailable" {
+		t.Errorf("Expected 3 attempts and the last error, got %+v", job)
+	}
+	if job.UniqueKey != nil {
+		t.Error("Expected a dead job to give up its unique key")
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("Expected a panic to count as a failed attempt and 3 runs in all, got %d", got)
+	}
+}
+
+func TestJobs_UniqueAndDelayedJobs(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewJobRepository(db)
+	queue := jobs.NewQueue(repo, 3)
+	ctx := context.Background()
+
+	later := time.Now().Add(time.Hour)
+	enqueued, err := queue.Enqueue(ctx, "report", nil, jobs.EnqueueOptions{UniqueKey: "report:daily", RunAt: later})
+	if err != nil || !enqueued {
+		t.Fatalf("Expected the job to be enqueued, got %v (error %v)", enqueued, err)
+	}
+	if enqueued, _ := queue.Enqueue(ctx, "report", nil, jobs.EnqueueOptions{UniqueKey: "report:daily"}); enqueued {
+		t.Error("Expected a job with a pending unique key to be skipped")
+	}
+
+	names := []string{"report"}
+	if claimed, _ := repo.Claim(ctx, names, time.Now(), time.Minute, 10); len(claimed) != 0 {
+		t.Errorf("Expected a delayed job not to be claimed early, got %d", len(claimed))
+	}
+	claimed, err := repo.Claim(ctx, names, later, time.Minute, 10)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("Expected the job to be claimed once due, got %d (error %v)", len(claimed), err)
+	}
+	if other, _ := repo.Claim(ctx, []string{"other"}, later, time.Minute, 10); len(other) != 0 {
+		t.Error("Expected jobs without a handler not to be claimed")
+	}
+
+	if err := repo.Complete(ctx, claimed[0].Id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if enqueued, _ := queue.Enqueue(ctx, "report", nil, jobs.EnqueueOptions{UniqueKey: "report:daily"}); !enqueued {
+		t.Error("Expected the unique key to be free once the job is done")
+	}
+}
+
+func TestJobs_SchedulersEnqueueEachRunOnce(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewJobRepository(db)
+	queue := jobs.NewQueue(repo, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{}, 2)
+	for range 2 {
+		scheduler := jobs.NewScheduler(repo, queue, repository.NewTransactor(db), 10*time.Millisecond, discardLog)
+		if err := scheduler.Add(jobs.PurgeExpiredCodes, "* * * * *"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		go func() {
+			scheduler.Run(ctx)
+			done <- struct{}{}
+		}()
+	}
+	defer func() {
+		cancel()
+		<-done
+		<-done
+	}()
+
+	var schedule models.JobSchedule
+	waitFor(t, "the schedule to be saved", func() bool {
+		return db.First(&schedule, "name = ?", jobs.PurgeExpiredCodes).Error == nil
+	})
+	// Make the run due now rather than at the next minute.
+	db.Model(&schedule).Update("next_run_at", time.Now().Add(-time.Second))
+
+	waitFor(t, "the due run to be enqueued", func() bool { return countJobs(db) > 0 })
+	time.Sleep(100 * time.Millisecond)
+	if count := countJobs(db); count != 1 {
+		t.Errorf("Expected one job for the run, got %d", count)
+	}
+
+	db.First(&schedule, "name = ?", jobs.PurgeExpiredCodes)
+	if schedule.LastRunAt == nil || !schedule.NextRunAt.After(time.Now()) {
+		t.Errorf("Expected the schedule to move on to its next run, got %+v", schedule)
+	}
+}
+
+func TestJobs_SchedulerRejectsSpecThatNeverRuns(t *testing.T) {
+	scheduler := jobs.NewScheduler(nil, nil, nil, time.Second, discardLog)
+	if err := scheduler.Add("never", "0 0 30 2 *"); err == nil {
+		t.Error("Expected a schedule that never fires to be rejected")
+	}
+}
+
+func builtinJob(t *testing.T, db *gorm.DB, name string) jobs.Handler {
+	t.Helper()
+	registry := jobs.NewRegistry()
+	jobs.RegisterBuiltins(registry, repository.NewUserRepository(db), repository.NewSessionRepository(db), 24*time.Hour, discardLog)
+	handler, ok := registry.Lookup(name)
+	if !ok {
+		t.Fatalf("Expected the built-in job %s", name)
+	}
+	return handler
+}
+
+func TestJobs_PurgeExpiredCodes(t *testing.T) {
+	db := openSQLite(t)
+	code := "123456"
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Minute)
+	expired := &models.User{Name: "Expired", Email: "expired@example.com", Password: "hash", OTPCode: &code, OTPCodeExp: &past, OTPAttempts: 2, ResetToken: &code, ResetTokenExp: &past}
+	valid := &models.User{Name: "Valid", Email: "valid@example.com", Password: "hash", OTPCode: &code, OTPCodeExp: &future}
+	for _, user := range []*models.User{expired, valid} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if err := builtinJob(t, db, jobs.PurgeExpiredCodes)(context.Background(), nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var cleared, kept models.User
+	db.First(&cleared, expired.Id)
+	if cleared.OTPCode != nil || cleared.OTPCodeExp != nil || cleared.OTPAttempts != 0 || cleared.ResetToken != nil || cleared.ResetTokenExp != nil {
+		t.Errorf("Expected the expired code and token to be erased, got %+v", cleared)
+	}
+	db.First(&kept, valid.Id)
+	if kept.OTPCode == nil {
+		t.Error("Expected a code that has not expired to be kept")
+	}
+}
+
+func TestJobs_PurgeExpiredSessions(t *testing.T) {
+	db := openSQLite(t)
+	now := time.Now()
+	sessions := []models.Session{
+		{Id: "expired", UserId: 1, LastSeenAt: now, ExpiresAt: now.Add(-time.Hour)},
+		{Id: "active", UserId: 1, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+	}
+	tokens := []models.RefreshToken{
+		{UserId: 1, FamilyId: "expired", JtiHash: "a", IssuedAt: now, ExpiresAt: now.Add(-time.Hour)},
+		{UserId: 1, FamilyId: "active", JtiHash: "b", IssuedAt: now, ExpiresAt: now.Add(time.Hour), RevokedAt: &now},
+	}
+	db.Create(&sessions)
+	db.Create(&tokens)
+
+	if err := builtinJob(t, db, jobs.PurgeExpiredSessions)(context.Background(), nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var sessionIds, tokenHashes []string
+	db.Model(&models.Session{}).Pluck("id", &sessionIds)
+	db.Model(&models.RefreshToken{}).Pluck("jti_hash", &tokenHashes)
+	if len(sessionIds) != 1 || sessionIds[0] != "active" || len(tokenHashes) != 1 || tokenHashes[0] != "b" {
+		t.Errorf("Expected only the unexpired session and token to remain, got %v and %v", sessionIds, tokenHashes)
+	}
+}
+
+func TestJobs_PurgeDeletedUsers(t *testing.T) {
+	db := openSQLite(t)
+	longAgo := gorm.DeletedAt{Time: time.Now().Add(-48 * time.Hour), Valid: true}
+	recently := gorm.DeletedAt{Time: time.Now().Add(-time.Hour), Valid: true}
+	purged := &models.User{Name: "Gone", Email: "gone@example.com", Password: "hash", DeletedAt: longAgo}
+	kept := &models.User{Name: "Recent", Email: "recent@example.com", Password: "hash", DeletedAt: recently}
+	for _, user := range []*models.User{purged, kept} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	var role models.Role
+	db.Where("name = ?", models.RoleUser).First(&role)
+	db.Model(purged).Association("Roles").Append(&role)
+	db.Create(&models.Session{Id: "gone", UserId: purged.Id, LastSeenAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)})
+
+	if err := builtinJob(t, db, jobs.PurgeDeletedUsers)(context.Background(), nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var emails []string
+	db.Unscoped().Model(&models.User{}).Pluck("email", &emails)
+	if len(emails) != 1 || emails[0] != "recent@example.com" {
+		t.Errorf("Expected only the user inside the retention window to remain, got %v", emails)
+	}
+	var leftovers int64
+	db.Table("user_roles").Where("user_id = ?", purged.Id).Count(&leftovers)
+	if leftovers != 0 || db.First(&models.Session{}, "id = ?", "gone").Error == nil {
+		t.Error("Expected the purged user's roles and sessions to be deleted")
+	}
+}
@@ -0,0 +1,168 @@
+package unit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/utils"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func writeEd25519Key(t *testing.T, name string) string {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	return writePrivateKey(t, name, private)
+}
+
+func writeRSAKey(t *testing.T, name string) string {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	return writePrivateKey(t, name, private)
+}
+
+func writePrivateKey(t *testing.T, name string, key any) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+
+	return path
+}
+
+func loadJWTKeys(t *testing.T, opts utils.JWTKeyOptions) {
+	t.Helper()
+	if err := utils.LoadJWTKeys(opts); err != nil {
+		t.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	t.Cleanup(func() { _ = utils.LoadJWTKeys(utils.JWTKeyOptions{}) })
+}
+
+func TestJWKS_PublishesActiveKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	loadJWTKeys(t, utils.JWTKeyOptions{
+		SigningKeyFile:   writeEd25519Key(t, "current.pem"),
+		SigningKeyId:     "current",
+		VerificationKeys: "previous=" + writeRSAKey(t, "previous.pem"),
+	})
+	controller := controllers.NewJWKSController()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/.well-known/jwks.json", nil)
+
+	controller.JWKS(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var jwks dto.JWKSet
+	if err := json.Unmarshal(w.Body.Bytes(), &jwks); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(jwks.Keys))
+	}
+
+	if jwks.Keys[0].Kid != "current" || jwks.Keys[0].Alg != "EdDSA" || jwks.Keys[0].X == "" {
+		t.Errorf("Unexpected Ed25519 key: %+v", jwks.Keys[0])
+	}
+
+	if jwks.Keys[1].Kid != "previous" || jwks.Keys[1].Alg != "RS256" || jwks.Keys[1].N == "" {
+		t.Errorf("Unexpected RSA key: %+v", jwks.Keys[1])
+	}
+}
+
+func TestJWKS_EmptyWithSharedSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	loadJWTKeys(t, utils.JWTKeyOptions{AccessSecret: "access", RefreshSecret: "refresh"})
+	controller := controllers.NewJWKSController()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/.well-known/jwks.json", nil)
+
+	controller.JWKS(c)
+
+	var jwks dto.JWKSet
+	if err := json.Unmarshal(w.Body.Bytes(), &jwks); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if jwks.Keys == nil || len(jwks.Keys) != 0 {
+		t.Errorf("Expected an empty key list, got %+v", jwks.Keys)
+	}
+}
+
+func TestVerifyAccessToken_KeyRotation(t *testing.T) {
+	user := &models.User{Id: 1}
+	oldKey := writeEd25519Key(t, "old.pem")
+	loadJWTKeys(t, utils.JWTKeyOptions{SigningKeyFile: oldKey, SigningKeyId: "old"})
+
+	token, err := utils.GenerateAccessToken(user, "session")
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	// During the overlap window tokens signed with the old key stay valid.
+	newKey := writeRSAKey(t, "new.pem")
+	loadJWTKeys(t, utils.JWTKeyOptions{
+		SigningKeyFile:   newKey,
+		VerificationKeys: "old=" + oldKey + "@" + time.Now().Add(time.Hour).Format(time.RFC3339),
+	})
+
+	if claims, err := utils.VerifyAccessToken(token); err != nil || claims.UserId != 1 {
+		t.Errorf("Expected old token to verify during overlap, got %v", err)
+	}
+
+	// Once the overlap window has passed the old key is rejected.
+	loadJWTKeys(t, utils.JWTKeyOptions{
+		SigningKeyFile:   newKey,
+		VerificationKeys: "old=" + oldKey + "@" + time.Now().Add(-time.Minute).Format(time.RFC3339),
+	})
+
+	if _, err := utils.VerifyAccessToken(token); err == nil {
+		t.Error("Expected old token to be rejected after overlap window")
+	}
+}
+
+func TestVerifyAccessToken_RejectsRefreshToken(t *testing.T) {
+	loadJWTKeys(t, utils.JWTKeyOptions{SigningKeyFile: writeEd25519Key(t, "key.pem")})
+
+	refreshToken, _, err := utils.GenerateRefreshToken(&models.User{Id: 1}, "family")
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	if _, err := utils.VerifyAccessToken(refreshToken); err == nil {
+		t.Error("Expected refresh token to be rejected as access token")
+	}
+}
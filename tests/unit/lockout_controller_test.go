@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/errorhandler"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MockLockoutService struct {
+	checkFunc           func(keys ...string) error
+	registerFailureFunc func(keys ...string) error
+	resetFunc           func(keys ...string) error
+	unlockUserFunc      func(userId int) error
+}
+
+func (m *MockLockoutService) Check(ctx context.Context, keys ...string) error {
+	if m.checkFunc != nil {
+		return m.checkFunc(keys...)
+	}
+	return nil
+}
+
+func (m *MockLockoutService) RegisterFailure(ctx context.Context, keys ...string) error {
+	if m.registerFailureFunc != nil {
+		return m.registerFailureFunc(keys...)
+	}
+	return nil
+}
+
+func (m *MockLockoutService) Reset(ctx context.Context, keys ...string) error {
+	if m.resetFunc != nil {
+		return m.resetFunc(keys...)
+	}
+	return nil
+}
+
+func (m *MockLockoutService) UnlockUser(ctx context.Context, userId int) error {
+	if m.unlockUserFunc != nil {
+		return m.unlockUserFunc(userId)
+	}
+	return nil
+}
+
+func TestUnlockUser_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var unlocked int
+	mockService := &MockLockoutService{
+		unlockUserFunc: func(userId int) error {
+			unlocked = userId
+			return nil
+		},
+	}
+	controller := controllers.NewLockoutController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/user/2/unlock", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "2"}}
+
+	controller.UnlockUser(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if unlocked != 2 {
+		t.Errorf("Expected user 2 to be unlocked, got %d", unlocked)
+	}
+}
+
+func TestUnlockUser_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockLockoutService{
+		unlockUserFunc: func(userId int) error {
+			return &errorhandler.NotFoundError{Message: "user not found"}
+		},
+	}
+	controller := controllers.NewLockoutController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/user/99/unlock", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "99"}}
+
+	controller.UnlockUser(c)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestUnlockUser_InvalidUserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockLockoutService{}
+	controller := controllers.NewLockoutController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = []gin.Param{{Key: "id", Value: "invalid"}}
+
+	controller.UnlockUser(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
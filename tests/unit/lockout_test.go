@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/mail"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+)
+
+func configureLockout() {
+	config.ENV.LOCKOUT_THRESHOLD = 5
+	config.ENV.LOCKOUT_IP_THRESHOLD = 1000
+	config.ENV.LOCKOUT_WINDOW = time.Minute
+	config.ENV.LOCKOUT_BASE_DURATION = time.Minute
+	config.ENV.LOCKOUT_MAX_DURATION = time.Hour
+	config.ENV.OTP_MAX_ATTEMPTS = 3
+}
+
+func TestLockout_ConcurrentFailuresAreAllCounted(t *testing.T) {
+	db := openSQLite(t)
+	configureLockout()
+	lockout := services.NewLockoutService(repository.NewAuthThrottleRepository(db), repository.NewUserRepository(db))
+	key := services.AccountLockoutKey("john@example.com")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- lockout.RegisterFailure(context.Background(), key)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	var throttle models.AuthThrottle
+	if err := db.Where("throttle_key = ?", key).First(&throttle).Error; err != nil {
+		t.Fatalf("Expected one throttle row, got %v", err)
+	}
+	if throttle.Failures != 20 {
+		t.Errorf("Expected 20 failures, got %d", throttle.Failures)
+	}
+	if throttle.LockedUntil == nil || !throttle.LockedUntil.After(time.Now()) {
+		t.Error("Expected the key to be locked")
+	}
+	if err := lockout.Check(context.Background(), key); err == nil {
+		t.Error("Expected Check to report the lock")
+	}
+}
+
+func TestLockout_FailuresOutsideWindowStartOver(t *testing.T) {
+	db := openSQLite(t)
+	configureLockout()
+	lockout := services.NewLockoutService(repository.NewAuthThrottleRepository(db), repository.NewUserRepository(db))
+	key := services.AccountLockoutKey("john@example.com")
+	db.Create(&models.AuthThrottle{Key: key, Failures: 4, LastFailureAt: time.Now().Add(-time.Hour)})
+
+	if err := lockout.RegisterFailure(context.Background(), key); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var throttle models.AuthThrottle
+	db.Where("throttle_key = ?", key).First(&throttle)
+	if throttle.Failures != 1 || throttle.LockedUntil != nil {
+		t.Errorf("Expected the count to start over without a lock, got %d failures", throttle.Failures)
+	}
+}
+
+func TestLockout_ConcurrentOTPGuessesAreBounded(t *testing.T) {
+	db := openSQLite(t)
+	configureLockout()
+	config.ENV.LOCKOUT_THRESHOLD = 1000
+
+	hash, _ := utils.HashBcrypt("123456")
+	exp := time.Now().Add(time.Hour)
+	user := &models.User{Name: "John", Email: "john@example.com", Password: "hash", OTPCode: &hash, OTPCodeExp: &exp}
+	if err := repository.NewUserRepository(db).CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service := newOutboxAuthService(db, mail.NewSender(mail.NewMemory(), loadTemplates(t)))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = service.VerifyOTP(context.Background(), &dto.VerifyOTPRequest{Email: user.Email, OTP: "000000"}, nil)
+		}()
+	}
+	wg.Wait()
+
+	var stored models.User
+	db.First(&stored, user.Id)
+	if stored.OTPAttempts != config.ENV.OTP_MAX_ATTEMPTS {
+		t.Errorf("Expected %d attempts, got %d", config.ENV.OTP_MAX_ATTEMPTS, stored.OTPAttempts)
+	}
+	if stored.OTPCode != nil {
+		t.Error("Expected the OTP to be burnt")
+	}
+	if _, err := service.VerifyOTP(context.Background(), &dto.VerifyOTPRequest{Email: user.Email, OTP: "123456"}, nil); err == nil {
+		t.Error("Expected the right OTP to be rejected once burnt")
+	}
+}
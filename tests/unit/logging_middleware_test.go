@@ -0,0 +1,223 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupLoggingRouter wires the middleware as routes.NewRouter does and logs
+// JSON lines to buf.
+func setupLoggingRouter(buf *bytes.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID(logger.New(buf, logger.FormatJSON, "debug")), middleware.AccessLog(), middleware.Errors())
+	return router
+}
+
+// logLines decodes the JSON lines written to buf.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to decode log line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestRequestID_GeneratedWhenMissing(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupLoggingRouter(&buf)
+	var seen string
+	router.GET("/test", func(c *gin.Context) {
+		seen = logger.RequestID(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	id := w.Header().Get(logger.RequestIDHeader)
+	if len(id) != 32 {
+		t.Errorf("Expected a generated 32 character request ID, got %q", id)
+	}
+	if seen != id {
+		t.Errorf("Expected handler to see request ID %q, got %q", id, seen)
+	}
+}
+
+func TestRequestID_PropagatesValidHeader(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupLoggingRouter(&buf)
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(logger.RequestIDHeader, "upstream-123")
+	router.ServeHTTP(w, req)
+
+	if id := w.Header().Get(logger.RequestIDHeader); id != "upstream-123" {
+		t.Errorf("Expected request ID upstream-123, got %q", id)
+	}
+}
+
+func TestRequestID_ReplacesInvalidHeader(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupLoggingRouter(&buf)
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, invalid := range []string{"has spaces", "line\"break", strings.Repeat("a", 129)} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(logger.RequestIDHeader, invalid)
+		router.ServeHTTP(w, req)
+
+		if id := w.Header().Get(logger.RequestIDHeader); id == invalid || len(id) != 32 {
+			t.Errorf("Expected %q to be replaced with a generated ID, got %q", invalid, id)
+		}
+	}
+}
+
+func TestAccessLog_LogsRouteUserStatusAndLatency(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupLoggingRouter(&buf)
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.Set("user", &models.User{Id: 42})
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set(logger.RequestIDHeader, "req-1")
+	router.ServeHTTP(w, req)
+
+	lines := logLines(t, &buf)
+	if len(lines) != 1 {
+		t.Fatalf("Expected one access log line, got %d", len(lines))
+	}
+	entry := lines[0]
+	expected := map[string]any{
+		"msg":        "request",
+		"level":      "INFO",
+		"method":     "GET",
+		"route":      "/users/:id",
+		"path":       "/users/7",
+		"status":     float64(http.StatusOK),
+		"user_id":    float64(42),
+		"request_id": "req-1",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, entry[key])
+		}
+	}
+	if _, ok := entry["latency_ms"].(float64); !ok {
+		t.Errorf("Expected latency_ms in the log line, got %v", entry)
+	}
+}
+
+func TestErrorHandler_LogsInternalErrorWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupLoggingRouter(&buf)
+	router.GET("/test", func(c *gin.Context) {
+		errorhandler.ErrorHandler(c, errors.New("dial tcp 10.0.0.5:3306: connection refused"))
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(logger.RequestIDHeader, "req-2")
+	router.ServeHTTP(w, req)
+
+	if strings.Contains(w.Body.String(), "10.0.0.5") {
+		t.Errorf("Expected the internal error to stay out of the response, got %s", w.Body.String())
+	}
+	var response errorhandler.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.RequestID != "req-2" {
+		t.Errorf("Expected request ID req-2 in the response, got %q", response.RequestID)
+	}
+
+	var logged map[string]any
+	for _, entry := range logLines(t, &buf) {
+		if entry["msg"] == "request failed" {
+			logged = entry
+		}
+	}
+	if logged == nil {
+		t.Fatal("Expected the internal error to be logged")
+	}
+	if logged["request_id"] != "req-2" || !strings.Contains(logged["error"].(string), "connection refused") {
+		t.Errorf("Expected the error logged with its request ID, got %v", logged)
+	}
+}
+
+func TestErrorHandler_HidesInternalServerErrorMessage(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupLoggingRouter(&buf)
+	router.GET("/test", func(c *gin.Context) {
+		errorhandler.ErrorHandler(c, &errorhandler.InternalServerError{Message: "secret db detail"})
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(logger.RequestIDHeader, "req-3")
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if strings.Contains(w.Body.String(), "secret db detail") {
+		t.Errorf("Expected the internal message to stay out of the response, got %s", w.Body.String())
+	}
+	var response errorhandler.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Message != "internal server error" || response.RequestID != "req-3" {
+		t.Errorf("Expected the generic message with request ID req-3, got %+v", response)
+	}
+	if !strings.Contains(buf.String(), "secret db detail") {
+		t.Error("Expected the internal message to be logged")
+	}
+}
+
+func TestErrorHandler_DoesNotLogClientErrors(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupLoggingRouter(&buf)
+	router.GET("/test", func(c *gin.Context) {
+		errorhandler.ErrorHandler(c, &errorhandler.BadRequestError{Message: "bad"})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	for _, entry := range logLines(t, &buf) {
+		if entry["level"] == slog.LevelError.String() {
+			t.Errorf("Expected no error log for a client error, got %v", entry)
+		}
+	}
+}
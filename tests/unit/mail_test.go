@@ -0,0 +1,239 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/mail"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadTemplates(t *testing.T) *mail.Templates {
+	t.Helper()
+	templates, err := mail.LoadTemplates("en")
+	if err != nil {
+		t.Fatalf("Expected the embedded templates to parse, got %v", err)
+	}
+	return templates
+}
+
+// fakeSMTP serves one SMTP session on a local port and sends the commands
+// and message data it received on the returned channel. STARTTLS is only
+// advertised when startTLS is set, and never actually supported.
+func fakeSMTP(t *testing.T, startTLS bool) (host, port string, received <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	session := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var transcript strings.Builder
+		defer func() { session <- transcript.String() }()
+
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			transcript.WriteString(line)
+			switch {
+			case inData:
+				if line == ".\r\n" {
+					inData = false
+					fmt.Fprint(conn, "250 queued\r\n")
+				}
+			case strings.HasPrefix(line, "EHLO"):
+				if startTLS {
+					fmt.Fprint(conn, "250-localhost\r\n250 STARTTLS\r\n")
+				} else {
+					fmt.Fprint(conn, "250 localhost\r\n")
+				}
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				fmt.Fprint(conn, "354 go ahead\r\n")
+			case strings.HasPrefix(line, "QUIT"):
+				fmt.Fprint(conn, "221 bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "250 ok\r\n")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(listener.Addr().String())
+	return host, port, session
+}
+
+func TestMail_RendersBestMatchingLocale(t *testing.T) {
+	templates := loadTemplates(t)
+	data := mail.OTPData{Name: "Budi", Code: "123456", ExpiresIn: 10 * time.Minute}
+
+	msg, err := templates.Render("id-ID,id;q=0.9,en;q=0.8", mail.TemplatePasswordReset, data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msg.Subject != "Atur ulang kata sandi Anda" {
+		t.Errorf("Expected the Indonesian subject, got %q", msg.Subject)
+	}
+
+	msg, err = templates.Render("fr-FR", mail.TemplatePasswordReset, data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msg.Subject != "Reset your password" {
+		t.Errorf("Expected unknown locales to fall back to English, got %q", msg.Subject)
+	}
+}
+
+func TestMail_RendersHTMLAndTextWithExpiry(t *testing.T) {
+	templates := loadTemplates(t)
+	data := mail.OTPData{Name: "<b>Mallory</b>", Code: "654321", ExpiresIn: 7 * time.Minute}
+
+	msg, err := templates.Render("", mail.TemplateEmailVerification, data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for part, body := range map[string]string{"HTML": msg.HTML, "text": msg.Text} {
+		if !strings.Contains(body, "654321") || !strings.Contains(body, "expire in 7 minutes") {
+			t.Errorf("Expected the %s body to carry the code and the 7 minute expiry, got %s", part, body)
+		}
+	}
+	if strings.Contains(msg.HTML, "<b>Mallory</b>") {
+		t.Error("Expected the name to be escaped in the HTML body")
+	}
+	if !strings.Contains(msg.HTML, `class="otp"`) {
+		t.Error("Expected the HTML body to be wrapped in the shared layout")
+	}
+}
+
+func TestMail_SenderUsesRequestLocale(t *testing.T) {
+	mailer := mail.NewMemory()
+	sender := mail.NewSender(mailer, loadTemplates(t))
+
+	ctx := mail.WithLocale(context.Background(), "id")
+	if err := sender.SendTemplate(ctx, "budi@example.com", mail.TemplateEmailVerification, mail.OTPData{Code: "111111", ExpiresIn: time.Minute}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	if messages[0].To[0] != "budi@example.com" || messages[0].Subject != "Verifikasi email Anda" {
+		t.Errorf("Expected an Indonesian message to the recipient, got %+v", messages[0])
+	}
+}
+
+func TestMail_FileDriverWritesMaildir(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := mail.New(mail.Options{Driver: mail.DriverFile, From: "App <no-reply@example.com>", Dir: dir})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	msg := &mail.Message{To: []string{"john@example.com"}, Subject: "Hello", HTML: "<p>Hi</p>", Text: "Hi"}
+	if err := mailer.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "new", "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 message in new, got %d", len(files))
+	}
+	content, _ := os.ReadFile(files[0])
+	for _, expected := range []string{"From: App <no-reply@example.com>", "To: john@example.com", "multipart/alternative", "text/plain", "text/html"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected %q in the message, got %s", expected, content)
+		}
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "tmp", "*")); len(leftovers) != 0 {
+		t.Errorf("Expected tmp to be empty, got %v", leftovers)
+	}
+}
+
+func TestMail_SMTPDeliversMessage(t *testing.T) {
+	host, port, received := fakeSMTP(t, false)
+	mailer := mail.NewSMTP(mail.SMTPOptions{Host: host, Port: port, TLS: mail.TLSNone})
+
+	msg := &mail.Message{From: "App <no-reply@example.com>", To: []string{"john@example.com"}, Subject: "Hello", Text: "Hi John"}
+	if err := mailer.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	transcript := <-received
+	for _, expected := range []string{"MAIL FROM:<no-reply@example.com>", "RCPT TO:<john@example.com>", "Subject: Hello", "Hi John"} {
+		if !strings.Contains(transcript, expected) {
+			t.Errorf("Expected %q in the SMTP session, got %s", expected, transcript)
+		}
+	}
+}
+
+func TestMail_SMTPRequiresStartTLS(t *testing.T) {
+	host, port, _ := fakeSMTP(t, false)
+	mailer := mail.NewSMTP(mail.SMTPOptions{Host: host, Port: port, TLS: mail.TLSStartTLS})
+
+	msg := &mail.Message{From: "no-reply@example.com", To: []string{"john@example.com"}, Text: "Hi"}
+	err := mailer.Send(context.Background(), msg)
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("Expected the send to fail without STARTTLS, got %v", err)
+	}
+}
+
+func TestMail_ForgotPasswordStatesConfiguredExpiry(t *testing.T) {
+	db := openSQLite(t)
+	config.ENV.OTP_TTL = 12 * time.Minute
+
+	users := repository.NewUserRepository(db)
+	if err := users.CreateUser(context.Background(), &models.User{Name: "John", Email: "john@example.com", Password: "hash"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	mailer := mail.NewMemory()
+	service := services.NewAuthService(
+		repository.NewAuthRepository(db),
+		users,
+		repository.NewRefreshTokenRepository(db),
+		repository.NewSessionRepository(db),
+		repository.NewRecoveryCodeRepository(db),
+		services.NewLockoutService(repository.NewAuthThrottleRepository(db), users),
+		repository.NewRoleRepository(db),
+		mail.NewSender(mailer, loadTemplates(t)),
+		repository.NewTransactor(db),
+		audit.NewRecorder(repository.NewAuditRepository(db)),
+	)
+
+	if err := service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: "john@example.com"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0].Text, "expire in 12 minutes") {
+		t.Errorf("Expected the email to state OTP_TTL, got %s", messages[0].Text)
+	}
+}
@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/metrics"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scrape returns the metrics exposition, sending token when it is set.
+func scrape(t *testing.T, router *gin.Engine, token string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	body, _ := io.ReadAll(w.Body)
+	return w.Code, string(body)
+}
+
+func setupMetricsRouter(token string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Metrics(), middleware.Errors())
+	router.GET("/metrics", middleware.StaticToken(token), gin.WrapH(metrics.Handler()))
+	return router
+}
+
+func TestMetrics_RecordsRequestsByRouteTemplate(t *testing.T) {
+	router := setupMetricsRouter("")
+	router.GET("/metrics-test/items/:id", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	for _, path := range []string{"/metrics-test/items/1", "/metrics-test/items/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	_, body := scrape(t, router, "")
+	expected := `app_http_requests_total{method="GET",route="/metrics-test/items/:id",status="202"} 2`
+	if !strings.Contains(body, expected) {
+		t.Errorf("Expected %s in the metrics", expected)
+	}
+	if !strings.Contains(body, `app_http_request_duration_seconds_count{method="GET",route="/metrics-test/items/:id",status="202"} 2`) {
+		t.Error("Expected a latency histogram for the route")
+	}
+	if strings.Contains(body, "/metrics-test/items/1") {
+		t.Error("Expected raw paths to stay out of the labels")
+	}
+}
+
+func TestMetrics_TokenProtectsEndpoint(t *testing.T) {
+	router := setupMetricsRouter("scrape-secret")
+
+	if code, _ := scrape(t, router, ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, code)
+	}
+	if code, _ := scrape(t, router, "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d with a wrong token, got %d", http.StatusUnauthorized, code)
+	}
+	if code, body := scrape(t, router, "scrape-secret"); code != http.StatusOK || !strings.Contains(body, "go_goroutines") {
+		t.Errorf("Expected metrics with the right token, got status %d", code)
+	}
+}
+
+func TestMetrics_ClassifiesAuthResults(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected string
+	}{
+		{nil, metrics.ResultSuccess},
+		{&errorhandler.NotFoundError{Message: "invalid email or password"}, metrics.ResultFailure},
+		{&errorhandler.UnauthorizedError{Message: "invalid code"}, metrics.ResultFailure},
+		{&errorhandler.LockedError{Message: "locked", RetryAfter: 60}, metrics.ResultLocked},
+		{&errorhandler.InternalServerError{Message: "db down"}, metrics.ResultError},
+		{errors.New("unexpected"), metrics.ResultError},
+	}
+
+	for _, tc := range cases {
+		if result := metrics.Result(tc.err); result != tc.expected {
+			t.Errorf("Expected %v to be classified as %s, got %s", tc.err, tc.expected, result)
+		}
+	}
+}
+
+func TestMetrics_AuthCounters(t *testing.T) {
+	metrics.Login("password", false, &errorhandler.NotFoundError{Message: "invalid email or password"})
+	metrics.Login("password", true, nil)
+	metrics.Lockout(metrics.ScopeIP)
+
+	_, body := scrape(t, setupMetricsRouter(""), "")
+	for _, series := range []string{
+		`app_auth_logins_total{method="password",result="failure"}`,
+		`app_auth_logins_total{method="password",result="mfa_required"}`,
+		`app_auth_lockouts_total{scope="ip"}`,
+	} {
+		if !strings.Contains(body, series) {
+			t.Errorf("Expected series %s in the metrics", series)
+		}
+	}
+}
+
+func TestMetrics_InstrumentsDatabase(t *testing.T) {
+	db := openSQLite(t)
+	if err := metrics.InstrumentDB(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var users []models.User
+	if err := db.Find(&users).Error; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, body := scrape(t, setupMetricsRouter(""), "")
+	if !strings.Contains(body, `app_db_query_duration_seconds_count{operation="query",table="users"}`) {
+		t.Error("Expected query durations labelled by operation and table")
+	}
+	if !strings.Contains(body, `go_sql_open_connections{db_name="sqlite"}`) {
+		t.Error("Expected connection pool statistics")
+	}
+}
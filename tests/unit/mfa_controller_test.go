@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/models"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MockMFAService struct {
+	enrollFunc                  func(user *models.User) (*dto.MFAEnrollResponse, error)
+	confirmFunc                 func(user *models.User, req *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error)
+	disableFunc                 func(user *models.User, req *dto.MFACodeRequest) error
+	regenerateRecoveryCodesFunc func(user *models.User, req *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error)
+}
+
+func (m *MockMFAService) Enroll(ctx context.Context, user *models.User) (*dto.MFAEnrollResponse, error) {
+	if m.enrollFunc != nil {
+		return m.enrollFunc(user)
+	}
+	return nil, nil
+}
+
+func (m *MockMFAService) Confirm(ctx context.Context, user *models.User, req *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error) {
+	if m.confirmFunc != nil {
+		return m.confirmFunc(user, req)
+	}
+	return nil, nil
+}
+
+func (m *MockMFAService) Disable(ctx context.Context, user *models.User, req *dto.MFACodeRequest) error {
+	if m.disableFunc != nil {
+		return m.disableFunc(user, req)
+	}
+	return nil
+}
+
+func (m *MockMFAService) RegenerateRecoveryCodes(ctx context.Context, user *models.User, req *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error) {
+	if m.regenerateRecoveryCodesFunc != nil {
+		return m.regenerateRecoveryCodesFunc(user, req)
+	}
+	return nil, nil
+}
+
+func TestMFAEnroll_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockMFAService{
+		enrollFunc: func(user *models.User) (*dto.MFAEnrollResponse, error) {
+			return &dto.MFAEnrollResponse{
+				Secret:          "JBSWY3DPEHPK3PXP",
+				ProvisioningURI: "otpauth://totp/Test:" + user.Email + "?secret=JBSWY3DPEHPK3PXP",
+			}, nil
+		},
+	}
+	controller := controllers.NewMFAController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/mfa/enroll", nil)
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com"})
+
+	controller.Enroll(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Errorf("Failed to unmarshal response: %v", err)
+	}
+
+	data, _ := response["data"].(map[string]interface{})
+	if !strings.HasPrefix(data["provisioning_uri"].(string), "otpauth://totp/") {
+		t.Errorf("Expected otpauth provisioning URI, got '%v'", data["provisioning_uri"])
+	}
+}
+
+func TestMFAEnroll_InvalidUserContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockMFAService{}
+	controller := controllers.NewMFAController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	controller.Enroll(c)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestMFAConfirm_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockMFAService{
+		confirmFunc: func(user *models.User, req *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error) {
+			return &dto.MFARecoveryCodesResponse{RecoveryCodes: []string{"abcde-fghjk"}}, nil
+		},
+	}
+	controller := controllers.NewMFAController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com"})
+	c.Request = httptest.NewRequest("POST", "/mfa/confirm", strings.NewReader(`{"code":"123456"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.Confirm(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestMFAConfirm_ValidationError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockMFAService{}
+	controller := controllers.NewMFAController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com"})
+	c.Request = httptest.NewRequest("POST", "/mfa/confirm", strings.NewReader(`{}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.Confirm(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestMFADisable_Forbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockMFAService{
+		disableFunc: func(user *models.User, req *dto.MFACodeRequest) error {
+			return &errorhandler.ForbiddenError{Message: "MFA is required for your role"}
+		},
+	}
+	controller := controllers.NewMFAController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com", Roles: []models.Role{{Name: "admin"}}, MFAEnabled: true})
+	c.Request = httptest.NewRequest("POST", "/mfa/disable", strings.NewReader(`{"code":"123456"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.Disable(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestMFARegenerateRecoveryCodes_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockMFAService{
+		regenerateRecoveryCodesFunc: func(user *models.User, req *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, error) {
+			return &dto.MFARecoveryCodesResponse{RecoveryCodes: []string{"abcde-fghjk", "mnpqr-stuvw"}}, nil
+		},
+	}
+	controller := controllers.NewMFAController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com", MFAEnabled: true})
+	c.Request = httptest.NewRequest("POST", "/mfa/recovery-codes", strings.NewReader(`{"code":"123456"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.RegenerateRecoveryCodes(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
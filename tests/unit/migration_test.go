@@ -0,0 +1,418 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"restApi-GoGin/src/config"
+	"restApi-GoGin/src/migration"
+	"restApi-GoGin/src/models"
+
+	"gorm.io/gorm"
+)
+
+// newMigrator returns a migrator writing to out for a SQLite database that
+// has every migration rolled back.
+func newMigrator(t *testing.T, out *bytes.Buffer) (*gorm.DB, *migration.Migrator) {
+	t.Helper()
+	db := openSQLite(t)
+
+	migrator, err := migration.NewMigrator(db, out)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := migrator.To(0); err != nil {
+		t.Fatalf("Expected rollback to succeed, got %v", err)
+	}
+	out.Reset()
+
+	return db, migrator
+}
+
+// appliedVersions lists the versions Status reports as applied.
+func appliedVersions(t *testing.T, migrator *migration.Migrator) []int64 {
+	t.Helper()
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	applied := []int64{}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			applied = append(applied, status.Version)
+		}
+	}
+	return applied
+}
+
+func TestMigration_UpAppliesEverythingOnce(t *testing.T) {
+	var out bytes.Buffer
+	db, migrator := newMigrator(t, &out)
+
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if applied := appliedVersions(t, migrator); !reflect.DeepEqual(applied, []int64{1, 2, 3, 4}) {
+		t.Errorf("Expected every migration to be applied, got %v", applied)
+	}
+	for _, table := range []string{"users", "mail_outbox", "jobs", "audit_logs"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("Expected table %s to exist", table)
+		}
+	}
+
+	before, _ := migrator.Status()
+	out.Reset()
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Expected a second Up to succeed, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected a second Up to run nothing, got %q", out.String())
+	}
+	after, _ := migrator.Status()
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("Expected a second Up to leave the status unchanged, got %v and %v", before, after)
+	}
+
+	pending, err := migrator.Pending(context.Background())
+	if err != nil || len(pending) != 0 {
+		t.Errorf("Expected nothing pending, got %v %v", pending, err)
+	}
+}
+
+func TestMigration_DownRollsBackMostRecent(t *testing.T) {
+	db := openSQLite(t)
+	migrator, err := migration.NewMigrator(db, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := migrator.Down(1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if db.Migrator().HasTable("audit_logs") {
+		t.Error("Expected audit_logs to be dropped")
+	}
+	if !db.Migrator().HasTable("jobs") {
+		t.Error("Expected jobs to be kept")
+	}
+
+	if err := migrator.Down(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if applied := appliedVersions(t, migrator); !reflect.DeepEqual(applied, []int64{1}) {
+		t.Errorf("Expected only the initial schema to be applied, got %v", applied)
+	}
+
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(pending) != 3 || pending[0].Version != 2 {
+		t.Errorf("Expected migrations 2 to 4 to be pending, got %v", pending)
+	}
+}
+
+func TestMigration_ToVersion(t *testing.T) {
+	db := openSQLite(t)
+	migrator, err := migration.NewMigrator(db, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := migrator.To(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if applied := appliedVersions(t, migrator); !reflect.DeepEqual(applied, []int64{1, 2}) {
+		t.Errorf("Expected migrations 1 and 2 to be applied, got %v", applied)
+	}
+	if db.Migrator().HasTable("jobs") || !db.Migrator().HasTable("mail_outbox") {
+		t.Error("Expected the schema of version 2")
+	}
+
+	if err := migrator.To(3); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if applied := appliedVersions(t, migrator); !reflect.DeepEqual(applied, []int64{1, 2, 3}) {
+		t.Errorf("Expected migrations 1 to 3 to be applied, got %v", applied)
+	}
+
+	if err := migrator.To(99); err == nil {
+		t.Error("Expected an error for an unknown version")
+	}
+}
+
+func TestMigration_DryRunChangesNothing(t *testing.T) {
+	var out bytes.Buffer
+	db, migrator := newMigrator(t, &out)
+	migrator.DryRun = true
+
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "-- 000001 initial_schema (up)") || !strings.Contains(out.String(), "CREATE TABLE `users`") {
+		t.Errorf("Expected the SQL to be printed, got %q", out.String())
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("Expected a dry run to create no tables")
+	}
+	if applied := appliedVersions(t, migrator); len(applied) != 0 {
+		t.Errorf("Expected a dry run to record nothing, got %v", applied)
+	}
+
+	migrator.DryRun = false
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	migrator.DryRun = true
+	out.Reset()
+	if err := migrator.Down(1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "DROP TABLE `audit_logs`") {
+		t.Errorf("Expected the rollback SQL to be printed, got %q", out.String())
+	}
+	if !db.Migrator().HasTable("audit_logs") || len(appliedVersions(t, migrator)) != 4 {
+		t.Error("Expected a dry run rollback to change nothing")
+	}
+}
+
+func TestMigration_BaselineRecordsWithoutRunning(t *testing.T) {
+	var out bytes.Buffer
+	db, migrator := newMigrator(t, &out)
+
+	if err := migrator.Baseline(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if applied := appliedVersions(t, migrator); !reflect.DeepEqual(applied, []int64{1, 2}) {
+		t.Errorf("Expected migrations 1 and 2 to be recorded, got %v", applied)
+	}
+	if db.Migrator().HasTable("users") || db.Migrator().HasTable("mail_outbox") {
+		t.Error("Expected Baseline to run no migration")
+	}
+}
+
+func TestMigration_BaselineAdoptsLegacySchema(t *testing.T) {
+	var out bytes.Buffer
+	db, _ := newMigrator(t, &out)
+	if err := db.Migrator().DropTable("schema_migrations"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A database built by AutoMigrate before SQL migrations existed.
+	if err := db.AutoMigrate(&models.Permission{}, &models.Role{}, &models.User{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	legacy := models.User{Name: "John", Email: "john@example.com", Password: "hash"}
+	db.Create(&legacy)
+
+	if err := config.RunMigration(db); err != nil {
+		t.Fatalf("Expected the legacy schema to be adopted, got %v", err)
+	}
+
+	migrator, err := migration.NewMigrator(db, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if applied := appliedVersions(t, migrator); !reflect.DeepEqual(applied, []int64{1, 2, 3, 4}) {
+		t.Errorf("Expected every migration to be applied, got %v", applied)
+	}
+	if err := db.First(&models.User{}, legacy.Id).Error; err != nil {
+		t.Errorf("Expected the legacy user to be kept, got %v", err)
+	}
+
+	if err := config.RunMigration(db); err != nil {
+		t.Errorf("Expected a second run to succeed, got %v", err)
+	}
+}
+
+func TestMigration_RunMigrationReportsSeedingErrors(t *testing.T) {
+	db := openSQLite(t)
+	// A permission the admin role has to be granted again, with nowhere to
+	// record the grant.
+	if err := db.Migrator().DropTable("role_permissions"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := db.Where("name = ?", models.PermissionRolesManage).Delete(&models.Permission{}).Error; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := config.RunMigration(db); err == nil {
+		t.Error("Expected the failed grant to be reported")
+	}
+}
+
+func TestMigration_LockedSharesTheLockedConnection(t *testing.T) {
+	var out bytes.Buffer
+	db, migrator := newMigrator(t, &out)
+
+	err := migrator.Locked(func(conn *gorm.DB, locked *migration.Migrator) error {
+		if err := locked.Up(); err != nil {
+			return err
+		}
+		var roles int64
+		return conn.Model(&models.Role{}).Count(&roles).Error
+	})
+	if err != nil {
+		t.Fatalf("Expected Up and queries to run under the lock, got %v", err)
+	}
+	if applied := appliedVersions(t, migrator); len(applied) != 4 {
+		t.Errorf("Expected every migration to be applied, got %v", applied)
+	}
+	if !db.Migrator().HasTable("users") {
+		t.Error("Expected the migrations to be committed")
+	}
+}
+
+func TestMigration_SplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "one statement per line",
+			sql:  "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want: []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name: "statement over several lines",
+			sql:  "CREATE TABLE a (\n  id INT\n);",
+			want: []string{"CREATE TABLE a (\n  id INT\n)"},
+		},
+		{
+			name: "several statements on one line",
+			sql:  "DROP TABLE a; DROP TABLE b;",
+			want: []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name: "semicolons inside quotes",
+			sql:  "INSERT INTO a VALUES ('x;y', 'it''s;');\nSELECT \"a;b\", `c;d` FROM a;",
+			want: []string{"INSERT INTO a VALUES ('x;y', 'it''s;')", "SELECT \"a;b\", `c;d` FROM a"},
+		},
+		{
+			name: "semicolons inside comments",
+			sql:  "-- drop a; then b;\nDROP TABLE a; -- done;\n/* not; a statement */ DROP TABLE b;",
+			want: []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name: "comment marker inside quotes",
+			sql:  "INSERT INTO a VALUES ('--not a comment', '/*;*/');",
+			want: []string{"INSERT INTO a VALUES ('--not a comment', '/*;*/')"},
+		},
+		{
+			name: "missing final semicolon",
+			sql:  "DROP TABLE a;\nDROP TABLE b\n",
+			want: []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name: "only comments",
+			sql:  "-- nothing here\n\n/* or here */\n",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := migration.SplitStatements(tt.sql); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMigration_EmbeddedFilesAreConsistent(t *testing.T) {
+	var reference []migration.Migration
+	for _, dialect := range []string{"sqlite", "mysql", "postgres"} {
+		migrations, err := migration.Embedded(dialect)
+		if err != nil {
+			t.Fatalf("Expected the %s migrations to load, got %v", dialect, err)
+		}
+
+		for i, m := range migrations {
+			if m.Version != int64(i+1) {
+				t.Errorf("Expected %s migration %d to have version %d, got %d", dialect, i, i+1, m.Version)
+			}
+			if len(migration.SplitStatements(m.Up)) == 0 || len(migration.SplitStatements(m.Down)) == 0 {
+				t.Errorf("Expected %s migration %d to have up and down statements", dialect, m.Version)
+			}
+		}
+
+		if reference == nil {
+			reference = migrations
+			continue
+		}
+		if len(migrations) != len(reference) {
+			t.Fatalf("Expected %d %s migrations, got %d", len(reference), dialect, len(migrations))
+		}
+		for i := range migrations {
+			if migrations[i].Version != reference[i].Version || migrations[i].Name != reference[i].Name {
+				t.Errorf("Expected %s migration %d to be %s, got %s", dialect, reference[i].Version, reference[i].Name, migrations[i].Name)
+			}
+		}
+	}
+}
+
+func TestMigration_LoadRejectsBrokenFiles(t *testing.T) {
+	file := func(sql string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(sql)} }
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name: "missing down file",
+			files: fstest.MapFS{
+				"sql/000001_init.up.sql":   file("CREATE TABLE a (id INT);"),
+				"sql/000001_init.down.sql": file("DROP TABLE a;"),
+				"sql/000002_more.up.sql":   file("CREATE TABLE b (id INT);"),
+			},
+		},
+		{
+			name: "duplicate version with two names",
+			files: fstest.MapFS{
+				"sql/000001_init.up.sql":    file("CREATE TABLE a (id INT);"),
+				"sql/000001_init.down.sql":  file("DROP TABLE a;"),
+				"sql/000001_other.up.sql":   file("CREATE TABLE b (id INT);"),
+				"sql/000001_other.down.sql": file("DROP TABLE b;"),
+			},
+		},
+		{
+			name: "duplicate version with the same name",
+			files: fstest.MapFS{
+				"sql/000001_init.up.sql":   file("CREATE TABLE a (id INT);"),
+				"sql/000001_init.down.sql": file("DROP TABLE a;"),
+				"sql/1_init.up.sql":        file("CREATE TABLE b (id INT);"),
+				"sql/1_init.down.sql":      file("DROP TABLE b;"),
+			},
+		},
+		{
+			name: "badly named file",
+			files: fstest.MapFS{
+				"sql/init.sql": file("CREATE TABLE a (id INT);"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if _, err := migration.Load(tt.files, "sql"); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	migrations, err := migration.Load(fstest.MapFS{
+		"sql/000002_more.up.sql":   file("CREATE TABLE b (id INT);"),
+		"sql/000002_more.down.sql": file("DROP TABLE b;"),
+		"sql/000001_init.up.sql":   file("CREATE TABLE a (id INT);"),
+		"sql/000001_init.down.sql": file("DROP TABLE a;"),
+	}, "sql")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Name != "more" {
+		t.Errorf("Expected the migrations sorted by version, got %v", migrations)
+	}
+}
@@ -0,0 +1,319 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/mail"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/outbox"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/utils"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var discardLog = logger.New(io.Discard, logger.FormatText, "error")
+
+// failingMailer fails every send and counts the attempts.
+type failingMailer struct {
+	attempts atomic.Int32
+}
+
+func (m *failingMailer) Send(context.Context, *mail.Message) error {
+	m.attempts.Add(1)
+	return errors.New("connection refused")
+}
+
+type failingSender struct{}
+
+func (failingSender) SendTemplate(context.Context, string, string, any) error {
+	return errors.New("outbox unavailable")
+}
+
+func newOutboxAuthService(db *gorm.DB, sender mail.Sender) services.AuthService {
+	users := repository.NewUserRepository(db)
+	return services.NewAuthService(
+		repository.NewAuthRepository(db),
+		users,
+		repository.NewRefreshTokenRepository(db),
+		repository.NewSessionRepository(db),
+		repository.NewRecoveryCodeRepository(db),
+		services.NewLockoutService(repository.NewAuthThrottleRepository(db), users),
+		repository.NewRoleRepository(db),
+		sender,
+		repository.NewTransactor(db),
+		audit.NewRecorder(repository.NewAuditRepository(db)),
+	)
+}
+
+func createOutboxUser(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	user := &models.User{Name: "John", Email: "john@example.com", Password: "hash"}
+	if err := repository.NewUserRepository(db).CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+// runWorker runs an outbox worker until the test ends.
+func runWorker(t *testing.T, db *gorm.DB, mailer mail.Mailer, options outbox.Options) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		outbox.NewWorker(repository.NewOutboxRepository(db), mailer, options, discardLog).Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitForMessage polls message id until it has status or the wait times out.
+func waitForMessage(t *testing.T, db *gorm.DB, id int64, status string) *models.OutboxMessage {
+	t.Helper()
+	repo := repository.NewOutboxRepository(db)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		message, err := repo.GetById(context.Background(), id)
+		if err == nil && message != nil && message.Status == status {
+			return message
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected message %d to become %s, got %+v (error %v)", id, status, message, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestOutbox_ForgotPasswordQueuesEmail(t *testing.T) {
+	db := openSQLite(t)
+	createOutboxUser(t, db)
+
+	sender := outbox.NewSender(repository.NewOutboxRepository(db), loadTemplates(t))
+	if err := newOutboxAuthService(db, sender).ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: "john@example.com"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var messages []models.OutboxMessage
+	db.Find(&messages)
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 queued message, got %d", len(messages))
+	}
+	message := messages[0]
+	if message.Status != models.OutboxPending || message.Recipient != "john@example.com" || message.Template != mail.TemplatePasswordReset {
+		t.Errorf("Expected a pending password reset email to the user, got %+v", message)
+	}
+	if message.Subject == "" || message.Text == "" || message.HTML == "" {
+		t.Error("Expected the message to be rendered when queued")
+	}
+}
+
+func TestOutbox_ForgotPasswordRollsBackWhenQueueingFails(t *testing.T) {
+	db := openSQLite(t)
+	createOutboxUser(t, db)
+
+	err := newOutboxAuthService(db, failingSender{}).ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: "john@example.com"})
+	if err == nil {
+		t.Fatal("Expected an error when the email cannot be queued")
+	}
+
+	user, _ := repository.NewUserRepository(db).GetUserByEmail(context.Background(), "john@example.com")
+	if user.OTPCode != nil {
+		t.Error("Expected the OTP not to be saved without its email")
+	}
+}
+
+func TestOutbox_WorkerDeliversAndErasesBodies(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewOutboxRepository(db)
+	message := &models.OutboxMessage{Template: "test", Recipient: "john@example.com", Subject: "Hello", Text: "code 123456", Status: models.OutboxPending, NextAttemptAt: time.Now()}
+	if err := repo.Create(context.Background(), message); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	mailer := mail.NewMemory()
+	runWorker(t, db, mailer, outbox.Options{Workers: 1, PollInterval: 10 * time.Millisecond, MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond, SendTimeout: time.Second})
+
+	sent := waitForMessage(t, db, message.Id, models.OutboxSent)
+	if sent.SentAt == nil || sent.Text != "" {
+		t.Errorf("Expected the delivery time to be set and the body erased, got %+v", sent)
+	}
+	delivered := mailer.Messages()
+	if len(delivered) != 1 || delivered[0].Text != "code 123456" {
+		t.Errorf("Expected the message to be delivered once, got %+v", delivered)
+	}
+}
+
+func TestOutbox_WorkerRetriesThenGivesUp(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewOutboxRepository(db)
+	message := &models.OutboxMessage{Template: "test", Recipient: "john@example.com", Subject: "Hello", Text: "Hi", Status: models.OutboxPending, NextAttemptAt: time.Now()}
+	if err := repo.Create(context.Background(), message); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	mailer := &failingMailer{}
+	runWorker(t, db, mailer, outbox.Options{Workers: 1, PollInterval: 10 * time.Millisecond, MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, SendTimeout: time.Second})
+
+	dead := waitForMessage(t, db, message.Id, models.OutboxDead)
+	if dead.Attempts != 3 || dead.LastError != "connection refused" {
+		t.Errorf("Expected 3 attempts and the last error, got %+v", dead)
+	}
+	if dead.Text == "" {
+		t.Error("Expected a dead message to keep its body for requeueing")
+	}
+	if attempts := mailer.attempts.Load(); attempts != 3 {
+		t.Errorf("Expected 3 sends, got %d", attempts)
+	}
+}
+
+func TestOutbox_Backoff(t *testing.T) {
+	base, limit := 30*time.Second, 5*time.Minute
+	expected := map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 4: 4 * time.Minute, 5: 5 * time.Minute, 20: 5 * time.Minute}
+	for attempts, delay := range expected {
+		if got := utils.Backoff(attempts, base, limit); got != delay {
+			t.Errorf("Expected a delay of %v after %d attempts, got %v", delay, attempts, got)
+		}
+	}
+}
+
+func TestOutbox_ClaimLeasesMessageOnce(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewOutboxRepository(db)
+	now := time.Now()
+	due := &models.OutboxMessage{Template: "test", Recipient: "a@example.com", Subject: "Due", Status: models.OutboxPending, NextAttemptAt: now.Add(-time.Second)}
+	later := &models.OutboxMessage{Template: "test", Recipient: "b@example.com", Subject: "Later", Status: models.OutboxPending, NextAttemptAt: now.Add(time.Hour)}
+	for _, message := range []*models.OutboxMessage{due, later} {
+		if err := repo.Create(context.Background(), message); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	claimed, err := repo.Claim(context.Background(), now, time.Minute, 10)
+	if err != nil || len(claimed) != 1 || claimed[0].Id != due.Id {
+		t.Fatalf("Expected only the due message to be claimed, got %+v (error %v)", claimed, err)
+	}
+	if again, _ := repo.Claim(context.Background(), now, time.Minute, 10); len(again) != 0 {
+		t.Errorf("Expected a leased message not to be claimed again, got %d", len(again))
+	}
+	if expired, _ := repo.Claim(context.Background(), now.Add(2*time.Minute), time.Minute, 10); len(expired) != 1 {
+		t.Errorf("Expected the message to be claimable once its lease ran out, got %d", len(expired))
+	}
+}
+
+func setupOutboxRouter(db *gorm.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := controllers.NewOutboxController(services.NewOutboxService(repository.NewOutboxRepository(db)))
+	router.GET("/mail/outbox", controller.ListMessages)
+	router.POST("/mail/outbox/requeue", controller.RequeueDead)
+	router.GET("/mail/outbox/:id", controller.GetMessage)
+	router.POST("/mail/outbox/:id/requeue", controller.Requeue)
+	return router
+}
+
+func TestOutbox_ListHidesBodies(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewOutboxRepository(db)
+	for _, status := range []string{models.OutboxSent, models.OutboxDead} {
+		message := &models.OutboxMessage{Template: "test", Recipient: "john@example.com", Subject: status, Text: "secret 123456", HTML: "<p>secret 123456</p>", Status: status, NextAttemptAt: time.Now()}
+		if err := repo.Create(context.Background(), message); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	setupOutboxRouter(db).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/mail/outbox?status=dead", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if strings.Contains(w.Body.String(), "123456") {
+		t.Error("Expected message bodies to stay out of the response")
+	}
+
+	var body struct {
+		Data     []models.OutboxMessage `json:"data"`
+		Paginate dto.Paginate           `json:"paginate"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Data) != 1 || body.Data[0].Status != models.OutboxDead || body.Paginate.Total != 1 {
+		t.Errorf("Expected only the dead message, got %+v", body)
+	}
+}
+
+func TestOutbox_RequeueDeadMessage(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewOutboxRepository(db)
+	dead := &models.OutboxMessage{Template: "test", Recipient: "john@example.com", Subject: "Hello", Status: models.OutboxDead, Attempts: 8, LastError: "timeout", NextAttemptAt: time.Now().Add(-time.Hour)}
+	sent := &models.OutboxMessage{Template: "test", Recipient: "john@example.com", Subject: "Hello", Status: models.OutboxSent, NextAttemptAt: time.Now()}
+	for _, message := range []*models.OutboxMessage{dead, sent} {
+		if err := repo.Create(context.Background(), message); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	router := setupOutboxRouter(db)
+
+	requeue := func(id int64) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mail/outbox/"+strconv.FormatInt(id, 10)+"/requeue", nil))
+		return w.Code
+	}
+
+	if code := requeue(dead.Id); code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+	}
+	message, _ := repo.GetById(context.Background(), dead.Id)
+	if message.Status != models.OutboxPending || message.Attempts != 0 {
+		t.Errorf("Expected the message to be pending with fresh attempts, got %+v", message)
+	}
+
+	if code := requeue(sent.Id); code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a sent message, got %d", http.StatusBadRequest, code)
+	}
+	if code := requeue(9999); code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown message, got %d", http.StatusNotFound, code)
+	}
+}
+
+func TestOutbox_RequeueAllDeadMessages(t *testing.T) {
+	db := openSQLite(t)
+	repo := repository.NewOutboxRepository(db)
+	for range 2 {
+		message := &models.OutboxMessage{Template: "test", Recipient: "john@example.com", Subject: "Hello", Status: models.OutboxDead, Attempts: 8, NextAttemptAt: time.Now()}
+		if err := repo.Create(context.Background(), message); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	setupOutboxRouter(db).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mail/outbox/requeue", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var body struct {
+		Data dto.RequeueResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body.Data.Requeued != 2 {
+		t.Errorf("Expected 2 messages requeued, got %d", body.Data.Requeued)
+	}
+}
@@ -0,0 +1,285 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MockRoleService struct {
+	listRolesFunc        func() ([]models.Role, error)
+	getRoleFunc          func(id int) (*models.Role, error)
+	createRoleFunc       func(req *dto.CreateRoleRequest) (*models.Role, error)
+	updateRoleFunc       func(id int, req *dto.UpdateRoleRequest) (*models.Role, error)
+	deleteRoleFunc       func(id int) error
+	listPermissionsFunc  func() ([]models.Permission, error)
+	createPermissionFunc func(req *dto.CreatePermissionRequest) (*models.Permission, error)
+	deletePermissionFunc func(id int) error
+	assignRolesFunc      func(userId int, req *dto.AssignRolesRequest) error
+}
+
+func (m *MockRoleService) ListRoles() ([]models.Role, error) {
+	if m.listRolesFunc != nil {
+		return m.listRolesFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockRoleService) GetRole(id int) (*models.Role, error) {
+	if m.getRoleFunc != nil {
+		return m.getRoleFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *MockRoleService) CreateRole(_ context.Context, req *dto.CreateRoleRequest) (*models.Role, error) {
+	if m.createRoleFunc != nil {
+		return m.createRoleFunc(req)
+	}
+	return nil, nil
+}
+
+func (m *MockRoleService) UpdateRole(_ context.Context, id int, req *dto.UpdateRoleRequest) (*models.Role, error) {
+	if m.updateRoleFunc != nil {
+		return m.updateRoleFunc(id, req)
+	}
+	return nil, nil
+}
+
+func (m *MockRoleService) DeleteRole(_ context.Context, id int) error {
+	if m.deleteRoleFunc != nil {
+		return m.deleteRoleFunc(id)
+	}
+	return nil
+}
+
+func (m *MockRoleService) ListPermissions() ([]models.Permission, error) {
+	if m.listPermissionsFunc != nil {
+		return m.listPermissionsFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockRoleService) CreatePermission(_ context.Context, req *dto.CreatePermissionRequest) (*models.Permission, error) {
+	if m.createPermissionFunc != nil {
+		return m.createPermissionFunc(req)
+	}
+	return nil, nil
+}
+
+func (m *MockRoleService) DeletePermission(_ context.Context, id int) error {
+	if m.deletePermissionFunc != nil {
+		return m.deletePermissionFunc(id)
+	}
+	return nil
+}
+
+func (m *MockRoleService) AssignRoles(_ context.Context, userId int, req *dto.AssignRolesRequest) error {
+	if m.assignRolesFunc != nil {
+		return m.assignRolesFunc(userId, req)
+	}
+	return nil
+}
+
+func TestListRoles_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockRoleService{
+		listRolesFunc: func() ([]models.Role, error) {
+			return []models.Role{
+				{Id: 1, Name: "admin", Permissions: []models.Permission{{Id: 1, Name: "users:read"}}},
+				{Id: 2, Name: "user"},
+			}, nil
+		},
+	}
+	controller := controllers.NewRoleController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	controller.ListRoles(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Errorf("Failed to unmarshal response: %v", err)
+	}
+
+	data, ok := response["data"].([]interface{})
+	if !ok || len(data) != 2 {
+		t.Fatalf("Expected two roles in response, got %v", response["data"])
+	}
+}
+
+func TestGetRole_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockRoleService{
+		getRoleFunc: func(id int) (*models.Role, error) {
+			return nil, &errorhandler.NotFoundError{Message: "role not found"}
+		},
+	}
+	controller := controllers.NewRoleController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = []gin.Param{{Key: "id", Value: "99"}}
+
+	controller.GetRole(c)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestCreateRole_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var created *dto.CreateRoleRequest
+	mockService := &MockRoleService{
+		createRoleFunc: func(req *dto.CreateRoleRequest) (*models.Role, error) {
+			created = req
+			return &models.Role{Id: 3, Name: req.Name}, nil
+		},
+	}
+	controller := controllers.NewRoleController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/roles", strings.NewReader(`{"name":"editor","permissions":["users:read"]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.CreateRole(c)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	if created == nil || created.Name != "editor" || len(created.Permissions) != 1 {
+		t.Errorf("Expected role editor with one permission, got %+v", created)
+	}
+}
+
+func TestCreateRole_ValidationError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockRoleService{}
+	controller := controllers.NewRoleController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/roles", strings.NewReader(`{"description":"no name"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.CreateRole(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDeleteRole_DefaultRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockRoleService{
+		deleteRoleFunc: func(id int) error {
+			return &errorhandler.BadRequestError{Message: "default roles cannot be deleted"}
+		},
+	}
+	controller := controllers.NewRoleController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = []gin.Param{{Key: "id", Value: "1"}}
+	c.Request = httptest.NewRequest("DELETE", "/roles/1", nil)
+
+	controller.DeleteRole(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestAssignRoles_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var assignedUser int
+	var assignedRoles []string
+	mockService := &MockRoleService{
+		assignRolesFunc: func(userId int, req *dto.AssignRolesRequest) error {
+			assignedUser = userId
+			assignedRoles = req.Roles
+			return nil
+		},
+	}
+	controller := controllers.NewRoleController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = []gin.Param{{Key: "id", Value: "2"}}
+	c.Request = httptest.NewRequest("PUT", "/user/2/roles", strings.NewReader(`{"roles":["admin","user"]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.AssignRoles(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if assignedUser != 2 || len(assignedRoles) != 2 {
+		t.Errorf("Expected two roles assigned to user 2, got %v for user %d", assignedRoles, assignedUser)
+	}
+}
+
+func TestAssignRoles_InvalidUserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockRoleService{}
+	controller := controllers.NewRoleController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = []gin.Param{{Key: "id", Value: "invalid"}}
+
+	controller.AssignRoles(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestRequirePermission_Granted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	verifiedAt := time.Now()
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com", EmailVerifiedAt: &verifiedAt})
+	c.Set("permissions", []string{models.PermissionUsersDelete})
+
+	middleware.RequirePermission(models.PermissionUsersDelete)(c)
+
+	if c.IsAborted() {
+		t.Errorf("Expected request to pass, got status %d", w.Code)
+	}
+}
+
+func TestRequirePermission_Missing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
+	c.Set("permissions", []string{models.PermissionUsersRead})
+
+	middleware.RequirePermission(models.PermissionUsersDelete)(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -0,0 +1,178 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/health"
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/server"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServer_ShutsDownGracefully(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	router := gin.New()
+	router.GET("/readyz", controllers.NewHealthController(health.NewRegistry()).Ready)
+	router.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	baseURL := "http://" + listener.Addr().String()
+
+	srv := server.NewServer("", router, server.Timeouts{
+		Read:         time.Second,
+		ReadHeader:   time.Second,
+		Write:        5 * time.Second,
+		Idle:         time.Second,
+		DrainDelay:   200 * time.Millisecond,
+		GracePeriod:  5 * time.Second,
+		CloseTimeout: time.Second,
+	}, logger.New(io.Discard, logger.FormatText, "error"))
+
+	var closed []string
+	srv.OnShutdown("database", func(context.Context) error {
+		closed = append(closed, "database")
+		return nil
+	})
+	srv.OnShutdown("workers", func(context.Context) error {
+		closed = append(closed, "workers")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, listener)
+	}()
+
+	if status := getStatus(t, baseURL+"/readyz"); status != http.StatusOK {
+		t.Fatalf("Expected ready status %d before shutdown, got %d", http.StatusOK, status)
+	}
+
+	slow := make(chan string, 1)
+	go func() {
+		res, err := http.Get(baseURL + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		slow <- string(body)
+	}()
+	<-started
+
+	cancel()
+
+	// During the drain delay the server still accepts requests but reports
+	// that it is not ready.
+	time.Sleep(50 * time.Millisecond)
+	if status := getStatus(t, baseURL+"/readyz"); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected ready status %d while draining, got %d", http.StatusServiceUnavailable, status)
+	}
+
+	close(release)
+	if body := <-slow; body != "done" {
+		t.Errorf("Expected in-flight request to complete, got %q", body)
+	}
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not shut down")
+	}
+
+	if len(closed) != 2 || closed[0] != "workers" || closed[1] != "database" {
+		t.Errorf("Expected closers to run in reverse order, got %v", closed)
+	}
+
+	if _, err := http.Get(baseURL + "/readyz"); err == nil {
+		t.Error("Expected server to stop accepting connections")
+	}
+}
+
+func TestServer_ClosersGetTheirOwnTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	router := gin.New()
+	router.GET("/stuck", func(c *gin.Context) {
+		close(started)
+		<-release
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	srv := server.NewServer("", router, server.Timeouts{
+		Read:         time.Second,
+		ReadHeader:   time.Second,
+		Write:        5 * time.Second,
+		Idle:         time.Second,
+		GracePeriod:  100 * time.Millisecond,
+		CloseTimeout: time.Second,
+	}, logger.New(io.Discard, logger.FormatText, "error"))
+
+	var closeErr error
+	srv.OnShutdown("outbox", func(ctx context.Context) error {
+		closeErr = ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, listener)
+	}()
+	go http.Get("http://" + listener.Addr().String() + "/stuck")
+	<-started
+
+	cancel()
+
+	select {
+	case err := <-served:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the stuck request to exceed the grace period, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not shut down")
+	}
+
+	if closeErr != nil {
+		t.Errorf("Expected the closer to get a live context after the grace period ran out, got %v", closeErr)
+	}
+}
+
+func getStatus(t *testing.T, url string) int {
+	t.Helper()
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	res.Body.Close()
+
+	return res.StatusCode
+}
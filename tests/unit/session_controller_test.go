@@ -0,0 +1,222 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/models"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MockSessionService struct {
+	listSessionsFunc        func(userId int, currentSessionId string) ([]dto.SessionResponse, error)
+	revokeSessionFunc       func(userId int, sessionId string) error
+	revokeOtherSessionsFunc func(userId int, currentSessionId string) error
+	revokeAllSessionsFunc   func(userId int) error
+}
+
+func (m *MockSessionService) ListSessions(ctx context.Context, userId int, currentSessionId string) ([]dto.SessionResponse, error) {
+	if m.listSessionsFunc != nil {
+		return m.listSessionsFunc(userId, currentSessionId)
+	}
+	return nil, nil
+}
+
+func (m *MockSessionService) RevokeSession(ctx context.Context, userId int, sessionId string) error {
+	if m.revokeSessionFunc != nil {
+		return m.revokeSessionFunc(userId, sessionId)
+	}
+	return nil
+}
+
+func (m *MockSessionService) RevokeOtherSessions(ctx context.Context, userId int, currentSessionId string) error {
+	if m.revokeOtherSessionsFunc != nil {
+		return m.revokeOtherSessionsFunc(userId, currentSessionId)
+	}
+	return nil
+}
+
+func (m *MockSessionService) RevokeAllSessions(ctx context.Context, userId int) error {
+	if m.revokeAllSessionsFunc != nil {
+		return m.revokeAllSessionsFunc(userId)
+	}
+	return nil
+}
+
+func TestListSessions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockSessionService{
+		listSessionsFunc: func(userId int, currentSessionId string) ([]dto.SessionResponse, error) {
+			return []dto.SessionResponse{{ID: currentSessionId, Current: true}}, nil
+		},
+	}
+	controller := controllers.NewSessionController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
+	c.Set("sessionId", "session-1")
+
+	controller.ListSessions(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Errorf("Failed to unmarshal response: %v", err)
+	}
+
+	data, ok := response["data"].([]interface{})
+	if !ok || len(data) != 1 {
+		t.Fatalf("Expected one session in response, got %v", response["data"])
+	}
+
+	if session := data[0].(map[string]interface{}); session["current"] != true {
+		t.Errorf("Expected current session to be flagged, got %v", session["current"])
+	}
+}
+
+func TestListSessions_InvalidUserContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockSessionService{}
+	controller := controllers.NewSessionController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	controller.ListSessions(c)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestRevokeSession_Success_CurrentSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockSessionService{
+		revokeSessionFunc: func(userId int, sessionId string) error {
+			return nil
+		},
+	}
+	controller := controllers.NewSessionController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/sessions/session-1", nil)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
+	c.Set("sessionId", "session-1")
+	c.Params = []gin.Param{{Key: "id", Value: "session-1"}}
+
+	controller.RevokeSession(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// Revoking the current session also logs the caller out
+	accessTokenCleared := false
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "accessToken" && cookie.Value == "" && cookie.MaxAge == -1 {
+			accessTokenCleared = true
+		}
+	}
+	if !accessTokenCleared {
+		t.Error("Expected accessToken cookie to be cleared when revoking the current session")
+	}
+}
+
+func TestRevokeSession_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockSessionService{
+		revokeSessionFunc: func(userId int, sessionId string) error {
+			return &errorhandler.NotFoundError{Message: "session not found"}
+		},
+	}
+	controller := controllers.NewSessionController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/sessions/unknown", nil)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
+	c.Params = []gin.Param{{Key: "id", Value: "unknown"}}
+
+	controller.RevokeSession(c)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRevokeOtherSessions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var keptSession string
+	mockService := &MockSessionService{
+		revokeOtherSessionsFunc: func(userId int, currentSessionId string) error {
+			keptSession = currentSessionId
+			return nil
+		},
+	}
+	controller := controllers.NewSessionController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/sessions/others", nil)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
+	c.Set("sessionId", "session-1")
+
+	controller.RevokeOtherSessions(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if keptSession != "session-1" {
+		t.Errorf("Expected current session 'session-1' to be kept, got '%v'", keptSession)
+	}
+}
+
+func TestRevokeUserSessions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockSessionService{
+		revokeAllSessionsFunc: func(userId int) error {
+			return nil
+		},
+	}
+	controller := controllers.NewSessionController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/user/2/sessions", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "2"}}
+
+	controller.RevokeUserSessions(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRevokeUserSessions_InvalidUserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockSessionService{}
+	controller := controllers.NewSessionController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = []gin.Param{{Key: "id", Value: "invalid"}}
+
+	controller.RevokeUserSessions(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
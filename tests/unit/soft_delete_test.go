@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+
+	"gorm.io/gorm"
+)
+
+// createUserWithRole creates a user with the user role through the repository.
+func createUserWithRole(t *testing.T, db *gorm.DB, email string) *models.User {
+	t.Helper()
+	roles, _ := repository.NewRoleRepository(db).GetByNames([]string{models.RoleUser})
+	user := &models.User{Name: "John", Email: email, Password: "hash", Roles: roles}
+	if err := repository.NewUserRepository(db).CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return user
+}
+
+func newSoftDeleteService(db *gorm.DB) services.UserService {
+	return services.NewUserService(
+		repository.NewUserRepository(db),
+		repository.NewRoleRepository(db),
+		repository.NewTransactor(db),
+		audit.NewRecorder(repository.NewAuditRepository(db)),
+	)
+}
+
+func TestSoftDelete_DeleteKeepsRowAndHidesUser(t *testing.T) {
+	db := openSQLite(t)
+	user := createUserWithRole(t, db, "john@example.com")
+	service := newSoftDeleteService(db)
+	ctx := context.Background()
+
+	if err := service.DeleteUser(ctx, user.Id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var stored models.User
+	if err := db.Unscoped().First(&stored, user.Id).Error; err != nil {
+		t.Fatalf("Expected the row to be kept, got %v", err)
+	}
+	if !stored.DeletedAt.Valid {
+		t.Error("Expected deleted_at to be set")
+	}
+
+	users := repository.NewUserRepository(db)
+	if found, _ := users.GetUserByID(ctx, user.Id); found != nil {
+		t.Error("Expected GetUserByID to skip the deleted user")
+	}
+	if found, _ := users.GetUserByEmail(ctx, user.Email); found != nil {
+		t.Error("Expected GetUserByEmail to skip the deleted user")
+	}
+	if _, total, _ := users.GetAllUsers(ctx, repository.UserFilter{Limit: 10}); total != 0 {
+		t.Errorf("Expected no active users, got %d", total)
+	}
+
+	authRepo := repository.NewAuthRepository(db)
+	if _, err := authRepo.GetUserById(ctx, user.Id); err == nil {
+		t.Error("Expected GetUserById to skip the deleted user")
+	}
+	if !authRepo.EmailExists(ctx, user.Email) {
+		t.Error("Expected the email of a deleted user to stay taken")
+	}
+
+	if err := service.DeleteUser(ctx, user.Id); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected deleting twice to fail with not found, got %v", err)
+	}
+}
+
+func TestSoftDelete_RestoreUser(t *testing.T) {
+	db := openSQLite(t)
+	user := createUserWithRole(t, db, "john@example.com")
+	service := newSoftDeleteService(db)
+	ctx := context.Background()
+
+	var notFound *errorhandler.NotFoundError
+	if err := service.RestoreUser(ctx, user.Id); !errors.As(err, &notFound) {
+		t.Errorf("Expected restoring an active user to fail with not found, got %v", err)
+	}
+
+	if err := service.DeleteUser(ctx, user.Id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := service.RestoreUser(ctx, user.Id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	restored, err := service.GetUserByID(ctx, user.Id)
+	if err != nil || restored == nil {
+		t.Fatalf("Expected the restored user, got %v %v", restored, err)
+	}
+	if restored.DeletedAt.Valid {
+		t.Error("Expected deleted_at to be cleared")
+	}
+	if len(restored.Roles) != 1 || restored.Roles[0].Name != models.RoleUser {
+		t.Errorf("Expected the roles to survive the delete, got %v", restored.RoleNames())
+	}
+
+	entries := auditEntries(t, db)
+	if len(entries) != 2 || entries[0].Action != audit.ActionUserDelete || entries[1].Action != audit.ActionUserRestore {
+		t.Errorf("Expected delete and restore entries, got %v", entries)
+	}
+}
+
+func TestSoftDelete_PurgeUser(t *testing.T) {
+	db := openSQLite(t)
+	user := createUserWithRole(t, db, "john@example.com")
+	other := createUserWithRole(t, db, "jane@example.com")
+	service := newSoftDeleteService(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	for _, id := range []int{user.Id, other.Id} {
+		db.Create(&models.Session{Id: "session-" + strconv.Itoa(id), UserId: id, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)})
+		db.Create(&models.RefreshToken{UserId: id, FamilyId: "family", JtiHash: "jti-" + strconv.Itoa(id), IssuedAt: now, ExpiresAt: now.Add(time.Hour)})
+		db.Create(&models.RecoveryCode{UserId: id, CodeHash: "code"})
+	}
+	for _, email := range []string{user.Email, other.Email} {
+		db.Create(&models.OutboxMessage{Template: "otp", Recipient: email, Subject: "OTP", Status: models.OutboxPending, NextAttemptAt: now})
+		db.Create(&models.AuthThrottle{Key: services.AccountLockoutKey(email), Failures: 1, LastFailureAt: now})
+	}
+
+	var notFound *errorhandler.NotFoundError
+	if err := service.PurgeUser(ctx, user.Id); !errors.As(err, &notFound) {
+		t.Errorf("Expected purging an active user to fail with not found, got %v", err)
+	}
+	if found, _ := service.GetUserByID(ctx, user.Id); found == nil {
+		t.Fatal("Expected the active user to be kept")
+	}
+
+	if err := service.DeleteUser(ctx, user.Id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := service.PurgeUser(ctx, user.Id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if db.Unscoped().First(&models.User{}, user.Id).Error == nil {
+		t.Error("Expected the row to be removed")
+	}
+	for _, model := range []any{&models.Session{}, &models.RefreshToken{}, &models.RecoveryCode{}} {
+		var purged, kept int64
+		db.Model(model).Where("user_id = ?", user.Id).Count(&purged)
+		db.Model(model).Where("user_id = ?", other.Id).Count(&kept)
+		if purged != 0 || kept != 1 {
+			t.Errorf("Expected only the purged user's %T rows to be deleted, got %d and %d left", model, purged, kept)
+		}
+	}
+	var mails, throttles int64
+	db.Model(&models.OutboxMessage{}).Where("recipient = ?", user.Email).Count(&mails)
+	db.Model(&models.AuthThrottle{}).Where("throttle_key = ?", services.AccountLockoutKey(user.Email)).Count(&throttles)
+	if mails != 0 || throttles != 0 {
+		t.Errorf("Expected the queued mail and lockout counters of the email to be deleted, got %d and %d", mails, throttles)
+	}
+	db.Model(&models.OutboxMessage{}).Count(&mails)
+	db.Model(&models.AuthThrottle{}).Count(&throttles)
+	if mails != 1 || throttles != 1 {
+		t.Errorf("Expected the other user's mail and counter to be kept, got %d and %d", mails, throttles)
+	}
+	var roles int64
+	db.Table("user_roles").Where("user_id = ?", user.Id).Count(&roles)
+	if roles != 0 {
+		t.Errorf("Expected the role assignments to be deleted, got %d", roles)
+	}
+	if repository.NewAuthRepository(db).EmailExists(ctx, user.Email) {
+		t.Error("Expected the email to be free after the purge")
+	}
+
+	entries := auditEntries(t, db)
+	last := entries[len(entries)-1]
+	if last.Action != audit.ActionUserPurge || last.TargetId != strconv.Itoa(user.Id) {
+		t.Errorf("Expected a purge entry for the user, got %s %s", last.Action, last.TargetId)
+	}
+}
+
+func TestSoftDelete_GetDeletedUsers(t *testing.T) {
+	db := openSQLite(t)
+	first := createUserWithRole(t, db, "first@example.com")
+	second := createUserWithRole(t, db, "second@example.com")
+	createUserWithRole(t, db, "active@example.com")
+	db.Model(&models.User{}).Where("id = ?", first.Id).Update("deleted_at", time.Now().Add(-time.Hour))
+	db.Model(&models.User{}).Where("id = ?", second.Id).Update("deleted_at", time.Now())
+
+	users, paginate, err := newSoftDeleteService(db).GetDeletedUsers(context.Background(), &dto.UserListQuery{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if paginate.Total != 2 || len(users) != 2 {
+		t.Fatalf("Expected 2 deleted users, got %d", paginate.Total)
+	}
+	if users[0].Email != "second@example.com" || users[1].Email != "first@example.com" {
+		t.Errorf("Expected the most recently deleted user first, got %s and %s", users[0].Email, users[1].Email)
+	}
+	if !users[0].DeletedAt.Valid {
+		t.Error("Expected deleted_at to be loaded")
+	}
+}
@@ -0,0 +1,56 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/middleware"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTokenSourceContext(req *http.Request) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	return c
+}
+
+func TestExtractToken_HeaderTakesPrecedence(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.Header.Set("Authorization", "Bearer header_token")
+	req.AddCookie(&http.Cookie{Name: "accessToken", Value: "cookie_token"})
+
+	token := middleware.ExtractToken(newTokenSourceContext(req), middleware.DefaultTokenSources...)
+
+	if token != "header_token" {
+		t.Errorf("Expected 'header_token', got '%s'", token)
+	}
+}
+
+func TestExtractToken_FallsBackToCookie(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	req.AddCookie(&http.Cookie{Name: "accessToken", Value: "cookie_token"})
+
+	token := middleware.ExtractToken(newTokenSourceContext(req), middleware.DefaultTokenSources...)
+
+	if token != "cookie_token" {
+		t.Errorf("Expected 'cookie_token', got '%s'", token)
+	}
+}
+
+func TestExtractToken_QueryOnlyOnWebSocket(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/ws?access_token=query_token", nil)
+
+	if token := middleware.ExtractToken(newTokenSourceContext(req), middleware.DefaultTokenSources...); token != "" {
+		t.Errorf("Expected no token outside a websocket handshake, got '%s'", token)
+	}
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+
+	if token := middleware.ExtractToken(newTokenSourceContext(req), middleware.DefaultTokenSources...); token != "query_token" {
+		t.Errorf("Expected 'query_token', got '%s'", token)
+	}
+}
@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"restApi-GoGin/src/audit"
+	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/logger"
+	"restApi-GoGin/src/middleware"
+	"restApi-GoGin/src/models"
+	"restApi-GoGin/src/repository"
+	"restApi-GoGin/src/services"
+	"restApi-GoGin/src/tracing"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that keeps every ended span in
+// memory, restoring the previous provider when the test ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanNamed(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestTracing_SpansFollowTheRequest(t *testing.T) {
+	recorder := recordSpans(t)
+	db := openSQLite(t)
+	if err := tracing.InstrumentDB(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	users := repository.NewUserRepository(db)
+	user := &models.User{Name: "Traced", Email: "traced@example.com", Password: "password123"}
+	if err := users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service := services.NewUserService(users, repository.NewRoleRepository(db), repository.NewTransactor(db), audit.NewRecorder(repository.NewAuditRepository(db)))
+	recorder.Reset()
+
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(otelgin.Middleware("test"), middleware.RequestID(logger.New(&logs, logger.FormatJSON, "info")))
+	router.GET("/user/:id", func(c *gin.Context) {
+		logger.FromContext(c.Request.Context()).Info("handling")
+	}, controllers.NewUserController(service).GetUserByID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/"+strconv.Itoa(int(user.Id)), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	spans := recorder.Ended()
+	request := spanNamed(spans, "GET /user/:id")
+	serviceSpan := spanNamed(spans, "UserService.GetUserByID")
+	query := spanNamed(spans, "db.query users")
+	if request == nil || serviceSpan == nil || query == nil {
+		t.Fatalf("Expected request, service and query spans, got %d spans", len(spans))
+	}
+	if serviceSpan.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Error("Expected the service span to be a child of the request span")
+	}
+	if query.Parent().SpanID() != serviceSpan.SpanContext().SpanID() {
+		t.Error("Expected the query span to be a child of the service span")
+	}
+	if !strings.Contains(logs.String(), `"trace_id":"`+request.SpanContext().TraceID().String()+`"`) {
+		t.Errorf("Expected the request log to carry the trace ID, got %s", logs.String())
+	}
+}
@@ -1,79 +1,112 @@
 package unit
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"restApi-GoGin/src/config"
 	"restApi-GoGin/src/controllers"
+	"restApi-GoGin/src/dto"
+	"restApi-GoGin/src/errorhandler"
 	"restApi-GoGin/src/models"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type MockUserService struct {
-	getAllUsersFunc    func() ([]models.User, error)
+	getAllUsersFunc    func(query *dto.UserListQuery) ([]models.User, *dto.Paginate, error)
 	getUserByEmailFunc func(email string) (*models.User, error)
 	getUserByIDFunc    func(id int) (*models.User, error)
 	createUserFunc     func(name, email, password, role string) error
 	updateUserFunc     func(id int, name, email, password, role *string) error // Tambahkan ini
 	deleteUserFunc     func(id int) error
+	getDeletedFunc     func(query *dto.UserListQuery) ([]models.User, *dto.Paginate, error)
+	restoreUserFunc    func(id int) error
+	purgeUserFunc      func(id int) error
 }
 
-func (m *MockUserService) GetAllUsers() ([]models.User, error) {
+func (m *MockUserService) GetAllUsers(ctx context.Context, query *dto.UserListQuery) ([]models.User, *dto.Paginate, error) {
 	if m.getAllUsersFunc != nil {
-		return m.getAllUsersFunc()
+		return m.getAllUsersFunc(query)
 	}
-	return nil, nil
+	return nil, nil, nil
 }
 
-func (m *MockUserService) GetUserByEmail(email string) (*models.User, error) {
+func (m *MockUserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	if m.getUserByEmailFunc != nil {
 		return m.getUserByEmailFunc(email)
 	}
 	return nil, nil
 }
 
-func (m *MockUserService) GetUserByID(id int) (*models.User, error) {
+func (m *MockUserService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	if m.getUserByIDFunc != nil {
 		return m.getUserByIDFunc(id)
 	}
 	return nil, nil
 }
 
-func (m *MockUserService) CreateUser(name, email, password, role string) error {
+func (m *MockUserService) CreateUser(ctx context.Context, name, email, password, role string) error {
 	if m.createUserFunc != nil {
 		return m.createUserFunc(name, email, password, role)
 	}
 	return nil
 }
 
-func (m *MockUserService) UpdateUser(id int, name, email, password, role *string) error {
+func (m *MockUserService) UpdateUser(ctx context.Context, id int, name, email, password, role *string) error {
 	if m.updateUserFunc != nil {
 		return m.updateUserFunc(id, name, email, password, role)
 	}
 	return nil
 }
 
-func (m *MockUserService) DeleteUser(id int) error {
+func (m *MockUserService) DeleteUser(ctx context.Context, id int) error {
 	if m.deleteUserFunc != nil {
 		return m.deleteUserFunc(id)
 	}
 	return nil
 }
 
+func (m *MockUserService) GetDeletedUsers(ctx context.Context, query *dto.UserListQuery) ([]models.User, *dto.Paginate, error) {
+	if m.getDeletedFunc != nil {
+		return m.getDeletedFunc(query)
+	}
+	return nil, nil, nil
+}
+
+func (m *MockUserService) RestoreUser(ctx context.Context, id int) error {
+	if m.restoreUserFunc != nil {
+		return m.restoreUserFunc(id)
+	}
+	return nil
+}
+
+func (m *MockUserService) PurgeUser(ctx context.Context, id int) error {
+	if m.purgeUserFunc != nil {
+		return m.purgeUserFunc(id)
+	}
+	return nil
+}
+
 func TestGetAllUsers_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockUserService{
-		getAllUsersFunc: func() ([]models.User, error) {
-			return []models.User{{Id: 1, Name: "User1", Email: "user1@example.com"}}, nil
+		getAllUsersFunc: func(query *dto.UserListQuery) ([]models.User, *dto.Paginate, error) {
+			return []models.User{{Id: 1, Name: "User1", Email: "user1@example.com"}}, &dto.Paginate{Page: 1, PerPage: 10, Total: 1, TotalPage: 1}, nil
 		},
 	}
 	controller := controllers.NewUserController(mockService)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/users", nil)
 
 	controller.GetAllUsers(c)
 
@@ -85,14 +118,15 @@ func TestGetAllUsers_Success(t *testing.T) {
 func TestGetAllUsers_Error(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockUserService{
-		getAllUsersFunc: func() ([]models.User, error) {
-			return nil, errors.New("mock error")
+		getAllUsersFunc: func(query *dto.UserListQuery) ([]models.User, *dto.Paginate, error) {
+			return nil, nil, errors.New("mock error")
 		},
 	}
 	controller := controllers.NewUserController(mockService)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/users", nil)
 
 	controller.GetAllUsers(c)
 
@@ -101,6 +135,79 @@ func TestGetAllUsers_Error(t *testing.T) {
 	}
 }
 
+func TestGetAllUsers_PaginatedAndFiltered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var received *dto.UserListQuery
+	mockService := &MockUserService{
+		getAllUsersFunc: func(query *dto.UserListQuery) ([]models.User, *dto.Paginate, error) {
+			received = query
+			return []models.User{{Id: 3, Name: "User3", Email: "user3@example.com"}}, &dto.Paginate{Page: 2, PerPage: 2, Total: 3, TotalPage: 2}, nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/users?page=2&per_page=2&role=admin&email=example.com&status=all&sort=-created_at,name", nil)
+
+	controller.GetAllUsers(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if received == nil || received.Page != 2 || received.PerPage != 2 || received.Role != "admin" ||
+		received.Email != "example.com" || received.Status != "all" || received.Sort != "-created_at,name" {
+		t.Errorf("Expected query parameters to be passed to the service, got %+v", received)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	paginate, ok := response["paginate"].(map[string]interface{})
+	if !ok || paginate["total"] != float64(3) || paginate["total_page"] != float64(2) {
+		t.Errorf("Expected paginate block with total 3 and total_page 2, got %v", response["paginate"])
+	}
+}
+
+func TestGetAllUsers_InvalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockUserService{}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/users?per_page=500&status=banned", nil)
+
+	controller.GetAllUsers(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestGetAllUsers_InvalidSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockUserService{
+		getAllUsersFunc: func(query *dto.UserListQuery) ([]models.User, *dto.Paginate, error) {
+			return nil, nil, &errorhandler.BadRequestError{Message: "cannot sort by password"}
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/users?sort=password", nil)
+
+	controller.GetAllUsers(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 func TestGetUserByEmail_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockUserService{
@@ -125,7 +232,7 @@ func TestGetUserByEmail_NotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockUserService{
 		getUserByEmailFunc: func(email string) (*models.User, error) {
-			return nil, errors.New("record not found")
+			return nil, gorm.ErrRecordNotFound
 		},
 	}
 	controller := controllers.NewUserController(mockService)
@@ -172,6 +279,7 @@ func TestGetUserByID_Success(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 	c.Params = []gin.Param{{Key: "id", Value: "1"}}
 
 	controller.GetUserByID(c)
@@ -192,6 +300,7 @@ func TestGetUserByID_NotFound(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 	c.Params = []gin.Param{{Key: "id", Value: "2"}}
 
 	controller.GetUserByID(c)
@@ -212,6 +321,7 @@ func TestGetUserByID_Error(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 	c.Params = []gin.Param{{Key: "id", Value: "3"}}
 
 	controller.GetUserByID(c)
@@ -324,7 +434,7 @@ func TestUpdateUser_NotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockUserService{
 		updateUserFunc: func(id int, name, email, password, role *string) error {
-			return errors.New("record not found")
+			return gorm.ErrRecordNotFound
 		},
 	}
 	controller := controllers.NewUserController(mockService)
@@ -375,7 +485,7 @@ func TestUpdateProfile_Success(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com", Role: "user"})
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
 	c.Request = httptest.NewRequest("PUT", "/user/profile", strings.NewReader(`{"name":"User Updated","email":"updated@example.com"}`))
 	c.Request.Header.Set("Content-Type", "application/json")
 
@@ -393,7 +503,7 @@ func TestUpdateProfile_ValidationError(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com", Role: "user"})
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
 	c.Request = httptest.NewRequest("PUT", "/user/profile", strings.NewReader(`{"email":"invalid"}`))
 	c.Request.Header.Set("Content-Type", "application/json")
 
@@ -415,7 +525,7 @@ func TestUpdateProfile_ServiceError(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com", Role: "user"})
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
 	c.Request = httptest.NewRequest("PUT", "/user/profile", strings.NewReader(`{"name":"User Updated"}`))
 	c.Request.Header.Set("Content-Type", "application/json")
 
@@ -455,7 +565,8 @@ func TestDeleteUser_Success_UserDeletingSelf(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com", Role: "user"})
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
 	c.Params = []gin.Param{{Key: "id", Value: "1"}}
 
 	controller.DeleteUser(c)
@@ -494,7 +605,10 @@ func TestDeleteUser_Success_AdminDeletingOtherUser(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com", Role: "admin"})
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	verifiedAt := time.Now()
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com", EmailVerifiedAt: &verifiedAt})
+	c.Set("permissions", []string{models.PermissionUsersDelete})
 	c.Params = []gin.Param{{Key: "id", Value: "2"}}
 
 	controller.DeleteUser(c)
@@ -523,7 +637,9 @@ func TestDeleteUser_Success_AdminDeletingSelf(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com", Role: "admin"})
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com"})
+	c.Set("permissions", []string{models.PermissionUsersDelete})
 	c.Params = []gin.Param{{Key: "id", Value: "1"}}
 
 	controller.DeleteUser(c)
@@ -558,7 +674,8 @@ func TestDeleteUser_Forbidden_UserDeletingOtherUser(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com", Role: "user"})
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
 	c.Params = []gin.Param{{Key: "id", Value: "2"}}
 
 	controller.DeleteUser(c)
@@ -575,7 +692,8 @@ func TestDeleteUser_InvalidUserID(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com", Role: "user"})
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
 	c.Params = []gin.Param{{Key: "id", Value: "invalid"}}
 
 	controller.DeleteUser(c)
@@ -589,14 +707,15 @@ func TestDeleteUser_UserNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := &MockUserService{
 		deleteUserFunc: func(id int) error {
-			return errors.New("record not found")
+			return gorm.ErrRecordNotFound
 		},
 	}
 	controller := controllers.NewUserController(mockService)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 999, Name: "User1", Email: "user1@example.com", Role: "user"})
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("user", &models.User{Id: 999, Name: "User1", Email: "user1@example.com"})
 	c.Params = []gin.Param{{Key: "id", Value: "999"}}
 
 	controller.DeleteUser(c)
@@ -617,7 +736,8 @@ func TestDeleteUser_ServiceError(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com", Role: "user"})
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("user", &models.User{Id: 1, Name: "User1", Email: "user1@example.com"})
 	c.Params = []gin.Param{{Key: "id", Value: "1"}}
 
 	controller.DeleteUser(c)
@@ -634,6 +754,7 @@ func TestDeleteUser_InvalidUserContext(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 	// Tidak set user context
 	c.Params = []gin.Param{{Key: "id", Value: "1"}}
 
@@ -643,3 +764,279 @@ func TestDeleteUser_InvalidUserContext(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
 	}
 }
+
+func TestGetDeletedUsers_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got *dto.UserListQuery
+	mockService := &MockUserService{
+		getDeletedFunc: func(query *dto.UserListQuery) ([]models.User, *dto.Paginate, error) {
+			got = query
+			return []models.User{{Id: 2, Name: "Gone", Email: "gone@example.com"}}, &dto.Paginate{Page: 1, PerPage: 10, Total: 1, TotalPage: 1}, nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/users/deleted?name=gone&sort=-deleted_at", nil)
+
+	controller.GetDeletedUsers(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if got == nil || got.Name != "gone" || got.Sort != "-deleted_at" {
+		t.Errorf("Expected the query to reach the service, got %+v", got)
+	}
+}
+
+func TestRestoreUser_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	restored := 0
+	mockService := &MockUserService{
+		restoreUserFunc: func(id int) error {
+			restored = id
+			return nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/user/2/restore", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "2"}}
+
+	controller.RestoreUser(c)
+
+	if w.Code != http.StatusOK || restored != 2 {
+		t.Errorf("Expected user 2 to be restored with status %d, got %d for user %d", http.StatusOK, w.Code, restored)
+	}
+}
+
+func TestRestoreUser_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockUserService{
+		restoreUserFunc: func(id int) error {
+			return &errorhandler.NotFoundError{Message: "deleted user not found"}
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/user/2/restore", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "2"}}
+
+	controller.RestoreUser(c)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestPurgeUser_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	purged := 0
+	mockService := &MockUserService{
+		purgeUserFunc: func(id int) error {
+			purged = id
+			return nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/user/2/purge", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "2"}}
+
+	controller.PurgeUser(c)
+
+	if w.Code != http.StatusOK || purged != 2 {
+		t.Errorf("Expected user 2 to be purged with status %d, got %d for user %d", http.StatusOK, w.Code, purged)
+	}
+}
+
+func TestPurgeUser_InvalidUserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	controller := controllers.NewUserController(&MockUserService{})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/user/abc/purge", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "abc"}}
+
+	controller.PurgeUser(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUpdateProfile_AdminUpdatingOtherUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var updated int
+	mockService := &MockUserService{
+		updateUserFunc: func(id int, name, email, password, role *string) error {
+			updated = id
+			return nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	verifiedAt := time.Now()
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com", EmailVerifiedAt: &verifiedAt})
+	c.Set("permissions", []string{models.PermissionUsersUpdate})
+	c.Request = httptest.NewRequest("PUT", "/user/profile?id=2", strings.NewReader(`{"name":"User Updated"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.UpdateProfile(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if updated != 2 {
+		t.Errorf("Expected user 2 to be updated, got %d", updated)
+	}
+}
+
+func TestUpdateProfile_AdminWithoutMFAForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := config.ENV
+	config.ENV = &config.Config{MFA_REQUIRED_ROLES: models.RoleAdmin}
+	defer func() { config.ENV = previous }()
+
+	mockService := &MockUserService{
+		updateUserFunc: func(id int, name, email, password, role *string) error {
+			t.Error("Expected the update to be refused before the service")
+			return nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com"})
+	c.Set("permissions", []string{models.PermissionUsersUpdate})
+	c.Set("roles", []string{models.RoleAdmin})
+	c.Request = httptest.NewRequest("PUT", "/user/profile?id=2", strings.NewReader(`{"name":"User Updated"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.UpdateProfile(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestDeleteUser_AdminWithUnverifiedEmailForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := config.ENV
+	config.ENV = &config.Config{EMAIL_VERIFICATION_POLICY: config.EmailVerificationSensitive}
+	defer func() { config.ENV = previous }()
+
+	mockService := &MockUserService{
+		deleteUserFunc: func(id int) error {
+			t.Error("Expected the delete to be refused before the service")
+			return nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/", nil)
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com"})
+	c.Set("permissions", []string{models.PermissionUsersDelete})
+	c.Params = []gin.Param{{Key: "id", Value: "2"}}
+
+	controller.DeleteUser(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestCreateUser_RoleWithoutRolesManageForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockUserService{
+		createUserFunc: func(name, email, password, role string) error {
+			t.Error("Expected the create to be refused before the service")
+			return nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com"})
+	c.Set("permissions", []string{models.PermissionUsersCreate})
+	c.Request = httptest.NewRequest("POST", "/user", strings.NewReader(`{"name":"User1","email":"user1@example.com","password":"password123","password_confirm":"password123"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.PostForm = url.Values{"role": {models.RoleAdmin}}
+
+	controller.CreateUser(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestUpdateUser_RoleWithoutRolesManageForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := &MockUserService{
+		updateUserFunc: func(id int, name, email, password, role *string) error {
+			t.Error("Expected the update to be refused before the service")
+			return nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com"})
+	c.Set("permissions", []string{models.PermissionUsersUpdate})
+	c.Params = []gin.Param{{Key: "id", Value: "2"}}
+	c.Request = httptest.NewRequest("PUT", "/user/2", strings.NewReader(`{"role":"admin"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.UpdateUser(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestUpdateUser_RoleWithRolesManage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var updatedRole string
+	mockService := &MockUserService{
+		updateUserFunc: func(id int, name, email, password, role *string) error {
+			if role != nil {
+				updatedRole = *role
+			}
+			return nil
+		},
+	}
+	controller := controllers.NewUserController(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	verifiedAt := time.Now()
+	c.Set("user", &models.User{Id: 1, Name: "Admin", Email: "admin@example.com", EmailVerifiedAt: &verifiedAt})
+	c.Set("permissions", []string{models.PermissionUsersUpdate, models.PermissionRolesManage})
+	c.Params = []gin.Param{{Key: "id", Value: "2"}}
+	c.Request = httptest.NewRequest("PUT", "/user/2", strings.NewReader(`{"role":"admin"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	controller.UpdateUser(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if updatedRole != models.RoleAdmin {
+		t.Errorf("Expected the role to be passed to the service, got %q", updatedRole)
+	}
+}